@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go.yaml.in/yaml/v3"
+)
+
+// printConfig 以YAML格式打印默认值、配置文件和环境变量合并后的配置，敏感项已脱敏。
+// 开头的注释按合并顺序列出已加载的配置文件
+func printConfig(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(opts)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	for _, file := range cfg.Files() {
+		fmt.Printf("# %s\n", file)
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Settings()); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return encoder.Close()
+}
@@ -1,20 +1,111 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"go-practical-roadmap/01-web-api-template/internal/app"
+	"go-practical-roadmap/01-web-api-template/internal/config"
 )
 
+// usage 命令行帮助
+const usage = `Go Web API Template
+
+Usage:
+  server [--config file] [--env name] [command]
+
+Commands:
+  serve                 启动HTTP服务器（默认命令）
+  migrate               执行数据库迁移
+  user create           创建用户，--admin 创建管理员
+  user reset-password   重置用户密码并注销其全部会话
+  user reactivate       重新启用已停用的账号
+  seed                  写入开发用的示例用户
+  config print          打印生效的配置（敏感项已脱敏）
+
+Global flags:
+  --config file         基础配置文件，默认在configs目录中查找config.yaml
+  --env name            运行环境，叠加同目录的config.{env}.yaml，默认读取APP_ENV
+
+Run "server <command> -h" for command flags.
+`
+
+// command 子命令，opts为全局参数指定的配置加载选项，args为子命令之后的参数
+type command func(opts config.LoadOptions, args []string) error
+
 func main() {
-	fmt.Println("Go Web API Template Project")
-	fmt.Println("This is a template project for building web APIs with Go, Gin, and Gorm.")
+	log.SetFlags(0)
+
+	// 全局参数在子命令之前
+	var opts config.LoadOptions
+	global := flag.NewFlagSet("server", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	global.StringVar(&opts.File, "config", "", "base config file")
+	global.StringVar(&opts.Env, "env", "", "environment overlay")
+	if err := global.Parse(os.Args[1:]); err != nil {
+		exit(err)
+		return
+	}
+
+	args := global.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	commands := map[string]command{
+		"serve":               serve,
+		"migrate":             migrate,
+		"user create":         createUser,
+		"user reset-password": resetPassword,
+		"user reactivate":     reactivateUser,
+		"seed":                seed,
+		"config print":        printConfig,
+	}
+
+	// 子命令由一个或两个单词组成
+	if len(args) > 1 {
+		if run, ok := commands[args[0]+" "+args[1]]; ok {
+			exit(run(opts, args[2:]))
+			return
+		}
+	}
+	if run, ok := commands[args[0]]; ok {
+		exit(run(opts, args[1:]))
+		return
+	}
+
+	switch args[0] {
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		os.Exit(2)
+	}
+}
+
+// exit 命令失败时输出错误并以非零状态退出
+func exit(err error) {
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// serve 启动HTTP服务器，收到中断信号后优雅关闭
+func serve(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
 
 	// 创建应用实例
-	application, err := app.NewApp()
+	application, err := app.NewApp(opts)
 	if err != nil {
-		log.Fatalf("Failed to create app: %v", err)
+		return fmt.Errorf("failed to create app: %w", err)
 	}
 
 	// 在goroutine中启动应用
@@ -24,7 +115,8 @@ func main() {
 		}
 	}()
 
-	fmt.Println("Server is running on :8080")
+	cfg := application.Config()
+	fmt.Printf("Server is running on %s:%d\n", cfg.Server.Host, cfg.Server.Port)
 	fmt.Println("Press Ctrl+C to stop")
 
 	// 等待中断信号
@@ -32,8 +124,23 @@ func main() {
 
 	// 停止应用
 	if err := application.Stop(); err != nil {
-		log.Fatalf("Failed to stop app: %v", err)
+		return fmt.Errorf("failed to stop app: %w", err)
 	}
 
 	fmt.Println("Server stopped")
-}
\ No newline at end of file
+	return nil
+}
+
+// migrate 执行数据库迁移，database.auto_migrate关闭时需在启动服务器前执行
+func migrate(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := app.Migrate(opts); err != nil {
+		return err
+	}
+	fmt.Println("Database migration completed")
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/app"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+)
+
+// seed 写入开发用的管理员和示例用户，已存在的用户名跳过，可重复执行
+func seed(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := flags.Int("users", 10, "示例用户数量")
+	password := flags.String("password", "password123", "管理员和示例用户的密码")
+	tenantSlug := flags.String("tenant", "", "租户标识，为空时使用 tenant.default")
+	force := flags.Bool("force", false, "允许在 release 模式下执行")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	application, err := app.NewApp(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	defer application.Close()
+
+	if application.Config().Server.Mode == gin.ReleaseMode && !*force {
+		return errors.New("refusing to seed in release mode, use --force to override")
+	}
+	ctx, err := cliContext(application, *tenantSlug)
+	if err != nil {
+		return err
+	}
+
+	requests := []*dto.RegisterRequest{{Username: "admin", Email: "admin@example.com", Password: *password}}
+	for i := 1; i <= *users; i++ {
+		requests = append(requests, &dto.RegisterRequest{
+			Username: fmt.Sprintf("demo%02d", i),
+			Email:    fmt.Sprintf("demo%02d@example.com", i),
+			Password: *password,
+		})
+	}
+
+	created := 0
+	for i, req := range requests {
+		if err := validate(req); err != nil {
+			return err
+		}
+		if _, err := application.Users().GetUserByUsername(ctx, req.Username); err == nil {
+			continue
+		}
+
+		user, err := application.Users().Register(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to create user %q: %w", req.Username, err)
+		}
+		if i == 0 {
+			if _, err := application.Users().ChangeRole(ctx, user.ID, model.RoleAdmin); err != nil {
+				return fmt.Errorf("failed to grant admin role: %w", err)
+			}
+		}
+		created++
+	}
+
+	fmt.Printf("Seeded %d users (%d already existed), password %q\n", created, len(requests)-created, *password)
+	return nil
+}
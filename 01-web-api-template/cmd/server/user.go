@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/app"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/validation"
+	"go-practical-roadmap/01-web-api-template/pkg/db"
+)
+
+// cliActor 命令行操作在审计日志中记录的操作者名称
+const cliActor = "admin-cli"
+
+// createUser 创建用户，与注册接口使用相同的校验规则
+func createUser(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := flags.String("username", "", "用户名")
+	email := flags.String("email", "", "邮箱")
+	password := flags.String("password", "", "密码，为空时从标准输入读取")
+	admin := flags.Bool("admin", false, "创建管理员")
+	tenantSlug := flags.String("tenant", "", "租户标识，为空时使用 tenant.default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	application, err := app.NewApp(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	defer application.Close()
+
+	ctx, err := cliContext(application, *tenantSlug)
+	if err != nil {
+		return err
+	}
+	if *password == "" {
+		if *password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+	req := &dto.RegisterRequest{Username: *username, Email: *email, Password: *password}
+	if err := validate(req); err != nil {
+		return err
+	}
+
+	user, err := application.Users().Register(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if *admin {
+		if user, err = application.Users().ChangeRole(ctx, user.ID, model.RoleAdmin); err != nil {
+			return fmt.Errorf("failed to grant admin role: %w", err)
+		}
+	}
+
+	role := model.RoleUser
+	if *admin {
+		role = model.RoleAdmin
+	}
+	fmt.Printf("Created %s %q (id %d)\n", role, user.Username, user.ID)
+	return nil
+}
+
+// resetPassword 重置用户密码并注销其全部会话
+func resetPassword(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	username := flags.String("username", "", "用户名")
+	password := flags.String("password", "", "新密码，为空时从标准输入读取")
+	tenantSlug := flags.String("tenant", "", "租户标识，为空时使用 tenant.default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("--username is required")
+	}
+
+	application, err := app.NewApp(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	defer application.Close()
+
+	ctx, err := cliContext(application, *tenantSlug)
+	if err != nil {
+		return err
+	}
+	user, err := application.Users().GetUserByUsername(ctx, *username)
+	if err != nil {
+		return fmt.Errorf("failed to find user %q: %w", *username, err)
+	}
+	if *password == "" {
+		if *password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+	// 与修改密码接口的新密码规则一致
+	if err := validate(&dto.ChangePasswordRequest{OldPassword: *password, NewPassword: *password}); err != nil {
+		return err
+	}
+
+	if err := application.Users().ResetPassword(ctx, user.ID, *password); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	fmt.Printf("Password of %q has been reset, all sessions revoked\n", user.Username)
+	return nil
+}
+
+// reactivateUser 重新启用已停用的账号，例如唯一的管理员停用了自己的账号
+func reactivateUser(opts config.LoadOptions, args []string) error {
+	flags := flag.NewFlagSet("user reactivate", flag.ContinueOnError)
+	username := flags.String("username", "", "用户名")
+	tenantSlug := flags.String("tenant", "", "租户标识，为空时使用 tenant.default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("--username is required")
+	}
+
+	application, err := app.NewApp(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	defer application.Close()
+
+	ctx, err := cliContext(application, *tenantSlug)
+	if err != nil {
+		return err
+	}
+	user, err := application.Users().GetUserByUsername(ctx, *username)
+	if err != nil {
+		return fmt.Errorf("failed to find user %q: %w", *username, err)
+	}
+	if _, err := application.Users().ReactivateUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+
+	fmt.Printf("User %q has been reactivated\n", user.Username)
+	return nil
+}
+
+// cliContext 返回指定租户的上下文，审计日志中的操作者为admin-cli
+func cliContext(application *app.App, tenantSlug string) (context.Context, error) {
+	if tenantSlug == "" {
+		tenantSlug = application.Config().Tenant.Default
+	}
+	if tenantSlug == "" {
+		return nil, errors.New("--tenant is required when tenant.default is empty")
+	}
+
+	// 命令行操作先读后写，读操作同样使用主库
+	ctx := db.WithPrimary(context.Background())
+	ctx = requestinfo.NewContext(ctx, requestinfo.Info{Username: cliActor})
+	tenantID, err := application.Tenants().ResolveTenant(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tenant %q: %w", tenantSlug, err)
+	}
+	return tenant.WithTenant(ctx, tenantID), nil
+}
+
+// readPassword 从标准输入读取一行作为密码
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+var (
+	registerValidationOnce sync.Once
+	registerValidationErr  error
+)
+
+// validate 按请求结构体的binding规则校验参数，错误信息与接口一致
+func validate(req interface{}) error {
+	registerValidationOnce.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			registerValidationErr = validation.Register(v)
+		}
+	})
+	if registerValidationErr != nil {
+		return registerValidationErr
+	}
+
+	err := binding.Validator.ValidateStruct(req)
+	if err == nil {
+		return nil
+	}
+
+	message, fields := validation.Translate(err, validation.LocaleEN)
+	details := make([]string, 0, len(fields))
+	for _, field := range fields {
+		details = append(details, field.Message)
+	}
+	return fmt.Errorf("%s: %s", message, strings.Join(details, "; "))
+}
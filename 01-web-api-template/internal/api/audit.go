@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// auditCSVHeader 审计日志CSV导出的表头
+var auditCSVHeader = []string{
+	"id", "created_at", "action", "success", "actor_id", "actor_name",
+	"target_type", "target_id", "ip", "request_id", "diff",
+}
+
+// listAuditLogsHandler 查询审计日志，format=csv时导出全部匹配记录
+func listAuditLogsHandler(c *gin.Context, auditService service.AuditService, log logger.Logger) {
+	var query dto.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if query.Format == "csv" {
+		exportAuditLogsCSV(c, auditService, &query, log)
+		return
+	}
+
+	result, err := auditService.Query(c.Request.Context(), &query)
+	if err != nil {
+		log.Error("Failed to query audit logs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Audit logs retrieved successfully",
+		"data":    result,
+	})
+}
+
+// exportAuditLogsCSV 以CSV格式流式输出审计日志
+func exportAuditLogsCSV(c *gin.Context, auditService service.AuditService, query *dto.AuditQuery, log logger.Logger) {
+	filename := "audit-" + time.Now().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(auditCSVHeader); err != nil {
+		return
+	}
+
+	err := auditService.Export(c.Request.Context(), query, func(entry *dto.AuditLogResponse) error {
+		actorID := ""
+		if entry.ActorID != nil {
+			actorID = strconv.FormatUint(uint64(*entry.ActorID), 10)
+		}
+		return w.Write([]string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.Action,
+			strconv.FormatBool(entry.Success),
+			actorID,
+			entry.ActorName,
+			entry.TargetType,
+			entry.TargetID,
+			entry.IP,
+			entry.RequestID,
+			string(entry.Diff),
+		})
+	})
+	w.Flush()
+
+	// 响应头已发送，出错时只能记录日志
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		log.Error("Failed to export audit logs", zap.Error(err))
+	}
+}
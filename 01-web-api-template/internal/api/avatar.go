@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/blob"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// avatarFormField 上传头像的表单字段名
+const avatarFormField = "avatar"
+
+// uploadAvatarHandler 上传当前用户头像（multipart/form-data，字段名avatar）
+func uploadAvatarHandler(c *gin.Context, avatarService service.AvatarService, log logger.Logger) {
+	claims, _ := middleware.CurrentClaims(c)
+
+	// 逐个读取表单分段，文件内容直接交给服务处理，不落盘到临时文件
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must be multipart/form-data"})
+		return
+	}
+
+	var file io.Reader
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed multipart body"})
+			return
+		}
+		if part.FormName() == avatarFormField {
+			file = part
+			break
+		}
+	}
+	if file == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing avatar file"})
+		return
+	}
+
+	user, err := avatarService.Upload(c.Request.Context(), claims.UserID, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAvatarTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUnsupportedImageType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Error("Failed to upload avatar", zap.Uint("user_id", claims.UserID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload avatar"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Avatar uploaded successfully",
+		"data":    user,
+	})
+	log.Info("Upload avatar endpoint called", zap.Uint("user_id", claims.UserID))
+}
+
+// serveFileHandler 通过签名地址访问存储的文件
+func serveFileHandler(c *gin.Context, avatarService service.AvatarService, log logger.Logger) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	file, err := avatarService.Open(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrInvalidSignature):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, blob.ErrNotFound), errors.Is(err, blob.ErrInvalidKey):
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		default:
+			log.Error("Failed to open file", zap.String("key", key), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		}
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
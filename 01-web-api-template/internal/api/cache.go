@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+)
+
+// cacheStatsHandler 查看缓存命中统计，userCache为nil表示未启用用户缓存
+func cacheStatsHandler(c *gin.Context, userCache cache.Cache) {
+	var response dto.CacheStatsResponse
+	if userCache != nil {
+		stats := userCache.Stats()
+		response.Users = &stats
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cache stats retrieved successfully",
+		"data":    response,
+	})
+}
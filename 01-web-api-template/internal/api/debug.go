@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/diagnostics"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SetupDebugRoutes 创建只包含诊断接口的路由，用于只在内网访问的独立监听地址
+func SetupDebugRoutes(profiler *diagnostics.Profiler, log logger.Logger) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	registerDebugRoutes(r.Group("/debug"), profiler, log)
+	return r
+}
+
+// registerDebugRoutes 注册诊断接口：pprof、goroutine堆栈、运行时状态、构建信息和CPU性能分析文件。
+// pprof.Index按 /debug/pprof/ 前缀解析性能分析名称，因此必须挂载在 /debug 下
+func registerDebugRoutes(group *gin.RouterGroup, profiler *diagnostics.Profiler, log logger.Logger) {
+	pprofGroup := group.Group("/pprof")
+	{
+		pprofGroup.GET("/", gin.WrapF(pprof.Index))
+		pprofGroup.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		pprofGroup.GET("/profile", gin.WrapF(pprof.Profile))
+		pprofGroup.GET("/symbol", gin.WrapF(pprof.Symbol))
+		pprofGroup.POST("/symbol", gin.WrapF(pprof.Symbol))
+		pprofGroup.GET("/trace", gin.WrapF(pprof.Trace))
+		for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+			pprofGroup.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+		}
+	}
+
+	group.GET("/goroutines", func(c *gin.Context) {
+		goroutineDumpHandler(c, log)
+	})
+	group.GET("/runtime", runtimeStatsHandler)
+	group.GET("/build", buildInfoHandler)
+	group.GET("/profiles", func(c *gin.Context) {
+		listProfilesHandler(c, profiler, log)
+	})
+	group.POST("/profiles/cpu", func(c *gin.Context) {
+		captureCPUProfileHandler(c, profiler, log)
+	})
+	group.GET("/profiles/:name", func(c *gin.Context) {
+		downloadProfileHandler(c, profiler, log)
+	})
+}
+
+// goroutineDumpHandler 以文本返回全部goroutine的完整堆栈
+func goroutineDumpHandler(c *gin.Context, log logger.Logger) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := runtimepprof.Lookup("goroutine").WriteTo(c.Writer, 2); err != nil {
+		log.Error("Failed to write goroutine dump", zap.Error(err))
+	}
+}
+
+// runtimeStatsHandler 返回goroutine数量、内存和GC统计
+func runtimeStatsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Runtime stats retrieved successfully",
+		"data":    diagnostics.ReadRuntimeStats(),
+	})
+}
+
+// buildInfoHandler 返回Go版本、VCS版本和依赖版本
+func buildInfoHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Build info retrieved successfully",
+		"data":    diagnostics.ReadBuildInfo(),
+	})
+}
+
+// listProfilesHandler 列出已保存的CPU性能分析文件
+func listProfilesHandler(c *gin.Context, profiler *diagnostics.Profiler, log logger.Logger) {
+	profiles, err := profiler.List()
+	if err != nil {
+		log.Error("Failed to list profiles", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list profiles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profiles retrieved successfully",
+		"data":    profiles,
+	})
+}
+
+// captureCPUProfileHandler 采集指定秒数的CPU性能分析并保存到debug.profile_dir，采集完成后返回；
+// 客户端断开时提前结束并保存已采集的部分
+func captureCPUProfileHandler(c *gin.Context, profiler *diagnostics.Profiler, log logger.Logger) {
+	var query dto.CaptureProfileQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	profile, err := profiler.CaptureCPU(c.Request.Context(), time.Duration(query.Seconds)*time.Second)
+	if err != nil {
+		switch {
+		case errors.Is(err, diagnostics.ErrInvalidDuration):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, diagnostics.ErrProfileInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": "CPU profile already in progress"})
+		default:
+			log.Error("Failed to capture cpu profile", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to capture cpu profile"})
+		}
+		return
+	}
+
+	log.Info("CPU profile captured", zap.String("name", profile.Name), zap.Int("seconds", query.Seconds))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "CPU profile captured successfully",
+		"data":    profile,
+	})
+}
+
+// downloadProfileHandler 下载性能分析文件，可以直接用 go tool pprof 分析
+func downloadProfileHandler(c *gin.Context, profiler *diagnostics.Profiler, log logger.Logger) {
+	name := c.Param("name")
+	path, err := profiler.Path(name)
+	if err != nil {
+		if errors.Is(err, diagnostics.ErrProfileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		log.Error("Failed to open profile", zap.String("name", name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open profile"})
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/diagnostics"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+)
+
+func TestDebugRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupDebugRoutes(diagnostics.NewProfiler(t.TempDir(), time.Second), logger.NewNop())
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, target, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/debug/pprof/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+
+	w = serve("GET", "/debug/pprof/heap?debug=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("GET", "/debug/goroutines")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "goroutine "))
+
+	w = serve("GET", "/debug/runtime")
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats struct {
+		Data diagnostics.RuntimeStats `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Greater(t, stats.Data.Goroutines, 0)
+
+	w = serve("GET", "/debug/build")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 采集时长超过上限或缺少参数
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "/debug/profiles/cpu?seconds=5").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "/debug/profiles/cpu").Code)
+
+	w = serve("POST", "/debug/profiles/cpu?seconds=1")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data diagnostics.Profile `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = serve("GET", "/debug/profiles")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), created.Data.Name)
+
+	w = serve("GET", "/debug/profiles/"+created.Data.Name)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), created.Data.Name)
+
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/debug/profiles/app.db").Code)
+}
+
+func TestDebugRoutes_DisabledByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRoutes(Dependencies{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/debug/pprof/", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,424 @@
+package api
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/api/openapi"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// docsPage 文档页面，使用Swagger UI渲染 /openapi.json
+//
+//go:embed docs.html
+var docsPage []byte
+
+// docsContentSecurityPolicy 文档页面的CSP，放行unpkg上的Swagger UI资源和页面内联脚本
+var docsContentSecurityPolicy = "default-src 'none'; " +
+	"script-src https://unpkg.com " + inlineScriptHash(docsPage) + "; " +
+	"style-src https://unpkg.com 'unsafe-inline'; " +
+	"img-src 'self' data: https:; " +
+	"connect-src 'self'; " +
+	"frame-ancestors 'none'"
+
+// inlineScriptPattern 匹配页面中的内联脚本
+var inlineScriptPattern = regexp.MustCompile(`(?s)<script>(.*?)</script>`)
+
+// inlineScriptHash 计算页面中内联脚本的CSP哈希
+func inlineScriptHash(page []byte) string {
+	match := inlineScriptPattern.FindSubmatch(page)
+	if match == nil {
+		return ""
+	}
+	sum := sha256.Sum256(match[1])
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+// apiInfo 文档基本信息
+var apiInfo = openapi.Info{
+	Title:       "Go Web API Template",
+	Version:     "1.0.0",
+	Description: "用户注册、登录、二次验证、会话与管理接口",
+}
+
+// routeDocs 路由文档登记表，键为 "METHOD /gin/path"。新增路由必须在此登记，否则测试失败
+var routeDocs = map[string]openapi.Operation{
+	"GET /health": {
+		Summary:  "健康检查",
+		Tags:     []string{"system"},
+		Response: dto.HealthResponse{},
+	},
+	"GET /openapi.json": {
+		Summary:  "OpenAPI文档",
+		Tags:     []string{"system"},
+		Response: map[string]interface{}{},
+	},
+	"GET /docs": {
+		Summary:     "API文档页面",
+		Tags:        []string{"system"},
+		ContentType: "text/html",
+		Response:    "",
+	},
+
+	"POST /api/v1/register": {
+		Summary: "用户注册",
+		Tags:    []string{"auth"},
+		Body:    dto.RegisterRequest{},
+		Data:    dto.UserProfileResponse{},
+		Status:  http.StatusCreated,
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"POST /api/v1/login": {
+		Summary:     "用户登录",
+		Description: "启用二次验证的用户返回 mfa_required 和 mfa_token，需继续调用 /api/v1/login/mfa；账号已停用时返回403",
+		Tags:        []string{"auth"},
+		Body:        dto.LoginRequest{},
+		Response:    dto.TokenResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"POST /api/v1/login/mfa": {
+		Summary:  "二次验证登录",
+		Tags:     []string{"auth"},
+		Body:     dto.MFALoginRequest{},
+		Response: dto.TokenResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests},
+	},
+	"GET /api/v1/auth/providers": {
+		Summary: "第三方登录提供方列表",
+		Tags:    []string{"auth"},
+		Data:    []string{},
+	},
+	"GET /api/v1/auth/:provider/login": {
+		Summary: "跳转到第三方授权页面",
+		Tags:    []string{"auth"},
+		Status:  http.StatusFound,
+		Errors:  []int{http.StatusNotFound, http.StatusBadGateway},
+	},
+	"GET /api/v1/auth/:provider/callback": {
+		Summary:  "第三方授权回调",
+		Tags:     []string{"auth"},
+		Response: dto.TokenResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/files/*key": {
+		Summary:     "通过签名地址访问文件",
+		Description: "地址由服务端生成，包含 expires 和 signature 查询参数",
+		Tags:        []string{"files"},
+		ContentType: "application/octet-stream",
+		Response:    "",
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound},
+	},
+
+	"GET /api/v1/profile": {
+		Summary: "当前用户信息",
+		Tags:    []string{"profile"},
+		Auth:    true,
+		Data:    dto.UserProfileResponse{},
+		Errors:  []int{http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"PUT /api/v1/profile/password": {
+		Summary: "修改密码",
+		Tags:    []string{"profile"},
+		Auth:    true,
+		Body:    dto.ChangePasswordRequest{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized},
+	},
+	"PUT /api/v1/profile/avatar": {
+		Summary: "上传头像",
+		Tags:    []string{"profile"},
+		Auth:    true,
+		Upload:  avatarFormField,
+		Data:    dto.UserProfileResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized,
+			http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType},
+	},
+	"PUT /api/v1/profile/email": {
+		Summary:     "修改邮箱",
+		Description: "需要验证当前密码，成功后发送 user.email_changed 事件",
+		Tags:        []string{"profile"},
+		Auth:        true,
+		Body:        dto.ChangeEmailRequest{},
+		Data:        dto.UserProfileResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict},
+	},
+	"POST /api/v1/profile/deactivate": {
+		Summary:     "停用账号",
+		Description: "需要验证当前密码，停用后注销全部会话并发送 user.deactivated 事件，只能由管理员重新启用",
+		Tags:        []string{"profile"},
+		Auth:        true,
+		Body:        dto.DeactivateAccountRequest{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+	},
+	"GET /api/v1/flags": {
+		Summary: "当前用户的功能开关",
+		Tags:    []string{"flags"},
+		Auth:    true,
+		Data:    map[string]bool{},
+		Errors:  []int{http.StatusUnauthorized},
+	},
+	"POST /api/v1/mfa/totp/enroll": {
+		Summary: "登记TOTP密钥",
+		Tags:    []string{"mfa"},
+		Auth:    true,
+		Data:    dto.MFAEnrollResponse{},
+		Errors:  []int{http.StatusUnauthorized, http.StatusConflict},
+	},
+	"POST /api/v1/mfa/totp/confirm": {
+		Summary: "确认启用二次验证",
+		Tags:    []string{"mfa"},
+		Auth:    true,
+		Body:    dto.MFAConfirmRequest{},
+		Data:    dto.MFAConfirmResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict},
+	},
+	"GET /api/v1/sessions": {
+		Summary: "当前用户的登录会话",
+		Tags:    []string{"sessions"},
+		Auth:    true,
+		Data:    []dto.SessionResponse{},
+		Errors:  []int{http.StatusUnauthorized},
+	},
+	"DELETE /api/v1/sessions/:id": {
+		Summary: "注销会话",
+		Tags:    []string{"sessions"},
+		Auth:    true,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+
+	"DELETE /api/v1/admin/users/:id/mfa": {
+		Summary: "重置用户二次验证",
+		Tags:    []string{"admin"},
+		Auth:    true,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"PUT /api/v1/admin/users/:id/role": {
+		Summary: "修改用户角色",
+		Tags:    []string{"admin"},
+		Auth:    true,
+		Body:    dto.ChangeRoleRequest{},
+		Data:    dto.UserProfileResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"DELETE /api/v1/admin/users/:id": {
+		Summary: "删除用户（软删除）",
+		Tags:    []string{"admin"},
+		Auth:    true,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/users/deleted": {
+		Summary: "已删除的用户",
+		Tags:    []string{"admin"},
+		Auth:    true,
+		Query:   dto.DeletedUserQuery{},
+		Data:    dto.DeletedUserListResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"POST /api/v1/admin/users/:id/restore": {
+		Summary: "恢复已删除的用户",
+		Tags:    []string{"admin"},
+		Auth:    true,
+		Data:    dto.UserProfileResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	},
+	"POST /api/v1/admin/users/:id/reactivate": {
+		Summary:     "重新启用已停用的账号",
+		Description: "发送 user.reactivated 事件，账号未停用时不做修改",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Data:        dto.UserProfileResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/users/export": {
+		Summary:     "导出用户",
+		Description: "流式导出当前租户的全部用户，format=csv（默认）或 ndjson",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Query:       dto.UserExportQuery{},
+		ContentType: "text/csv",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"POST /api/v1/admin/users/import": {
+		Summary:     "导入用户",
+		Description: "上传包含 username、email、password 列（可选 first_name、last_name）的CSV，创建异步导入任务",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Query:       dto.UserImportQuery{},
+		Upload:      importFormField,
+		Data:        dto.UserImportJobResponse{},
+		Status:      http.StatusAccepted,
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden,
+			http.StatusRequestEntityTooLarge, http.StatusServiceUnavailable},
+	},
+	"GET /api/v1/admin/users/import/:id": {
+		Summary: "导入任务状态",
+		Tags:    []string{"admin"},
+		Auth:    true,
+		Data:    dto.UserImportJobResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/users/import/:id/errors": {
+		Summary:     "导入错误报告",
+		Description: "逐行的校验和写入错误，format=csv 时导出CSV",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Query:       dto.UserImportErrorQuery{},
+		Data:        []dto.UserImportErrorResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/audit": {
+		Summary:     "查询审计日志",
+		Description: "只返回当前租户的记录；format=csv 时以 text/csv 导出全部匹配记录（忽略分页参数）",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Query:       dto.AuditQuery{},
+		Data:        dto.AuditListResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"GET /api/v1/admin/cache/stats": {
+		Summary:     "缓存命中统计",
+		Description: "缓存由全部租户共用，仅默认租户的管理员可以访问",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Data:        dto.CacheStatsResponse{},
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"GET /api/v1/admin/webhooks": {
+		Summary: "Webhook订阅列表",
+		Tags:    []string{"webhooks"},
+		Auth:    true,
+		Data:    []dto.WebhookResponse{},
+		Errors:  []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"POST /api/v1/admin/webhooks": {
+		Summary:     "创建Webhook订阅",
+		Description: "events 为空时订阅全部事件（user.registered、user.email_changed、user.deleted、user.deactivated、user.reactivated）；签名密钥只在创建时返回",
+		Tags:        []string{"webhooks"},
+		Auth:        true,
+		Body:        dto.CreateWebhookRequest{},
+		Data:        dto.WebhookSecretResponse{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"PUT /api/v1/admin/webhooks/:id": {
+		Summary: "修改Webhook订阅",
+		Tags:    []string{"webhooks"},
+		Auth:    true,
+		Body:    dto.UpdateWebhookRequest{},
+		Data:    dto.WebhookResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"DELETE /api/v1/admin/webhooks/:id": {
+		Summary: "删除Webhook订阅",
+		Tags:    []string{"webhooks"},
+		Auth:    true,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/webhooks/:id/deliveries": {
+		Summary: "Webhook投递记录",
+		Tags:    []string{"webhooks"},
+		Auth:    true,
+		Query:   dto.WebhookDeliveryQuery{},
+		Data:    dto.WebhookDeliveryListResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/flags": {
+		Summary:     "功能开关列表",
+		Description: "包含配置文件和管理员设置的全部开关，仅默认租户的管理员可以访问",
+		Tags:        []string{"flags"},
+		Auth:        true,
+		Data:        []dto.FeatureFlagResponse{},
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"PUT /api/v1/admin/flags/:key": {
+		Summary:     "设置功能开关",
+		Description: "创建或覆盖开关并立即生效，rollout省略时为100；仅默认租户的管理员可以访问",
+		Tags:        []string{"flags"},
+		Auth:        true,
+		Body:        dto.UpdateFeatureFlagRequest{},
+		Data:        dto.FeatureFlagResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"DELETE /api/v1/admin/flags/:key": {
+		Summary:     "重置功能开关",
+		Description: "删除管理员的设置，恢复为配置文件中的开关；仅默认租户的管理员可以访问",
+		Tags:        []string{"flags"},
+		Auth:        true,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/log-level": {
+		Summary:     "日志级别",
+		Description: "根级别、单独设置了级别的命名日志记录器和全部日志记录器名称；仅默认租户的管理员可以访问",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Data:        dto.LogLevelResponse{},
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"PUT /api/v1/admin/log-level": {
+		Summary:     "修改日志级别",
+		Description: "logger为空时修改根级别，否则只修改该命名日志记录器；立即生效，重启后恢复配置文件中的级别。仅默认租户的管理员可以访问",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Body:        dto.UpdateLogLevelRequest{},
+		Data:        dto.LogLevelResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"DELETE /api/v1/admin/log-level/:logger": {
+		Summary:     "重置命名日志记录器的级别",
+		Description: "取消单独设置的级别，恢复跟随根级别；仅默认租户的管理员可以访问",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Data:        dto.LogLevelResponse{},
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /api/v1/admin/tenants": {
+		Summary:     "租户列表",
+		Description: "仅默认租户的管理员可以访问",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Data:        []dto.TenantResponse{},
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	"POST /api/v1/admin/tenants": {
+		Summary:     "创建租户",
+		Description: "仅默认租户的管理员可以访问",
+		Tags:        []string{"admin"},
+		Auth:        true,
+		Body:        dto.CreateTenantRequest{},
+		Data:        dto.TenantResponse{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict},
+	},
+}
+
+// registerDocs 注册文档端点，需在所有业务路由注册之后调用
+func registerDocs(r *gin.Engine, log logger.Logger) {
+	var spec *openapi.Document
+
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Header("Content-Security-Policy", docsContentSecurityPolicy)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	})
+
+	spec, missing := buildSpec(r)
+	if len(missing) > 0 {
+		log.Warn("Routes missing OpenAPI documentation", zap.Strings("routes", missing))
+	}
+}
+
+// buildSpec 根据引擎当前的路由表生成文档，返回未登记文档的路由
+func buildSpec(r *gin.Engine) (*openapi.Document, []string) {
+	routes := make([]openapi.Route, 0, len(r.Routes()))
+	for _, route := range r.Routes() {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+	return openapi.NewGenerator(apiInfo).Build(routes, routeDocs)
+}
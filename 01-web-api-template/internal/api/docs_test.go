@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRouteDocs_AllRoutesDocumented 每个注册的路由都必须在routeDocs中登记文档
+func TestRouteDocs_AllRoutesDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRoutes(Dependencies{Tenants: fakeTenants{}})
+
+	_, missing := buildSpec(r)
+	assert.Empty(t, missing, "routes missing OpenAPI documentation, add them to routeDocs")
+
+	// 登记表中不应保留已删除路由的文档
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for key := range routeDocs {
+		assert.True(t, registered[key], "routeDocs entry %q has no matching route", key)
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRoutes(Dependencies{Tenants: fakeTenants{}})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string `json:"required"`
+				Properties map[string]struct {
+					Type      string        `json:"type"`
+					Format    string        `json:"format"`
+					MinLength *int          `json:"minLength"`
+					MaxLength *int          `json:"maxLength"`
+					Enum      []interface{} `json:"enum"`
+				} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+
+	// Gin路径参数转换为OpenAPI格式
+	assert.Contains(t, spec.Paths, "/api/v1/sessions/{id}")
+	assert.Contains(t, spec.Paths["/api/v1/admin/users/{id}"], "delete")
+
+	// binding规则转换为Schema约束
+	register := spec.Components.Schemas["RegisterRequest"]
+	assert.ElementsMatch(t, []string{"username", "email", "password"}, register.Required)
+	assert.Equal(t, 3, *register.Properties["username"].MinLength)
+	assert.Equal(t, 50, *register.Properties["username"].MaxLength)
+	assert.Equal(t, "email", register.Properties["email"].Format)
+
+	role := spec.Components.Schemas["ChangeRoleRequest"].Properties["role"]
+	assert.Equal(t, []interface{}{"user", "admin"}, role.Enum)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/openapi.json")
+
+	// 文档页面放行Swagger UI的资源，内联脚本按哈希放行
+	csp := w.Header().Get("Content-Security-Policy")
+	assert.Contains(t, csp, "script-src https://unpkg.com 'sha256-")
+	assert.NotContains(t, csp, "script-src https://unpkg.com 'unsafe-inline'")
+}
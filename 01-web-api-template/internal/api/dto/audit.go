@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditQuery 审计日志查询参数
+type AuditQuery struct {
+	Action     string    `form:"action"`
+	ActorID    uint      `form:"actor_id"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit      int       `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset     int       `form:"offset" binding:"omitempty,min=0"`
+	Format     string    `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// AuditLogResponse 审计日志响应
+type AuditLogResponse struct {
+	ID         uint            `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Action     string          `json:"action"`
+	Success    bool            `json:"success"`
+	ActorID    *uint           `json:"actor_id,omitempty"`
+	ActorName  string          `json:"actor_name"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+}
+
+// AuditListResponse 审计日志分页响应
+type AuditListResponse struct {
+	Items  []AuditLogResponse `json:"items"`
+	Total  int64              `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
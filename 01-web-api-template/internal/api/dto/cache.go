@@ -0,0 +1,8 @@
+package dto
+
+import "go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+
+// CacheStatsResponse 缓存命中统计响应，未启用的缓存不返回
+type CacheStatsResponse struct {
+	Users *cache.Stats `json:"users,omitempty"`
+}
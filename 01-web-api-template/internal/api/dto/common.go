@@ -0,0 +1,15 @@
+package dto
+
+// HealthResponse 健康检查响应
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// TokenResponse 登录响应，启用二次验证时返回等待验证令牌而不是访问令牌
+type TokenResponse struct {
+	Message     string `json:"message"`
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+// CaptureProfileQuery 采集CPU性能分析的参数，seconds不能超过debug.max_profile_duration
+type CaptureProfileQuery struct {
+	Seconds int `form:"seconds" binding:"required,min=1"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// UpdateFeatureFlagRequest 设置功能开关请求，rollout为空时表示100%
+type UpdateFeatureFlagRequest struct {
+	Enabled     *bool    `json:"enabled" binding:"required"`
+	Rollout     *int     `json:"rollout" binding:"omitempty,min=0,max=100"`
+	Users       []uint   `json:"users" binding:"omitempty,max=100"`
+	Roles       []string `json:"roles" binding:"omitempty,max=10"`
+	Description string   `json:"description" binding:"max=255"`
+}
+
+// FeatureFlagResponse 功能开关信息，source为config表示来自配置文件，db表示已被管理员覆盖
+type FeatureFlagResponse struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Rollout     int        `json:"rollout"`
+	Users       []uint     `json:"users"`
+	Roles       []string   `json:"roles"`
+	Source      string     `json:"source"`
+	UpdatedBy   uint       `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+// UpdateLogLevelRequest 修改日志级别请求，logger为空时修改根级别
+type UpdateLogLevelRequest struct {
+	Logger string `json:"logger" binding:"max=64"`
+	Level  string `json:"level" binding:"required,oneof=debug info warn error"`
+}
+
+// LogLevelResponse 当前的日志级别，loggers为单独设置了级别的命名日志记录器，其余跟随level
+type LogLevelResponse struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers"`
+	Names   []string          `json:"names"`
+}
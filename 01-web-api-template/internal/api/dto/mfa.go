@@ -0,0 +1,23 @@
+package dto
+
+// MFAEnrollResponse 二次验证登记响应
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFAConfirmRequest 二次验证启用确认请求
+type MFAConfirmRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// MFAConfirmResponse 二次验证启用确认响应，恢复码只返回这一次
+type MFAConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFALoginRequest 登录二次验证请求，code可以是TOTP验证码或恢复码
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// CreateTenantRequest 创建租户请求，slug用作子域名和租户请求头的值，只能包含小写字母、数字和连字符
+type CreateTenantRequest struct {
+	Slug string `json:"slug" binding:"required,min=2,max=63"`
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// TenantResponse 租户信息响应
+type TenantResponse struct {
+	ID        uint      `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
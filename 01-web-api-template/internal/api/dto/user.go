@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // RegisterRequest 用户注册请求
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
@@ -13,11 +15,66 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=100"`
+}
+
+// ChangeEmailRequest 修改邮箱请求，需要验证当前密码
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// DeactivateAccountRequest 停用账号请求，需要验证当前密码
+type DeactivateAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	Reason   string `json:"reason" binding:"max=255"`
+}
+
+// ChangeRoleRequest 修改角色请求
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
 // UserProfileResponse 用户信息响应
 type UserProfileResponse struct {
-	ID        uint   `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-}
\ No newline at end of file
+	ID         uint              `json:"id"`
+	Username   string            `json:"username"`
+	Email      string            `json:"email"`
+	FirstName  string            `json:"first_name"`
+	LastName   string            `json:"last_name"`
+	AvatarURL  string            `json:"avatar_url,omitempty"`
+	AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+}
+
+// LoginResponse 登录结果，启用二次验证时只返回等待验证令牌
+type LoginResponse struct {
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// DeletedUserQuery 已删除用户列表查询参数
+type DeletedUserQuery struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+// DeletedUserResponse 已删除用户信息
+type DeletedUserResponse struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// DeletedUserListResponse 已删除用户分页响应
+type DeletedUserListResponse struct {
+	Items  []DeletedUserResponse `json:"items"`
+	Total  int64                 `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
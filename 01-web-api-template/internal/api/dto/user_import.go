@@ -0,0 +1,60 @@
+package dto
+
+import "time"
+
+// UserExportQuery 导出用户的查询参数
+type UserExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+}
+
+// UserExportRecord 导出的用户记录，不包含密码等敏感字段
+type UserExportRecord struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Role       string    `json:"role"`
+	IsActive   bool      `json:"is_active"`
+	MFAEnabled bool      `json:"mfa_enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UserImportQuery 导入用户的查询参数，dry_run为true时只校验不写入
+type UserImportQuery struct {
+	DryRun bool `form:"dry_run"`
+}
+
+// ImportUserRow CSV中的一行用户数据，校验规则与注册请求一致
+type ImportUserRow struct {
+	RegisterRequest
+	FirstName string `json:"first_name" binding:"max=50"`
+	LastName  string `json:"last_name" binding:"max=50"`
+}
+
+// UserImportJobResponse 导入任务状态响应
+type UserImportJobResponse struct {
+	ID         uint       `json:"id"`
+	Status     string     `json:"status"`
+	DryRun     bool       `json:"dry_run"`
+	Filename   string     `json:"filename"`
+	TotalRows  int        `json:"total_rows"`
+	Imported   int        `json:"imported"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// UserImportErrorResponse 导入任务中单行数据的错误，row为CSV中的行号（表头为第1行）
+type UserImportErrorResponse struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// UserImportErrorQuery 查询导入错误报告的参数
+type UserImportErrorQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+}
@@ -0,0 +1,85 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CreateWebhookRequest 创建Webhook订阅请求，events为空时订阅全部事件
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" binding:"omitempty,max=20"`
+	Description string   `json:"description" binding:"max=255"`
+}
+
+// UpdateWebhookRequest 更新Webhook订阅请求，active为空时保持原状态
+type UpdateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" binding:"omitempty,max=20"`
+	Description string   `json:"description" binding:"max=255"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookResponse Webhook订阅信息
+type WebhookResponse struct {
+	ID          uint      `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookSecretResponse 创建订阅的响应，签名密钥只在创建时返回一次
+type WebhookSecretResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryQuery 投递记录查询参数
+type WebhookDeliveryQuery struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+// WebhookDeliveryResponse 投递记录
+type WebhookDeliveryResponse struct {
+	ID             uint       `json:"id"`
+	EventID        uint       `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WebhookDeliveryListResponse 投递记录分页响应
+type WebhookDeliveryListResponse struct {
+	Items  []WebhookDeliveryResponse `json:"items"`
+	Total  int64                     `json:"total"`
+	Limit  int                       `json:"limit"`
+	Offset int                       `json:"offset"`
+}
+
+// WebhookEvent 投递到Webhook的请求体，id可用于接收方去重
+type WebhookEvent struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   uint            `json:"tenant_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// UserEventData 用户相关事件的数据，old_email只在修改邮箱事件中出现，reason只在停用账号事件中出现
+type UserEventData struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	OldEmail string `json:"old_email,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/flags"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// currentFlagsHandler 返回当前用户的功能开关状态
+func currentFlagsHandler(c *gin.Context) {
+	set := flags.FromContext(c.Request.Context())
+	if set == nil {
+		set = flags.Set{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feature flags evaluated successfully",
+		"data":    set,
+	})
+}
+
+// listFlagsHandler 列出全部功能开关
+func listFlagsHandler(c *gin.Context, flagService service.FlagService, log logger.Logger) {
+	items, err := flagService.List(c.Request.Context())
+	if err != nil {
+		log.Error("Failed to list feature flags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list feature flags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feature flags retrieved successfully",
+		"data":    items,
+	})
+}
+
+// setFlagHandler 创建或修改功能开关，立即生效
+func setFlagHandler(c *gin.Context, flagService service.FlagService, log logger.Logger) {
+	var req dto.UpdateFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	key := c.Param("key")
+	flag, err := flagService.Set(c.Request.Context(), key, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFlagKey) || errors.Is(err, service.ErrInvalidFlagRole) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to set feature flag", zap.String("key", key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set feature flag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feature flag updated successfully",
+		"data":    flag,
+	})
+}
+
+// resetFlagHandler 删除管理员的开关设置，恢复配置文件中的值
+func resetFlagHandler(c *gin.Context, flagService service.FlagService, log logger.Logger) {
+	key := c.Param("key")
+	if err := flagService.Reset(c.Request.Context(), key); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Feature flag override not found"})
+			return
+		}
+		log.Error("Failed to reset feature flag", zap.String("key", key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset feature flag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feature flag reset successfully"})
+}
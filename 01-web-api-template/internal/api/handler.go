@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Access 路由的访问级别
+type Access int
+
+const (
+	// AccessPublic 公开接口，只需要确定租户
+	AccessPublic Access = iota
+	// AccessUser 需要登录
+	AccessUser
+	// AccessAdmin 需要管理员角色
+	AccessAdmin
+)
+
+// Route 与Web框架无关的路由，Path使用Gin的参数语法（/users/:id）
+type Route struct {
+	Method  string
+	Path    string
+	Access  Access
+	Handler HandlerFunc
+	// Idempotent 公开接口是否支持Idempotency-Key，登录后的接口都支持
+	Idempotent bool
+}
+
+// HandlerFunc 与Web框架无关的处理函数，返回的错误由errorResponse转换为响应
+type HandlerFunc func(r *Request) (*Response, error)
+
+// Request 与Web框架无关的请求，Gin和net/http适配器只负责提供路径参数
+type Request struct {
+	*http.Request
+	param func(name string) string
+}
+
+// Param 返回路径参数
+func (r *Request) Param(name string) string {
+	return r.param(name)
+}
+
+// BindJSON 解析并校验JSON请求体，与Gin的ShouldBindJSON使用相同的校验器
+func (r *Request) BindJSON(v interface{}) error {
+	if err := binding.JSON.Bind(r.Request, v); err != nil {
+		return &bindError{err: err}
+	}
+	return nil
+}
+
+// BindQuery 解析并校验查询参数
+func (r *Request) BindQuery(v interface{}) error {
+	if err := binding.Query.Bind(r.Request, v); err != nil {
+		return &bindError{err: err}
+	}
+	return nil
+}
+
+// Claims 返回当前登录用户的认证信息，未经过认证中间件时返回401
+func (r *Request) Claims() (*middleware.Claims, error) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		return nil, &Error{Status: http.StatusUnauthorized, Message: "Unauthorized"}
+	}
+	return claims, nil
+}
+
+// Response 处理结果，Body序列化为JSON
+type Response struct {
+	Status int
+	Body   interface{}
+}
+
+// respond 返回指定状态码和{"message", "data"}，data为nil时省略
+func respond(status int, message string, data interface{}) *Response {
+	body := gin.H{"message": message}
+	if data != nil {
+		body["data"] = data
+	}
+	return &Response{Status: status, Body: body}
+}
+
+// Error 带HTTP状态码的错误。Message返回给客户端，Err为内部原因，只记录日志
+type Error struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+// Error 实现error
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 返回内部原因
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// clientError 返回状态码为status、错误信息为err.Error()的错误
+func clientError(status int, err error) *Error {
+	return &Error{Status: status, Message: err.Error(), Err: err}
+}
+
+// internalError 返回500错误，message返回给客户端，err记录到日志
+func internalError(message string, err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Message: message, Err: err}
+}
+
+// bindError 请求参数解析或校验失败
+type bindError struct {
+	err error
+}
+
+// Error 实现error
+func (e *bindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *bindError) Unwrap() error {
+	return e.err
+}
+
+// errorResponse 将处理函数返回的错误转换为响应：请求参数错误按请求语言本地化，
+// *Error使用其状态码，其他错误返回500。服务端错误记录日志
+func errorResponse(r *Request, err error, log logger.Logger) *Response {
+	var bindErr *bindError
+	if errors.As(err, &bindErr) {
+		return bindErrorResponse(r.Context(), bindErr.err)
+	}
+
+	var httpErr *Error
+	if !errors.As(err, &httpErr) {
+		httpErr = internalError("Internal server error", err)
+	}
+	if httpErr.Status >= http.StatusInternalServerError {
+		log.Error(httpErr.Message,
+			zap.String("path", r.URL.Path),
+			zap.Uint("user_id", requestinfo.FromContext(r.Context()).UserID),
+			zap.Error(httpErr.Err))
+	}
+	return &Response{Status: httpErr.Status, Body: gin.H{"error": httpErr.Message}}
+}
+
+// ginHandler 将处理函数适配为Gin处理器，服务端错误记录到log
+func ginHandler(handler HandlerFunc, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := &Request{Request: c.Request, param: c.Param}
+		resp, err := handler(r)
+		if err != nil {
+			resp = errorResponse(r, err, log)
+		}
+		c.JSON(resp.Status, resp.Body)
+	}
+}
+
+// httpHandler 将处理函数适配为net/http处理器，响应格式与Gin的c.JSON相同
+func httpHandler(handler HandlerFunc, log logger.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r := &Request{Request: req, param: req.PathValue}
+		resp, err := handler(r)
+		if err != nil {
+			resp = errorResponse(r, err, log)
+		}
+
+		body, err := json.Marshal(resp.Body)
+		if err != nil {
+			log.Error("Failed to encode response", zap.String("path", req.URL.Path), zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(resp.Status)
+		w.Write(body)
+	})
+}
+
+// HTTPMiddlewares 挂载到net/http时各访问级别使用的中间件，为nil的中间件跳过。
+// 与Gin路由的顺序一致：所有接口先经过Public、Flags；登录后的接口再经过Authorized、Flags（按用户重新评估）、
+// Idempotency，管理员接口最后经过Admin；声明了Idempotent的公开接口在Flags之后经过Idempotency
+type HTTPMiddlewares struct {
+	// Public 所有接口使用，例如middleware.HTTPTenantMiddleware、HTTPLocaleMiddleware
+	Public func(http.Handler) http.Handler
+	// Flags 功能开关评估，通常为middleware.HTTPFeatureFlagMiddleware
+	Flags func(http.Handler) http.Handler
+	// Authorized 登录后的接口使用，通常为middleware.HTTPAuthMiddleware
+	Authorized func(http.Handler) http.Handler
+	// Idempotency 幂等请求处理，通常为middleware.HTTPIdempotencyMiddleware
+	Idempotency func(http.Handler) http.Handler
+	// Admin 管理员接口使用，通常为middleware.HTTPRequireRole(model.RoleAdmin)
+	Admin func(http.Handler) http.Handler
+}
+
+// MountHTTP 将路由挂载到net/http的ServeMux，与Gin上的同名路由使用相同的处理函数和中间件顺序，服务端错误记录到log
+func MountHTTP(mux *http.ServeMux, routes []Route, mw HTTPMiddlewares, log logger.Logger) {
+	if log == nil {
+		log = logger.NewNop()
+	}
+	for _, route := range routes {
+		handler := httpHandler(route.Handler, log)
+		switch route.Access {
+		case AccessAdmin:
+			handler = wrap(wrap(wrap(wrap(handler, mw.Admin), mw.Idempotency), mw.Flags), mw.Authorized)
+		case AccessUser:
+			handler = wrap(wrap(wrap(handler, mw.Idempotency), mw.Flags), mw.Authorized)
+		case AccessPublic:
+			if route.Idempotent {
+				handler = wrap(handler, mw.Idempotency)
+			}
+		}
+		mux.Handle(route.Method+" "+muxPattern(route.Path), wrap(wrap(handler, mw.Flags), mw.Public))
+	}
+}
+
+// wrap 使用中间件包装处理器，中间件为nil时原样返回
+func wrap(handler http.Handler, mw func(http.Handler) http.Handler) http.Handler {
+	if mw == nil {
+		return handler
+	}
+	return mw(handler)
+}
+
+// muxPattern 将Gin的路径参数语法（:id）转换为ServeMux的语法（{id}）
+func muxPattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
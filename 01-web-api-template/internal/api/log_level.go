@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// logLevelHandler 查看当前的日志级别
+func logLevelHandler(c *gin.Context, levels *logger.Levels) {
+	if levels == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Logger is not initialized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Log level retrieved successfully",
+		"data":    logLevelResponse(levels),
+	})
+}
+
+// setLogLevelHandler 修改根级别或命名日志记录器的级别，立即生效，重启后恢复配置文件中的级别
+func setLogLevelHandler(c *gin.Context, levels *logger.Levels, log logger.Logger) {
+	var req dto.UpdateLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if levels == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Logger is not initialized"})
+		return
+	}
+	if err := levels.Set(req.Logger, req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 使用Warn级别，保证调高级别后仍能看到这次修改
+	log.Warn("Log level changed",
+		zap.String("logger", req.Logger),
+		zap.String("level", req.Level),
+		zap.String("by", requestinfo.FromContext(c.Request.Context()).Username))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Log level updated successfully",
+		"data":    logLevelResponse(levels),
+	})
+}
+
+// resetLogLevelHandler 取消命名日志记录器的级别设置，恢复跟随根级别
+func resetLogLevelHandler(c *gin.Context, levels *logger.Levels, log logger.Logger) {
+	if levels == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Logger is not initialized"})
+		return
+	}
+
+	name := c.Param("logger")
+	if _, ok := levels.Overrides()[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Log level override not found"})
+		return
+	}
+	levels.Reset(name)
+
+	log.Warn("Log level reset",
+		zap.String("logger", name),
+		zap.String("by", requestinfo.FromContext(c.Request.Context()).Username))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Log level reset successfully",
+		"data":    logLevelResponse(levels),
+	})
+}
+
+// logLevelResponse 转换为响应
+func logLevelResponse(levels *logger.Levels) dto.LogLevelResponse {
+	return dto.LogLevelResponse{
+		Level:   levels.Root(),
+		Loggers: levels.Overrides(),
+		Names:   levels.Names(),
+	}
+}
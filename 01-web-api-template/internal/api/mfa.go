@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// mfaErrorStatus 将二次验证错误映射为HTTP状态码
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidMFACode), errors.Is(err, service.ErrInvalidMFAToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrTooManyMFAAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrAccountDeactivated):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// mfaEnrollHandler 登记TOTP密钥
+func mfaEnrollHandler(c *gin.Context, mfaService service.MFAService, log logger.Logger) {
+	claims, _ := middleware.CurrentClaims(c)
+
+	enrollment, err := mfaService.Enroll(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scan the otpauth URI and confirm with a code",
+		"data":    enrollment,
+	})
+	log.Info("MFA enroll endpoint called", zap.Uint("user_id", claims.UserID))
+}
+
+// mfaConfirmHandler 确认并启用二次验证
+func mfaConfirmHandler(c *gin.Context, mfaService service.MFAService, log logger.Logger) {
+	claims, _ := middleware.CurrentClaims(c)
+
+	var req dto.MFAConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	result, err := mfaService.Confirm(c.Request.Context(), claims.UserID, req.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication enabled, store the recovery codes safely",
+		"data":    result,
+	})
+	log.Info("MFA confirm endpoint called", zap.Uint("user_id", claims.UserID))
+}
+
+// mfaLoginHandler 完成登录二次验证
+func mfaLoginHandler(c *gin.Context, mfaService service.MFAService, log logger.Logger) {
+	var req dto.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	token, err := mfaService.VerifyLogin(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"token":   token,
+	})
+	log.Info("MFA login endpoint called")
+}
+
+// adminResetMFAHandler 管理员重置用户的二次验证
+func adminResetMFAHandler(c *gin.Context, mfaService service.MFAService, log logger.Logger) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	if err := mfaService.Reset(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, _ := middleware.CurrentClaims(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset successfully"})
+	log.Info("Admin reset MFA endpoint called",
+		zap.Uint("admin_id", claims.UserID),
+		zap.Uint64("user_id", id))
+}
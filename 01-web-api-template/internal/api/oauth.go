@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// oauthProvidersHandler 列出可用的第三方登录提供方
+func oauthProvidersHandler(c *gin.Context, oauthService service.OAuthService) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "OAuth providers retrieved successfully",
+		"data":    oauthService.Providers(),
+	})
+}
+
+// oauthLoginHandler 重定向到第三方授权页面
+func oauthLoginHandler(c *gin.Context, oauthService service.OAuthService, log logger.Logger) {
+	provider := c.Param("provider")
+
+	authURL, err := oauthService.AuthCodeURL(c.Request.Context(), provider)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to build oauth authorization url",
+			zap.String("provider", provider), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "OAuth provider unavailable"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// oauthCallbackHandler 处理第三方授权回调并签发登录令牌
+func oauthCallbackHandler(c *gin.Context, oauthService service.OAuthService, log logger.Logger) {
+	provider := c.Param("provider")
+
+	// 用户在提供方拒绝授权
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuth authorization failed: " + errCode})
+		return
+	}
+
+	result, err := oauthService.HandleCallback(c.Request.Context(), provider, c.Query("state"), c.Query("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidOAuthState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAccountDeactivated):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.Warn("OAuth callback failed", zap.String("provider", provider), zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuth login failed"})
+		}
+		return
+	}
+
+	if result.MFARequired {
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "MFA verification required",
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"token":   result.Token,
+	})
+	log.Info("OAuth login endpoint called", zap.String("provider", provider))
+}
@@ -0,0 +1,298 @@
+// Package openapi 根据路由表和dto结构体生成OpenAPI 3文档
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Info 文档基本信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Route 路由表中的一条路由，路径使用Gin格式（:id、*path）
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Operation 接口文档描述，由各路由手工登记，请求和响应结构由dto类型反射生成
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Auth 是否需要JWT认证
+	Auth bool
+	// Query 查询参数结构体（form标签）
+	Query interface{}
+	// Body JSON请求体结构体
+	Body interface{}
+	// Upload multipart/form-data上传的文件字段名
+	Upload string
+	// Data 成功响应中data字段的类型，响应体为 {"message", "data"}
+	Data interface{}
+	// Response 完整的成功响应体类型，设置后忽略Data
+	Response interface{}
+	// ContentType 成功响应的内容类型，默认 application/json
+	ContentType string
+	// Status 成功状态码，默认200
+	Status int
+	// Errors 可能返回的错误状态码，响应体为 {"error"}
+	Errors []int
+}
+
+// Document OpenAPI 3文档
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// bearerAuth 安全方案名称
+const bearerAuth = "bearerAuth"
+
+// Generator 文档生成器
+type Generator struct {
+	doc *Document
+}
+
+// NewGenerator 创建文档生成器
+func NewGenerator(info Info) *Generator {
+	return &Generator{doc: &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*operation{},
+		Components: components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*securityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}}
+}
+
+// Build 为路由表生成文档，返回文档以及未登记文档的路由（格式为 "METHOD /path"）
+func (g *Generator) Build(routes []Route, operations map[string]Operation) (*Document, []string) {
+	var missing []string
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		op, ok := operations[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		path, params := convertPath(route.Path)
+		if g.doc.Paths[path] == nil {
+			g.doc.Paths[path] = map[string]*operation{}
+		}
+		g.doc.Paths[path][strings.ToLower(route.Method)] = g.operation(route, op, params)
+	}
+	sort.Strings(missing)
+	return g.doc, missing
+}
+
+// operation 生成单个接口的描述
+func (g *Generator) operation(route Route, op Operation, pathParams []*parameter) *operation {
+	result := &operation{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		OperationID: operationID(route),
+		Parameters:  pathParams,
+		Responses:   map[string]*response{},
+	}
+	if op.Auth {
+		result.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	if op.Query != nil {
+		result.Parameters = append(result.Parameters, g.queryParameters(reflect.TypeOf(op.Query))...)
+	}
+
+	switch {
+	case op.Body != nil:
+		result.RequestBody = &requestBody{
+			Required: true,
+			Content: map[string]*mediaType{
+				"application/json": {Schema: g.schemaFor(reflect.TypeOf(op.Body))},
+			},
+		}
+	case op.Upload != "":
+		result.RequestBody = &requestBody{
+			Required: true,
+			Content: map[string]*mediaType{
+				"multipart/form-data": {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{op.Upload: {Type: "string", Format: "binary"}},
+					Required:   []string{op.Upload},
+				}},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	result.Responses[strconv.Itoa(status)] = g.successResponse(status, op)
+
+	for _, code := range op.Errors {
+		result.Responses[strconv.Itoa(code)] = &response{
+			Description: http.StatusText(code),
+			Content: map[string]*mediaType{
+				"application/json": {Schema: errorSchema()},
+			},
+		}
+	}
+	return result
+}
+
+// successResponse 生成成功响应，默认使用 {"message", "data"} 结构
+func (g *Generator) successResponse(status int, op Operation) *response {
+	result := &response{Description: http.StatusText(status)}
+	if status == http.StatusFound || status == http.StatusNoContent {
+		return result
+	}
+
+	var schema *Schema
+	switch {
+	case op.Response != nil:
+		schema = g.schemaFor(reflect.TypeOf(op.Response))
+	default:
+		schema = &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"message": {Type: "string"}},
+			Required:   []string{"message"},
+		}
+		if op.Data != nil {
+			schema.Properties["data"] = g.schemaFor(reflect.TypeOf(op.Data))
+		}
+	}
+
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	result.Content = map[string]*mediaType{contentType: {Schema: schema}}
+	return result
+}
+
+// queryParameters 根据form标签生成查询参数
+func (g *Generator) queryParameters(t reflect.Type) []*parameter {
+	t = indirect(t)
+	var params []*parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		schema := g.schemaFor(field.Type)
+		required := applyBinding(schema, field.Type, field.Tag.Get("binding"))
+		params = append(params, &parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+// convertPath 将Gin路径参数转换为OpenAPI格式，例如 /users/:id -> /users/{id}
+func convertPath(path string) (string, []*parameter) {
+	segments := strings.Split(path, "/")
+	var params []*parameter
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+
+		schema := &Schema{Type: "string"}
+		if name == "id" {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+		params = append(params, &parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 根据方法和路径生成唯一的operationId
+func operationID(route Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.Split(route.Path, "/") {
+		segment = strings.TrimLeft(segment, ":*")
+		for _, part := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// errorSchema 错误响应结构，参数校验失败时包含逐字段的错误信息
+func errorSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error": {Type: "string"},
+			"fields": {Type: "array", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"field":   {Type: "string"},
+					"message": {Type: "string"},
+				},
+			}},
+		},
+		Required: []string{"error"},
+	}
+}
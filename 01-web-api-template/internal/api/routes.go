@@ -4,119 +4,254 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
 	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/diagnostics"
 	"go-practical-roadmap/01-web-api-template/internal/service"
 	"go-practical-roadmap/01-web-api-template/pkg/logger"
-	"go.uber.org/zap"
 )
 
+// Dependencies 路由依赖的配置和服务，由App创建后注入。
+// 为nil的服务对应的路由仍会注册，便于测试只关心路由表和中间件的场景；
+// Sessions为nil时认证中间件只校验令牌，不校验会话是否已注销；
+// Tenants为nil时无法限定默认租户，不注册平台管理接口和诊断接口
+type Dependencies struct {
+	Config      *config.Config
+	Logger      logger.Logger
+	Tokens      *middleware.TokenIssuer
+	Users       service.UserService
+	OAuth       service.OAuthService
+	MFA         service.MFAService
+	Sessions    service.SessionService
+	Audit       service.AuditService
+	Avatars     service.AvatarService
+	Idempotency middleware.IdempotencyStore
+	UserCache   cache.Cache
+	Tenants     service.TenantService
+	BulkUsers   service.BulkUserService
+	Webhooks    service.WebhookService
+	Flags       service.FlagService
+}
+
+// withDefaults 为未注入的配置、日志记录器和令牌签发器补充默认值
+func (d Dependencies) withDefaults() Dependencies {
+	if d.Config == nil {
+		// 未加载配置时（例如测试中）使用默认租户，健康检查仍不记录Info级别的请求日志，诊断接口不开启
+		d.Config = &config.Config{
+			Tenant: config.TenantConfig{Default: "default"},
+			Logger: config.LoggerConfig{QuietPaths: []string{"/health"}},
+		}
+	}
+	if d.Logger == nil {
+		d.Logger = logger.NewNop()
+	}
+	if d.Tokens == nil {
+		d.Tokens = middleware.NewTokenIssuer(d.Config.JWT)
+	}
+	return d
+}
+
 // SetupRoutes 设置Gin路由
-func SetupRoutes(userService service.UserService) *gin.Engine {
+func SetupRoutes(deps Dependencies) *gin.Engine {
+	deps = deps.withDefaults()
+	cfg := deps.Config
+	log := deps.Logger
+
 	// 创建Gin引擎
 	r := gin.New()
 
-	// 添加日志和恢复中间件
-	r.Use(gin.Logger())
+	// 添加恢复中间件，请求日志由RequestTracerMiddleware记录
 	r.Use(gin.Recovery())
 
+	// 安全响应头和请求体大小限制，上传接口由服务按各自的配置限制大小
+	r.Use(middleware.SecurityHeadersMiddleware(cfg.Server.Headers))
+	r.Use(middleware.BodyLimitMiddleware(cfg.Server.MaxBodySize, map[string]int64{
+		"PUT /api/v1/profile/avatar":      0,
+		"POST /api/v1/admin/users/import": 0,
+	}))
+
 	// 添加自定义中间件
-	r.Use(middleware.RequestTracerMiddleware())
+	r.Use(middleware.RequestTracerMiddleware(log, cfg.Logger.QuietPaths...))
+	r.Use(middleware.ConsistencyMiddleware())
 	r.Use(middleware.CORSMiddleware())
+	r.Use(middleware.LocaleMiddleware(cfg.I18n.DefaultLocale))
+
+	// 功能开关先按未登录请求评估，认证后的路由按用户重新评估
+	featureFlags := middleware.FeatureFlagMiddleware(flagEvaluator(deps.Flags))
+	r.Use(featureFlags)
+
+	// 校验错误按请求语言本地化
+	registerValidation(log)
 
 	// 公开路由
 	r.GET("/health", healthCheck)
-	r.POST("/api/v1/register", func(c *gin.Context) {
-		registerHandler(c, userService)
+	idempotency := middleware.IdempotencyMiddleware(deps.Idempotency, log)
+
+	// 访问用户数据的路由需要先确定租户
+	tenantConfig := cfg.Tenant
+	tenancy := middleware.TenantMiddleware(deps.Tenants, tenantConfig, log)
+
+	r.POST("/api/v1/login/mfa", tenancy, func(c *gin.Context) {
+		mfaLoginHandler(c, deps.MFA, log)
+	})
+
+	// 第三方登录（OIDC授权码 + PKCE），发起登录时解析的租户随state保存，回调时使用该租户
+	r.GET("/api/v1/auth/providers", func(c *gin.Context) {
+		oauthProvidersHandler(c, deps.OAuth)
 	})
-	r.POST("/api/v1/login", func(c *gin.Context) {
-		loginHandler(c, userService)
+	r.GET("/api/v1/auth/:provider/login", tenancy, func(c *gin.Context) {
+		oauthLoginHandler(c, deps.OAuth, log)
+	})
+	r.GET("/api/v1/auth/:provider/callback", tenancy, func(c *gin.Context) {
+		oauthCallbackHandler(c, deps.OAuth, log)
+	})
+
+	// 签名地址本身即授权凭证，无需JWT
+	r.GET("/api/v1/files/*key", func(c *gin.Context) {
+		serveFileHandler(c, deps.Avatars, log)
 	})
 
 	// 受保护的路由组
 	authorized := r.Group("/")
-	authorized.Use(middleware.JWTAuthMiddleware)
+	authorized.Use(tenancy, middleware.JWTAuthMiddleware(deps.Tokens, deps.Sessions, log), featureFlags, idempotency)
 	{
-		authorized.GET("/api/v1/profile", profileHandler)
+		authorized.GET("/api/v1/flags", currentFlagsHandler)
+		authorized.PUT("/api/v1/profile/avatar", func(c *gin.Context) {
+			uploadAvatarHandler(c, deps.Avatars, log)
+		})
+		authorized.POST("/api/v1/mfa/totp/enroll", func(c *gin.Context) {
+			mfaEnrollHandler(c, deps.MFA, log)
+		})
+		authorized.POST("/api/v1/mfa/totp/confirm", func(c *gin.Context) {
+			mfaConfirmHandler(c, deps.MFA, log)
+		})
+		authorized.GET("/api/v1/sessions", func(c *gin.Context) {
+			listSessionsHandler(c, deps.Sessions, log)
+		})
+		authorized.DELETE("/api/v1/sessions/:id", func(c *gin.Context) {
+			revokeSessionHandler(c, deps.Sessions, log)
+		})
 	}
 
-	return r
-}
-
-// healthCheck 健康检查端点
-func healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"message": "Service is running",
-	})
-	logger.Info("Health check endpoint called")
-}
-
-// registerHandler 注册端点
-func registerHandler(c *gin.Context, userService service.UserService) {
-	var req dto.RegisterRequest
-
-	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	// 管理员路由组
+	admin := authorized.Group("/api/v1/admin")
+	admin.Use(middleware.RequireRole(model.RoleAdmin, log))
+	{
+		admin.DELETE("/users/:id/mfa", func(c *gin.Context) {
+			adminResetMFAHandler(c, deps.MFA, log)
+		})
+		admin.GET("/users/export", func(c *gin.Context) {
+			exportUsersHandler(c, deps.BulkUsers, log)
+		})
+		admin.POST("/users/import", func(c *gin.Context) {
+			importUsersHandler(c, deps.BulkUsers, log)
+		})
+		admin.GET("/users/import/:id", func(c *gin.Context) {
+			importJobHandler(c, deps.BulkUsers, log)
+		})
+		admin.GET("/users/import/:id/errors", func(c *gin.Context) {
+			importErrorsHandler(c, deps.BulkUsers, log)
+		})
+		admin.GET("/audit", func(c *gin.Context) {
+			listAuditLogsHandler(c, deps.Audit, log)
+		})
+		admin.GET("/webhooks", func(c *gin.Context) {
+			listWebhooksHandler(c, deps.Webhooks, log)
+		})
+		admin.POST("/webhooks", func(c *gin.Context) {
+			createWebhookHandler(c, deps.Webhooks, log)
+		})
+		admin.PUT("/webhooks/:id", func(c *gin.Context) {
+			updateWebhookHandler(c, deps.Webhooks, log)
+		})
+		admin.DELETE("/webhooks/:id", func(c *gin.Context) {
+			deleteWebhookHandler(c, deps.Webhooks, log)
+		})
+		admin.GET("/webhooks/:id/deliveries", func(c *gin.Context) {
+			listWebhookDeliveriesHandler(c, deps.Webhooks, log)
+		})
 	}
 
-	// 调用用户服务注册
-	user, err := userService.Register(&req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	// 用户接口的处理函数与net/http版本（MountHTTP）共用，按访问级别挂载到对应的路由组
+	userAdmin := authorized.Group("", middleware.RequireRole(model.RoleAdmin, log))
+	for _, route := range NewUserHandlers(deps.Users, log).Routes() {
+		handler := ginHandler(route.Handler, log)
+		switch route.Access {
+		case AccessPublic:
+			if route.Idempotent {
+				r.Handle(route.Method, route.Path, tenancy, idempotency, handler)
+			} else {
+				r.Handle(route.Method, route.Path, tenancy, handler)
+			}
+		case AccessUser:
+			authorized.Handle(route.Method, route.Path, handler)
+		case AccessAdmin:
+			userAdmin.Handle(route.Method, route.Path, handler)
+		}
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
-		"data":    user,
-	})
-	logger.Info("User registration endpoint called",
-		zap.String("username", req.Username),
-		zap.String("email", req.Email))
-}
-
-// loginHandler 登录端点
-func loginHandler(c *gin.Context, userService service.UserService) {
-	var req dto.LoginRequest
-
-	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	// 租户、功能开关、日志级别和缓存统计对全部租户生效，只对默认租户的管理员开放
+	if deps.Tenants != nil {
+		platform := admin.Group("")
+		platform.Use(middleware.RequireTenant(deps.Tenants, tenantConfig.Default, log))
+		platform.GET("/tenants", func(c *gin.Context) {
+			listTenantsHandler(c, deps.Tenants, log)
+		})
+		platform.POST("/tenants", func(c *gin.Context) {
+			createTenantHandler(c, deps.Tenants, log)
+		})
+		platform.GET("/flags", func(c *gin.Context) {
+			listFlagsHandler(c, deps.Flags, log)
+		})
+		platform.PUT("/flags/:key", func(c *gin.Context) {
+			setFlagHandler(c, deps.Flags, log)
+		})
+		platform.DELETE("/flags/:key", func(c *gin.Context) {
+			resetFlagHandler(c, deps.Flags, log)
+		})
+		platform.GET("/cache/stats", func(c *gin.Context) {
+			cacheStatsHandler(c, deps.UserCache)
+		})
+		levels := log.Levels()
+		platform.GET("/log-level", func(c *gin.Context) {
+			logLevelHandler(c, levels)
+		})
+		platform.PUT("/log-level", func(c *gin.Context) {
+			setLogLevelHandler(c, levels, log)
+		})
+		platform.DELETE("/log-level/:logger", func(c *gin.Context) {
+			resetLogLevelHandler(c, levels, log)
+		})
 	}
 
-	// 调用用户服务登录
-	token, err := userService.Login(&req)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+	// API文档
+	registerDocs(r, log)
+
+	// 诊断接口不属于公开API，在生成文档之后注册；配置了独立监听地址时由App另行启动
+	if debugConfig := cfg.Debug; debugConfig.Enabled && debugConfig.Listen == "" && deps.Tenants != nil {
+		debug := r.Group("/debug")
+		debug.Use(tenancy, middleware.JWTAuthMiddleware(deps.Tokens, deps.Sessions, log), middleware.RequireRole(model.RoleAdmin, log),
+			middleware.RequireTenant(deps.Tenants, tenantConfig.Default, log))
+		registerDebugRoutes(debug, diagnostics.NewProfiler(debugConfig.ProfileDir, debugConfig.MaxProfileDuration), log)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-	})
-	logger.Info("User login endpoint called",
-		zap.String("username", req.Username))
+	return r
 }
 
-// profileHandler 用户信息端点
-func profileHandler(c *gin.Context) {
-	// 模拟用户数据
-	user := dto.UserProfileResponse{
-		ID:       1,
-		Username: "testuser",
-		Email:    "test@example.com",
+// flagEvaluator 功能开关服务为nil时（例如测试中）返回nil接口，使中间件跳过评估
+func flagEvaluator(flagService service.FlagService) middleware.FlagEvaluator {
+	if flagService == nil {
+		return nil
 	}
+	return flagService
+}
 
+// healthCheck 健康检查端点
+func healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User profile retrieved successfully",
-		"data":    user,
+		"status":  "ok",
+		"message": "Service is running",
 	})
-	logger.Info("User profile endpoint called",
-		zap.Uint("user_id", user.ID),
-		zap.String("username", user.Username))
-}
\ No newline at end of file
+}
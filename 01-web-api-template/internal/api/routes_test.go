@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -123,4 +128,105 @@ func TestLoginHandler_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Login successful", response["message"])
 	assert.Equal(t, "mock-jwt-token", response["token"])
-}
\ No newline at end of file
+}
+
+func TestRegisterHandler_LocalizedValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRoutes(Dependencies{})
+
+	request := func(acceptLanguage string) map[string]interface{} {
+		body := bytes.NewBufferString(`{"username": "ab", "email": "bad", "password": "secret123"}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/register", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := request("zh-CN,zh;q=0.9")
+	assert.Equal(t, "请求参数校验失败", response["error"])
+	fields := response["fields"].([]interface{})
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "username", fields[0].(map[string]interface{})["field"])
+	assert.Equal(t, "username长度必须至少为3个字符", fields[0].(map[string]interface{})["message"])
+
+	response = request("en-US")
+	assert.Equal(t, "Validation failed", response["error"])
+	assert.Equal(t, "email", response["fields"].([]interface{})[1].(map[string]interface{})["field"])
+}
+
+func TestSecurityMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.SecurityHeadersMiddleware(config.SecurityHeadersConfig{
+		HSTSMaxAge:            24 * time.Hour,
+		ContentSecurityPolicy: "default-src 'none'",
+		FrameOptions:          "DENY",
+	}))
+	r.Use(middleware.BodyLimitMiddleware(16, map[string]int64{"POST /upload": 0}))
+	bind := func(c *gin.Context) {
+		var req map[string]interface{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	}
+	r.POST("/echo", bind)
+	r.POST("/upload", bind)
+
+	send := func(path string, body string, chunked bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if chunked {
+			req.ContentLength = -1
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/echo", `{"a":1}`, false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'none'", w.Header().Get("Content-Security-Policy"))
+	// HSTS只对HTTPS请求发送
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+
+	large := `{"a":"` + strings.Repeat("x", 32) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send("/echo", large, false).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send("/echo", large, true).Code)
+	assert.Equal(t, http.StatusOK, send("/upload", large, false).Code)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "max-age=86400", w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestPlatformRoutes_RequireTenantService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := middleware.NewTokenIssuer(config.JWTConfig{Secret: "test-secret", AccessTokenExp: 3600})
+	token, _, err := tokens.GenerateToken(1, "root", model.RoleAdmin, 0)
+	assert.NoError(t, err)
+
+	request := func(r *gin.Engine) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/admin/log-level", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 未注入租户服务时无法限定默认租户，不注册平台管理接口
+	assert.Equal(t, http.StatusNotFound, request(SetupRoutes(Dependencies{Tokens: tokens})))
+	assert.Equal(t, http.StatusOK, request(SetupRoutes(Dependencies{Tokens: tokens, Tenants: fakeTenants{}})))
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// listSessionsHandler 列出当前用户的登录会话
+func listSessionsHandler(c *gin.Context, sessionService service.SessionService, log logger.Logger) {
+	claims, _ := middleware.CurrentClaims(c)
+
+	sessions, err := sessionService.List(c.Request.Context(), claims.UserID, claims.ID)
+	if err != nil {
+		log.Error("Failed to list sessions", zap.Uint("user_id", claims.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sessions retrieved successfully",
+		"data":    sessions,
+	})
+}
+
+// revokeSessionHandler 注销当前用户的指定会话（远程登出）
+func revokeSessionHandler(c *gin.Context, sessionService service.SessionService, log logger.Logger) {
+	claims, _ := middleware.CurrentClaims(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+		return
+	}
+
+	if err := sessionService.Revoke(c.Request.Context(), claims.UserID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to revoke session", zap.Uint("user_id", claims.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+	log.Info("Revoke session endpoint called",
+		zap.Uint("user_id", claims.UserID),
+		zap.Uint64("session_id", id))
+}
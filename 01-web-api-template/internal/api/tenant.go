@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// listTenantsHandler 列出全部租户
+func listTenantsHandler(c *gin.Context, tenantService service.TenantService, log logger.Logger) {
+	tenants, err := tenantService.List(c.Request.Context())
+	if err != nil {
+		log.Error("Failed to list tenants", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tenants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tenants retrieved successfully",
+		"data":    tenants,
+	})
+}
+
+// createTenantHandler 创建租户
+func createTenantHandler(c *gin.Context, tenantService service.TenantService, log logger.Logger) {
+	var req dto.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	tenant, err := tenantService.Create(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTenantSlug):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTenantExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error("Failed to create tenant", zap.String("slug", req.Slug), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tenant"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tenant created successfully",
+		"data":    tenant,
+	})
+}
@@ -1,93 +1,317 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/password"
 	"go-practical-roadmap/01-web-api-template/internal/service"
 	"go-practical-roadmap/01-web-api-template/pkg/logger"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-// UserController 用户控制器
-type UserController struct {
-	userService service.UserService
+// UserHandlers 基于UserService的用户接口，同一组处理函数可以挂载到Gin（SetupRoutes）或net/http（MountHTTP）
+type UserHandlers struct {
+	users service.UserService
+	log   logger.Logger
 }
 
-// NewUserController 创建用户控制器实例
-func NewUserController(userService service.UserService) *UserController {
-	return &UserController{userService: userService}
+// NewUserHandlers 创建用户接口，log记录接口调用
+func NewUserHandlers(users service.UserService, log logger.Logger) *UserHandlers {
+	return &UserHandlers{users: users, log: log}
+}
+
+// Routes 用户接口的路由表
+func (h *UserHandlers) Routes() []Route {
+	return []Route{
+		{Method: http.MethodPost, Path: "/api/v1/register", Access: AccessPublic, Handler: h.Register, Idempotent: true},
+		{Method: http.MethodPost, Path: "/api/v1/login", Access: AccessPublic, Handler: h.Login},
+		{Method: http.MethodGet, Path: "/api/v1/profile", Access: AccessUser, Handler: h.Profile},
+		{Method: http.MethodPut, Path: "/api/v1/profile/password", Access: AccessUser, Handler: h.ChangePassword},
+		{Method: http.MethodPut, Path: "/api/v1/profile/email", Access: AccessUser, Handler: h.ChangeEmail},
+		{Method: http.MethodPost, Path: "/api/v1/profile/deactivate", Access: AccessUser, Handler: h.DeactivateAccount},
+		{Method: http.MethodPut, Path: "/api/v1/admin/users/:id/role", Access: AccessAdmin, Handler: h.ChangeRole},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/users/:id", Access: AccessAdmin, Handler: h.DeleteUser},
+		{Method: http.MethodGet, Path: "/api/v1/admin/users/deleted", Access: AccessAdmin, Handler: h.ListDeleted},
+		{Method: http.MethodPost, Path: "/api/v1/admin/users/:id/restore", Access: AccessAdmin, Handler: h.RestoreUser},
+		{Method: http.MethodPost, Path: "/api/v1/admin/users/:id/reactivate", Access: AccessAdmin, Handler: h.ReactivateUser},
+	}
 }
 
 // Register 注册用户
-func (c *UserController) Register(w http.ResponseWriter, r *http.Request) {
+func (h *UserHandlers) Register(r *Request) (*Response, error) {
 	var req dto.RegisterRequest
-
-	// 解析请求参数
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+	if err := r.BindJSON(&req); err != nil {
+		return nil, err
 	}
 
-	// 调用UserService.Register
-	user, err := c.userService.Register(&req)
+	user, err := h.users.Register(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, clientError(http.StatusBadRequest, err)
 	}
 
-	// 返回响应
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User registered successfully",
-		"data":    user,
-	})
-
-	logger.Info("User registration endpoint called",
+	h.log.Info("User registration endpoint called",
 		zap.String("username", req.Username),
 		zap.String("email", req.Email))
+	return respond(http.StatusCreated, "User registered successfully", user), nil
 }
 
-// Login 用户登录
-func (c *UserController) Login(w http.ResponseWriter, r *http.Request) {
+// Login 用户登录，启用二次验证时返回等待验证令牌，需要继续调用 /api/v1/login/mfa
+func (h *UserHandlers) Login(r *Request) (*Response, error) {
 	var req dto.LoginRequest
-
-	// 解析请求参数
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+	if err := r.BindJSON(&req); err != nil {
+		return nil, err
 	}
 
-	// 调用UserService.Login
-	token, err := c.userService.Login(&req)
+	result, err := h.users.Login(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
+		if errors.Is(err, service.ErrAccountDeactivated) {
+			return nil, clientError(http.StatusForbidden, err)
+		}
+		return nil, clientError(http.StatusUnauthorized, err)
 	}
 
-	// 返回JWT令牌
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	if result.MFARequired {
+		return &Response{Status: http.StatusOK, Body: map[string]interface{}{
+			"message":      "MFA verification required",
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		}}, nil
+	}
+
+	h.log.Info("User login endpoint called", zap.String("username", req.Username))
+	return &Response{Status: http.StatusOK, Body: map[string]interface{}{
 		"message": "Login successful",
-		"token":   token,
-	})
+		"token":   result.Token,
+	}}, nil
+}
+
+// Profile 当前用户的信息
+func (h *UserHandlers) Profile(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.users.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusNotFound, Message: "User not found", Err: err}
+	}
+
+	h.log.Info("User profile endpoint called",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username))
+	return respond(http.StatusOK, "User profile retrieved successfully", user), nil
+}
+
+// ChangePassword 修改当前用户密码
+func (h *UserHandlers) ChangePassword(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := r.BindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	if err := h.users.ChangePassword(r.Context(), claims.UserID, &req); err != nil {
+		if errors.Is(err, service.ErrIncorrectPassword) || errors.Is(err, password.ErrWeakPassword) {
+			return nil, clientError(http.StatusBadRequest, err)
+		}
+		return nil, internalError("Failed to change password", err)
+	}
+
+	h.log.Info("Change password endpoint called", zap.Uint("user_id", claims.UserID))
+	return respond(http.StatusOK, "Password changed successfully", nil), nil
+}
+
+// ChangeEmail 修改当前用户邮箱
+func (h *UserHandlers) ChangeEmail(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+
+	var req dto.ChangeEmailRequest
+	if err := r.BindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	user, err := h.users.ChangeEmail(r.Context(), claims.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrIncorrectPassword):
+			return nil, clientError(http.StatusBadRequest, err)
+		case errors.Is(err, service.ErrEmailExists):
+			return nil, clientError(http.StatusConflict, err)
+		default:
+			return nil, internalError("Failed to change email", err)
+		}
+	}
+
+	h.log.Info("Change email endpoint called", zap.Uint("user_id", claims.UserID))
+	return respond(http.StatusOK, "Email changed successfully", user), nil
+}
+
+// DeactivateAccount 停用当前用户的账号
+func (h *UserHandlers) DeactivateAccount(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+
+	var req dto.DeactivateAccountRequest
+	if err := r.BindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	if err := h.users.DeactivateAccount(r.Context(), claims.UserID, &req); err != nil {
+		if errors.Is(err, service.ErrIncorrectPassword) {
+			return nil, clientError(http.StatusBadRequest, err)
+		}
+		return nil, internalError("Failed to deactivate account", err)
+	}
+
+	h.log.Info("Deactivate account endpoint called", zap.Uint("user_id", claims.UserID))
+	return respond(http.StatusOK, "Account deactivated successfully", nil), nil
+}
+
+// ChangeRole 管理员修改用户角色
+func (h *UserHandlers) ChangeRole(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+	id, err := userIDParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req dto.ChangeRoleRequest
+	if err := r.BindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	user, err := h.users.ChangeRole(r.Context(), id, req.Role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &Error{Status: http.StatusNotFound, Message: "User not found", Err: err}
+		}
+		return nil, internalError("Failed to change user role", err)
+	}
+
+	h.log.Info("Admin change role endpoint called",
+		zap.Uint("admin_id", claims.UserID),
+		zap.Uint("user_id", id),
+		zap.String("role", req.Role))
+	return respond(http.StatusOK, "User role changed successfully", user), nil
+}
+
+// DeleteUser 管理员软删除用户
+func (h *UserHandlers) DeleteUser(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+	id, err := userIDParam(r)
+	if err != nil {
+		return nil, err
+	}
+	if id == claims.UserID {
+		return nil, &Error{Status: http.StatusBadRequest, Message: "Cannot delete yourself"}
+	}
+
+	if err := h.users.DeleteUser(r.Context(), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &Error{Status: http.StatusNotFound, Message: "User not found", Err: err}
+		}
+		return nil, internalError("Failed to delete user", err)
+	}
 
-	logger.Info("User login endpoint called", zap.String("username", req.Username))
+	h.log.Info("Admin delete user endpoint called",
+		zap.Uint("admin_id", claims.UserID),
+		zap.Uint("user_id", id))
+	return respond(http.StatusOK, "User deleted successfully", nil), nil
+}
+
+// ListDeleted 管理员查看已删除的用户
+func (h *UserHandlers) ListDeleted(r *Request) (*Response, error) {
+	var query dto.DeletedUserQuery
+	if err := r.BindQuery(&query); err != nil {
+		return nil, err
+	}
+
+	result, err := h.users.ListDeleted(r.Context(), &query)
+	if err != nil {
+		return nil, internalError("Failed to list deleted users", err)
+	}
+	return respond(http.StatusOK, "Deleted users retrieved successfully", result), nil
 }
 
-// GetProfile 获取用户信息
-func (c *UserController) GetProfile(w http.ResponseWriter, r *http.Request) {
-	// TODO: 实现获取用户信息逻辑
-	// 从JWT中获取用户信息
-	// 返回用户详情
-
-	logger.Info("Get user profile endpoint called")
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Get user profile endpoint",
-	})
-}
\ No newline at end of file
+// RestoreUser 管理员恢复已删除的用户
+func (h *UserHandlers) RestoreUser(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+	id, err := userIDParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.users.RestoreUser(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, &Error{Status: http.StatusNotFound, Message: "Deleted user not found", Err: err}
+		case errors.Is(err, service.ErrUserConflict):
+			return nil, clientError(http.StatusConflict, err)
+		default:
+			return nil, internalError("Failed to restore user", err)
+		}
+	}
+
+	h.log.Info("Admin restore user endpoint called",
+		zap.Uint("admin_id", claims.UserID),
+		zap.Uint("user_id", id))
+	return respond(http.StatusOK, "User restored successfully", user), nil
+}
+
+// ReactivateUser 管理员重新启用已停用的账号
+func (h *UserHandlers) ReactivateUser(r *Request) (*Response, error) {
+	claims, err := r.Claims()
+	if err != nil {
+		return nil, err
+	}
+	id, err := userIDParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.users.ReactivateUser(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &Error{Status: http.StatusNotFound, Message: "User not found", Err: err}
+		}
+		return nil, internalError("Failed to reactivate user", err)
+	}
+
+	h.log.Info("Admin reactivate user endpoint called",
+		zap.Uint("admin_id", claims.UserID),
+		zap.Uint("user_id", id))
+	return respond(http.StatusOK, "User reactivated successfully", user), nil
+}
+
+// userIDParam 解析路径中的用户ID
+func userIDParam(r *Request) (uint, error) {
+	id, err := strconv.ParseUint(r.Param("id"), 10, 64)
+	if err != nil {
+		return 0, &Error{Status: http.StatusBadRequest, Message: "Invalid user id"}
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// importFormField 导入用户的表单字段名
+const importFormField = "file"
+
+// userCSVHeader 用户CSV导出的表头
+var userCSVHeader = []string{
+	"id", "username", "email", "first_name", "last_name", "role", "is_active", "mfa_enabled", "created_at",
+}
+
+// exportUsersHandler 流式导出当前租户的全部用户，format为csv（默认）或ndjson
+func exportUsersHandler(c *gin.Context, bulkService service.BulkUserService, log logger.Logger) {
+	var query dto.UserExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = "csv"
+	}
+	filename := "users-" + time.Now().Format("20060102-150405") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	var err error
+	if format == "ndjson" {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+
+		encoder := json.NewEncoder(c.Writer)
+		err = bulkService.Export(c.Request.Context(), func(record *dto.UserExportRecord) error {
+			return encoder.Encode(record)
+		})
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		if err = w.Write(userCSVHeader); err != nil {
+			return
+		}
+		err = bulkService.Export(c.Request.Context(), func(record *dto.UserExportRecord) error {
+			return w.Write([]string{
+				strconv.FormatUint(uint64(record.ID), 10),
+				record.Username,
+				record.Email,
+				record.FirstName,
+				record.LastName,
+				record.Role,
+				strconv.FormatBool(record.IsActive),
+				strconv.FormatBool(record.MFAEnabled),
+				record.CreatedAt.Format(time.RFC3339),
+			})
+		})
+		w.Flush()
+		if err == nil {
+			err = w.Error()
+		}
+	}
+
+	// 响应头已发送，出错时只能记录日志
+	if err != nil {
+		log.Error("Failed to export users", zap.Error(err))
+	}
+}
+
+// importUsersHandler 上传CSV创建导入任务（multipart/form-data，字段名file），dry_run=true时只校验不写入
+func importUsersHandler(c *gin.Context, bulkService service.BulkUserService, log logger.Logger) {
+	var query dto.UserImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must be multipart/form-data"})
+		return
+	}
+
+	var req *service.UserImportRequest
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed multipart body"})
+			return
+		}
+		if part.FormName() == importFormField {
+			req = &service.UserImportRequest{
+				Filename: part.FileName(),
+				File:     part,
+				DryRun:   query.DryRun,
+				Locale:   middleware.Locale(c),
+			}
+			break
+		}
+	}
+	if req == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing import file"})
+		return
+	}
+
+	job, err := bulkService.Import(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidImportFile):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrImportQueueFull):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			log.Error("Failed to create import job", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create import job"})
+		}
+		return
+	}
+
+	claims, _ := middleware.CurrentClaims(c)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Import job created",
+		"data":    job,
+	})
+	log.Info("Admin import users endpoint called",
+		zap.Uint("admin_id", claims.UserID),
+		zap.Uint("job_id", job.ID),
+		zap.Bool("dry_run", job.DryRun))
+}
+
+// importJobHandler 查询导入任务状态
+func importJobHandler(c *gin.Context, bulkService service.BulkUserService, log logger.Logger) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import job id"})
+		return
+	}
+
+	job, err := bulkService.GetImportJob(c.Request.Context(), uint(id))
+	if err != nil {
+		respondImportJobError(c, id, err, log)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import job retrieved successfully",
+		"data":    job,
+	})
+}
+
+// importErrorsHandler 获取导入任务的逐行错误报告，format=csv时导出CSV
+func importErrorsHandler(c *gin.Context, bulkService service.BulkUserService, log logger.Logger) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import job id"})
+		return
+	}
+
+	var query dto.UserImportErrorQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	rowErrors, err := bulkService.ImportErrors(c.Request.Context(), uint(id))
+	if err != nil {
+		respondImportJobError(c, id, err, log)
+		return
+	}
+
+	if query.Format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="import-`+strconv.FormatUint(id, 10)+`-errors.csv"`)
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"row", "field", "message"})
+		for _, rowError := range rowErrors {
+			w.Write([]string{strconv.Itoa(rowError.Row), rowError.Field, rowError.Message})
+		}
+		w.Flush()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import errors retrieved successfully",
+		"data":    rowErrors,
+	})
+}
+
+// respondImportJobError 返回查询导入任务失败的响应
+func respondImportJobError(c *gin.Context, id uint64, err error, log logger.Logger) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+	log.Error("Failed to get import job", zap.Uint64("job_id", id), zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get import job"})
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// fakeUserService 只实现测试用到的方法，其他方法调用时panic
+type fakeUserService struct {
+	service.UserService
+}
+
+func (fakeUserService) Register(_ context.Context, req *dto.RegisterRequest) (*dto.UserProfileResponse, error) {
+	return &dto.UserProfileResponse{ID: 3, Username: req.Username, Email: req.Email}, nil
+}
+
+func (fakeUserService) Login(_ context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
+	switch req.Username {
+	case "alice":
+		return &dto.LoginResponse{Token: "token"}, nil
+	case "dormant":
+		return nil, service.ErrAccountDeactivated
+	}
+	return nil, service.ErrInvalidCredentials
+}
+
+func (fakeUserService) GetUserByID(_ context.Context, id uint) (*dto.UserProfileResponse, error) {
+	return &dto.UserProfileResponse{ID: id, Username: "alice"}, nil
+}
+
+func (fakeUserService) ChangeEmail(_ context.Context, _ uint, req *dto.ChangeEmailRequest) (*dto.UserProfileResponse, error) {
+	switch req.Email {
+	case "taken@example.com":
+		return nil, service.ErrEmailExists
+	case "broken@example.com":
+		return nil, errors.New("database is locked")
+	}
+	return &dto.UserProfileResponse{ID: 1, Username: "alice", Email: req.Email}, nil
+}
+
+func (fakeUserService) DeleteUser(_ context.Context, userID uint) error {
+	if userID == 404 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// fakeSessions 所有会话都有效
+type fakeSessions struct {
+	service.SessionService
+}
+
+func (fakeSessions) ValidateSession(context.Context, string) error {
+	return nil
+}
+
+// fakeTenants 所有租户标识都解析为同一个租户
+type fakeTenants struct {
+	service.TenantService
+}
+
+func (fakeTenants) ResolveTenant(context.Context, string) (uint, error) {
+	return 1, nil
+}
+
+// memoryIdempotencyStore 内存中的幂等请求存储
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	hashes    map[middleware.IdempotencyScope]string
+	responses map[middleware.IdempotencyScope]*middleware.IdempotentResponse
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		hashes:    make(map[middleware.IdempotencyScope]string),
+		responses: make(map[middleware.IdempotencyScope]*middleware.IdempotentResponse),
+	}
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, scope middleware.IdempotencyScope, requestHash string) (*middleware.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash, ok := s.hashes[scope]
+	switch {
+	case !ok:
+		s.hashes[scope] = requestHash
+		return nil, nil
+	case hash != requestHash:
+		return nil, middleware.ErrIdempotencyMismatch
+	case s.responses[scope] == nil:
+		return nil, middleware.ErrIdempotencyInProgress
+	}
+	return s.responses[scope], nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, scope middleware.IdempotencyScope, response *middleware.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[scope] = response
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, scope middleware.IdempotencyScope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.hashes, scope)
+	return nil
+}
+
+func TestUserHandlers_GinAndNetHTTPMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := middleware.NewTokenIssuer(config.JWTConfig{Secret: "test-secret", AccessTokenExp: 3600})
+	users, sessions := fakeUserService{}, fakeSessions{}
+	log := logger.NewNop()
+
+	ginRouter := SetupRoutes(Dependencies{Tokens: tokens, Users: users, Sessions: sessions, Idempotency: newMemoryIdempotencyStore()})
+	mux := http.NewServeMux()
+	MountHTTP(mux, NewUserHandlers(users, log).Routes(), HTTPMiddlewares{
+		Public:      middleware.HTTPLocaleMiddleware(""),
+		Flags:       middleware.HTTPFeatureFlagMiddleware(nil),
+		Authorized:  middleware.HTTPAuthMiddleware(tokens, sessions, log),
+		Idempotency: middleware.HTTPIdempotencyMiddleware(newMemoryIdempotencyStore(), log),
+		Admin:       middleware.HTTPRequireRole(model.RoleAdmin, log),
+	}, log)
+
+	userToken, _, err := tokens.GenerateToken(1, "alice", model.RoleUser, 0)
+	require.NoError(t, err)
+	adminToken, _, err := tokens.GenerateToken(1, "root", model.RoleAdmin, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method, path   string
+		body, token    string
+		acceptLanguage string
+		idempotencyKey string
+		status         int
+		replayed       bool
+	}{
+		{name: "register validation zh", method: "POST", path: "/api/v1/register", body: `{"username":"ab","email":"bad","password":"secret123"}`, acceptLanguage: "zh-CN", status: http.StatusBadRequest},
+		{name: "register malformed json", method: "POST", path: "/api/v1/register", body: `{"username":`, status: http.StatusBadRequest},
+		{name: "register", method: "POST", path: "/api/v1/register", body: `{"username":"carol","email":"carol@example.com","password":"secret123"}`, status: http.StatusCreated},
+		{name: "register idempotent", method: "POST", path: "/api/v1/register", body: `{"username":"dave","email":"dave@example.com","password":"secret123"}`, idempotencyKey: "register-1", status: http.StatusCreated},
+		{name: "register replayed", method: "POST", path: "/api/v1/register", body: `{"username":"dave","email":"dave@example.com","password":"secret123"}`, idempotencyKey: "register-1", status: http.StatusCreated, replayed: true},
+		{name: "register key reused", method: "POST", path: "/api/v1/register", body: `{"username":"erin","email":"erin@example.com","password":"secret123"}`, idempotencyKey: "register-1", status: http.StatusUnprocessableEntity},
+		{name: "login not idempotent", method: "POST", path: "/api/v1/login", body: `{"username":"alice","password":"secret123"}`, idempotencyKey: "login-1", status: http.StatusOK},
+		{name: "login not replayed", method: "POST", path: "/api/v1/login", body: `{"username":"alice","password":"secret123"}`, idempotencyKey: "login-1", status: http.StatusOK},
+		{name: "login", method: "POST", path: "/api/v1/login", body: `{"username":"alice","password":"secret123"}`, status: http.StatusOK},
+		{name: "login invalid", method: "POST", path: "/api/v1/login", body: `{"username":"mallory","password":"secret123"}`, status: http.StatusUnauthorized},
+		{name: "login deactivated", method: "POST", path: "/api/v1/login", body: `{"username":"dormant","password":"secret123"}`, status: http.StatusForbidden},
+		{name: "profile without token", method: "GET", path: "/api/v1/profile", status: http.StatusUnauthorized},
+		{name: "profile invalid token", method: "GET", path: "/api/v1/profile", token: "bogus", status: http.StatusUnauthorized},
+		{name: "profile", method: "GET", path: "/api/v1/profile", token: userToken, status: http.StatusOK},
+		{name: "change email", method: "PUT", path: "/api/v1/profile/email", body: `{"email":"new@example.com","password":"secret123"}`, token: userToken, status: http.StatusOK},
+		{name: "change email idempotent", method: "PUT", path: "/api/v1/profile/email", body: `{"email":"new@example.com","password":"secret123"}`, token: userToken, idempotencyKey: "email-1", status: http.StatusOK},
+		{name: "change email replayed", method: "PUT", path: "/api/v1/profile/email", body: `{"email":"new@example.com","password":"secret123"}`, token: userToken, idempotencyKey: "email-1", status: http.StatusOK, replayed: true},
+		{name: "change email conflict", method: "PUT", path: "/api/v1/profile/email", body: `{"email":"taken@example.com","password":"secret123"}`, token: userToken, status: http.StatusConflict},
+		{name: "change email internal error", method: "PUT", path: "/api/v1/profile/email", body: `{"email":"broken@example.com","password":"secret123"}`, token: userToken, status: http.StatusInternalServerError},
+		{name: "admin route as user", method: "DELETE", path: "/api/v1/admin/users/2", token: userToken, status: http.StatusForbidden},
+		{name: "delete invalid id", method: "DELETE", path: "/api/v1/admin/users/abc", token: adminToken, status: http.StatusBadRequest},
+		{name: "delete self", method: "DELETE", path: "/api/v1/admin/users/1", token: adminToken, status: http.StatusBadRequest},
+		{name: "delete missing", method: "DELETE", path: "/api/v1/admin/users/404", token: adminToken, status: http.StatusNotFound},
+		{name: "delete", method: "DELETE", path: "/api/v1/admin/users/2", token: adminToken, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serve := func(handler http.Handler) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				if tt.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+				if tt.acceptLanguage != "" {
+					req.Header.Set("Accept-Language", tt.acceptLanguage)
+				}
+				if tt.idempotencyKey != "" {
+					req.Header.Set(middleware.IdempotencyKeyHeader, tt.idempotencyKey)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				return w
+			}
+
+			fromGin, fromMux := serve(ginRouter), serve(mux)
+			assert.Equal(t, tt.status, fromGin.Code)
+			assert.Equal(t, fromGin.Code, fromMux.Code)
+			assert.Equal(t, tt.replayed, fromGin.Header().Get(middleware.IdempotentReplayedHeader) == "true")
+			assert.Equal(t, fromGin.Header().Get(middleware.IdempotentReplayedHeader), fromMux.Header().Get(middleware.IdempotentReplayedHeader))
+			assert.Equal(t, fromGin.Header().Get("Content-Type"), fromMux.Header().Get("Content-Type"))
+			assert.JSONEq(t, fromGin.Body.String(), fromMux.Body.String())
+		})
+	}
+}
+
+func TestMuxPattern(t *testing.T) {
+	assert.Equal(t, "/api/v1/admin/users/{id}/restore", muxPattern("/api/v1/admin/users/:id/restore"))
+	assert.Equal(t, "/api/v1/profile", muxPattern("/api/v1/profile"))
+}
+
+func TestSetupRoutes_WithoutSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := middleware.NewTokenIssuer(config.JWTConfig{Secret: "test-secret", AccessTokenExp: 3600})
+	router := SetupRoutes(Dependencies{Tokens: tokens, Users: fakeUserService{}})
+
+	token, _, err := tokens.GenerateToken(1, "alice", model.RoleUser, 0)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/v1/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/validation"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var registerValidationOnce sync.Once
+
+// registerValidation 为Gin的校验器注册本地化翻译，只执行一次
+func registerValidation(log logger.Logger) {
+	registerValidationOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		if err := validation.Register(v); err != nil {
+			log.Error("Failed to register validation translations", zap.Error(err))
+		}
+	})
+}
+
+// respondBindError 返回本地化的请求参数错误，包含逐字段的错误信息；请求体超出大小限制时返回413
+func respondBindError(c *gin.Context, err error) {
+	resp := bindErrorResponse(c.Request.Context(), err)
+	c.JSON(resp.Status, resp.Body)
+}
+
+// bindErrorResponse 请求参数错误的响应，Gin处理器和与框架无关的处理函数共用
+func bindErrorResponse(ctx context.Context, err error) *Response {
+	if middleware.IsBodyTooLarge(err) {
+		return &Response{Status: http.StatusRequestEntityTooLarge, Body: gin.H{"error": "Request body too large"}}
+	}
+	message, fields := validation.Translate(err, middleware.LocaleFromContext(ctx))
+	body := gin.H{"error": message}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+	return &Response{Status: http.StatusBadRequest, Body: body}
+}
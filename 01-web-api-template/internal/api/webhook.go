@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// listWebhooksHandler 列出当前租户的Webhook订阅
+func listWebhooksHandler(c *gin.Context, webhookService service.WebhookService, log logger.Logger) {
+	webhooks, err := webhookService.List(c.Request.Context())
+	if err != nil {
+		log.Error("Failed to list webhooks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhooks retrieved successfully",
+		"data":    webhooks,
+	})
+}
+
+// createWebhookHandler 创建Webhook订阅，响应中包含只返回一次的签名密钥
+func createWebhookHandler(c *gin.Context, webhookService service.WebhookService, log logger.Logger) {
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	webhook, err := webhookService.Create(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookURL) || errors.Is(err, service.ErrInvalidWebhookEvent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to create webhook", zap.String("url", req.URL), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"data":    webhook,
+	})
+}
+
+// updateWebhookHandler 修改Webhook订阅
+func updateWebhookHandler(c *gin.Context, webhookService service.WebhookService, log logger.Logger) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook id"})
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	webhook, err := webhookService.Update(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhookURL), errors.Is(err, service.ErrInvalidWebhookEvent):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		default:
+			log.Error("Failed to update webhook", zap.Uint64("webhook_id", id), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated successfully",
+		"data":    webhook,
+	})
+}
+
+// deleteWebhookHandler 删除Webhook订阅
+func deleteWebhookHandler(c *gin.Context, webhookService service.WebhookService, log logger.Logger) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook id"})
+		return
+	}
+
+	if err := webhookService.Delete(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+		log.Error("Failed to delete webhook", zap.Uint64("webhook_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// listWebhookDeliveriesHandler 查看Webhook的投递记录
+func listWebhookDeliveriesHandler(c *gin.Context, webhookService service.WebhookService, log logger.Logger) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook id"})
+		return
+	}
+
+	var query dto.WebhookDeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	result, err := webhookService.ListDeliveries(c.Request.Context(), uint(id), &query)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+		log.Error("Failed to list webhook deliveries", zap.Uint64("webhook_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deliveries retrieved successfully",
+		"data":    result,
+	})
+}
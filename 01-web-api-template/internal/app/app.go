@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,83 +13,413 @@ import (
 	"github.com/gin-gonic/gin"
 	"go-practical-roadmap/01-web-api-template/internal/api"
 	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/job"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
 	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/blob"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/certs"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/diagnostics"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/mockoidc"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
 	"go-practical-roadmap/01-web-api-template/internal/repository"
 	"go-practical-roadmap/01-web-api-template/internal/service"
 	"go-practical-roadmap/01-web-api-template/pkg/db"
 	"go-practical-roadmap/01-web-api-template/pkg/logger"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-// App 应用结构体
+// App 应用结构体，持有一个实例的全部依赖，同一进程中的多个实例互不影响
 type App struct {
-	server *http.Server
+	cfg      *config.Config
+	log      logger.Logger
+	db       *gorm.DB
+	tokens   *middleware.TokenIssuer
+	router   *gin.Engine
+	server   *http.Server
+	debug    *http.Server
+	certs    *certs.Reloader
+	replicas *db.Replicas
+
+	audit    service.AuditService
+	users    service.UserService
+	tenants  service.TenantService
+	oauth    service.OAuthService
+	mfa      service.MFAService
+	sessions service.SessionService
+	avatars  service.AvatarService
+	webhook  service.WebhookService
+	bulk     service.BulkUserService
+	flags    service.FlagService
+
+	idempotency middleware.IdempotencyStore
+	userCache   cache.Cache
+
+	purge    *job.UserPurgeJob
+	webhooks *job.WebhookDispatchJob
 }
 
-// NewApp 创建新的应用实例
-func NewApp() (*App, error) {
-	// 加载配置
-	cfg, err := config.LoadConfig()
+// NewApp 按opts加载配置并初始化日志，然后按配置创建应用实例。命令行工具与服务器共用此初始化过程
+func NewApp(opts config.LoadOptions) (*App, error) {
+	cfg, log, err := setup(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+		return nil, err
 	}
+	return New(cfg, log)
+}
 
-	// 初始化日志
-	if err := logger.InitLogger(cfg.Logger.Level, cfg.Logger.Format, cfg.Logger.FilePath); err != nil {
-		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+// New 使用给定的配置和日志记录器创建应用实例：连接数据库并创建全部服务和路由，
+// 后台任务和HTTP服务器在Run中启动。log为nil时不输出日志
+func New(cfg *config.Config, log logger.Logger) (*App, error) {
+	if log == nil {
+		log = logger.NewNop()
 	}
 
 	// 连接数据库
-	if err := db.Connect(cfg.Database.DSN, cfg.Database.Driver); err != nil {
+	pool := databasePool(cfg.Database)
+	database, err := db.Connect(cfg.Database.DSN, cfg.Database.Driver, pool)
+	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	a := &App{cfg: cfg, log: log, db: database}
+
+	// 自动迁移数据库，关闭时需先执行 migrate 命令
+	if cfg.Database.AutoMigrate {
+		if err := autoMigrate(database, log, cfg.Tenant.Default); err != nil {
+			a.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	// 迁移完成后启用租户隔离，之后访问租户数据都需要在上下文中指定租户
+	if err := tenant.RegisterCallbacks(database); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("failed to register tenant callbacks: %w", err)
+	}
+
+	// 用户查询分发到只读副本，写操作和事务使用主库
+	if len(cfg.Database.Replicas) > 0 {
+		a.replicas, err = db.UseReplicas(database, db.ReplicaConfig{
+			Driver:              cfg.Database.Driver,
+			DSNs:                cfg.Database.Replicas,
+			HealthCheckInterval: cfg.Database.ReplicaCheckInterval,
+			Pool:                pool,
+			Logger:              log.Named("db").Named("replica"),
+		}, &model.User{})
+		if err != nil {
+			a.Close()
+			return nil, fmt.Errorf("failed to configure database replicas: %w", err)
+		}
+	}
+
+	if err := a.wire(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	if err := a.setupRouter(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// Migrate 加载配置、连接数据库并执行迁移，不创建服务
+func Migrate(opts config.LoadOptions) error {
+	cfg, log, err := setup(opts)
+	if err != nil {
+		return err
+	}
+	defer log.Sync()
+
+	database, err := db.Connect(cfg.Database.DSN, cfg.Database.Driver, databasePool(cfg.Database))
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close(database)
+
+	if err := autoMigrate(database, log, cfg.Tenant.Default); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+// setup 加载配置并按配置创建日志记录器
+func setup(opts config.LoadOptions) (*config.Config, logger.Logger, error) {
+	// 加载配置
+	cfg, err := config.LoadConfig(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
 
-	// 自动迁移数据库
-	if err := autoMigrate(); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	// 初始化日志
+	log, err := logger.NewLogger(loggerConfig(cfg.Logger))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	return cfg, log, nil
+}
+
+// loggerConfig 转换日志配置
+func loggerConfig(cfg config.LoggerConfig) logger.Config {
+	sinks := make([]logger.SinkConfig, 0, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		sinks = append(sinks, logger.SinkConfig{
+			Type:   sink.Type,
+			Path:   sink.Path,
+			Level:  sink.Level,
+			Format: sink.Format,
+			Rotation: logger.RotationConfig{
+				MaxSize:    sink.MaxSize,
+				MaxAge:     sink.MaxAge,
+				MaxBackups: sink.MaxBackups,
+			},
+		})
 	}
 
-	return &App{}, nil
+	return logger.Config{
+		Level:    cfg.Level,
+		Format:   cfg.Format,
+		Output:   cfg.Output,
+		FilePath: cfg.FilePath,
+		Rotation: logger.RotationConfig{
+			MaxSize:    cfg.MaxSize,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		},
+		Sinks:  sinks,
+		Levels: cfg.Levels,
+		Sampling: logger.SamplingConfig{
+			Initial:    cfg.Sampling.Initial,
+			Thereafter: cfg.Sampling.Thereafter,
+			Tick:       cfg.Sampling.Tick,
+		},
+	}
 }
 
-// autoMigrate 自动迁移数据库
-func autoMigrate() error {
-	// 获取数据库实例
-	database := db.GetDB()
-	if database == nil {
-		return fmt.Errorf("database connection is nil")
+// wire 创建仓库和服务
+func (a *App) wire() error {
+	cfg := a.cfg
+	database := a.db
+
+	// 审计日志异步写入，Close时写入剩余事件
+	auditRepo := repository.NewAuditRepository(database)
+	a.audit = service.NewAuditService(auditRepo, cfg.Audit, a.log)
+
+	// 文件存储和签名访问地址
+	store, err := newBlobStore(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	signingSecret := cfg.Storage.SigningSecret
+	if signingSecret == "" {
+		signingSecret = cfg.JWT.Secret
+	}
+	signer := blob.NewURLSigner(signingSecret, "/api/v1/files", cfg.Storage.URLTTL)
+
+	// 创建用户仓库和服务，按配置为用户查询增加缓存
+	userRepo := repository.NewUserRepository(database)
+	a.tenants = service.NewTenantService(repository.NewTenantRepository(database))
+	if cfg.Cache.Enabled {
+		a.userCache = cache.NewLRU(cfg.Cache.Capacity, cfg.Cache.TTL)
+		userRepo = repository.NewCachedUserRepository(userRepo, a.userCache)
 	}
+	a.avatars = service.NewAvatarService(userRepo, store, signer, cfg.Avatar, a.log)
+	sessionRepo := repository.NewSessionRepository(database)
+	// 访问令牌使用本实例的密钥签发和校验
+	a.tokens = middleware.NewTokenIssuer(cfg.JWT)
+	a.sessions = service.NewSessionService(sessionRepo, a.audit, a.tokens, a.log)
+	// 领域事件与业务数据在同一事务中写入发件箱
+	outboxRepo := repository.NewOutboxRepository(database)
+	events := service.NewEventOutbox(repository.NewTransactor(database), outboxRepo)
+	// 新密码使用配置的哈希算法，旧哈希在登录时升级
+	passwords, err := service.NewPasswords(cfg.Password)
+	if err != nil {
+		return err
+	}
+	a.users = service.NewUserService(userRepo, a.sessions, a.audit, a.avatars, events, passwords, a.log)
+	identityRepo := repository.NewIdentityRepository(database)
+	a.oauth = service.NewOAuthService(userRepo, identityRepo, a.sessions, events, passwords, cfg.OAuth)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(database)
+	a.mfa = service.NewMFAService(repository.NewTransactor(database), userRepo, recoveryCodeRepo, a.sessions, a.audit, cfg.MFA)
+
+	// 发件箱中的事件由Run启动的后台任务投递到Webhook
+	webhookRepo := repository.NewWebhookRepository(database)
+	a.webhook = service.NewWebhookService(repository.NewTransactor(database), outboxRepo, webhookRepo, a.audit, cfg.Webhook, a.log)
+
+	// 批量导入在后台依次执行，Close时中断
+	importRepo := repository.NewUserImportRepository(database)
+	a.bulk = service.NewBulkUserService(userRepo, importRepo, a.audit, events, passwords, cfg.Import, a.log)
+
+	// 功能开关合并配置文件和数据库中的设置，定期刷新
+	a.flags = service.NewFlagService(repository.NewFeatureFlagRepository(database), a.audit, cfg.Flags, a.log)
+
+	idempotencyRepo := repository.NewIdempotencyRepository(database)
+	a.idempotency = service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL, a.log)
+	return nil
+}
+
+// setupRouter 创建路由，开发模式下挂载本地模拟OIDC提供方
+func (a *App) setupRouter() error {
+	// 设置Gin模式
+	gin.SetMode(a.cfg.Server.Mode)
+
+	a.router = api.SetupRoutes(api.Dependencies{
+		Config:      a.cfg,
+		Logger:      a.log,
+		Tokens:      a.tokens,
+		Users:       a.users,
+		OAuth:       a.oauth,
+		MFA:         a.mfa,
+		Sessions:    a.sessions,
+		Audit:       a.audit,
+		Avatars:     a.avatars,
+		Idempotency: a.idempotency,
+		UserCache:   a.userCache,
+		Tenants:     a.tenants,
+		BulkUsers:   a.bulk,
+		Webhooks:    a.webhook,
+		Flags:       a.flags,
+	})
+
+	if a.cfg.OAuth.MockProvider && a.cfg.Server.Mode == gin.DebugMode {
+		mockProvider, err := mockoidc.New("/mock-oidc")
+		if err != nil {
+			return fmt.Errorf("failed to create mock oidc provider: %w", err)
+		}
+		a.router.Any("/mock-oidc/*path", gin.WrapH(mockProvider))
+		a.log.Warn("Mock OIDC provider mounted at /mock-oidc, do not enable in production")
+	}
+	return nil
+}
+
+// Handler 应用的HTTP处理器，测试中可以直接用httptest启动
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// Config 应用配置
+func (a *App) Config() *config.Config {
+	return a.cfg
+}
 
+// Users 用户服务
+func (a *App) Users() service.UserService {
+	return a.users
+}
+
+// Tenants 租户服务
+func (a *App) Tenants() service.TenantService {
+	return a.tenants
+}
+
+// databasePool 按配置生成主库和副本共用的连接池设置，conn_max_lifetime与JWT有效期一样以秒为单位
+func databasePool(cfg config.DatabaseConfig) db.PoolConfig {
+	return db.PoolConfig{
+		MaxIdleConns:    cfg.MaxIdleConns,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime * time.Second,
+	}
+}
+
+// autoMigrate 自动迁移数据库，defaultTenant为默认租户标识，不存在时自动创建
+func autoMigrate(database *gorm.DB, log logger.Logger, defaultTenant string) error {
 	// 自动迁移模型
-	err := database.AutoMigrate(&model.User{})
+	err := database.AutoMigrate(&model.Tenant{}, &model.User{}, &model.UserIdentity{}, &model.MFARecoveryCode{}, &model.Session{}, &model.AuditLog{}, &model.IdempotencyRecord{}, &model.UserImportJob{}, &model.UserImportError{}, &model.OutboxEvent{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.FeatureFlag{})
 	if err != nil {
 		return fmt.Errorf("failed to auto migrate: %w", err)
 	}
 
-	logger.Info("Database migration completed successfully")
+	// 旧版本的用户名、邮箱唯一索引不区分软删除状态和租户，已由包含tenant_id、deleted_id的联合索引取代
+	err = database.Model(&model.User{}).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_id = 0").
+		Update("deleted_id", gorm.Expr("id")).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill deleted users: %w", err)
+	}
+	for _, index := range []string{"idx_users_username", "idx_users_email", "idx_users_username_live", "idx_users_email_live"} {
+		if database.Migrator().HasIndex(&model.User{}, index) {
+			if err := database.Migrator().DropIndex(&model.User{}, index); err != nil {
+				return fmt.Errorf("failed to drop index %s: %w", index, err)
+			}
+		}
+	}
+
+	// 旧版本的第三方身份唯一索引不区分租户，已由包含tenant_id的联合索引取代
+	if database.Migrator().HasIndex(&model.UserIdentity{}, "idx_identity_provider_subject") {
+		if err := database.Migrator().DropIndex(&model.UserIdentity{}, "idx_identity_provider_subject"); err != nil {
+			return fmt.Errorf("failed to drop index idx_identity_provider_subject: %w", err)
+		}
+	}
+
+	// 旧版本的幂等记录唯一索引不区分租户，已由包含tenant_id的联合索引取代；
+	// 旧记录不属于任何租户，不会再被匹配，过期后由清理任务删除
+	if database.Migrator().HasIndex(&model.IdempotencyRecord{}, "idx_idempotency_scope") {
+		if err := database.Migrator().DropIndex(&model.IdempotencyRecord{}, "idx_idempotency_scope"); err != nil {
+			return fmt.Errorf("failed to drop index idx_idempotency_scope: %w", err)
+		}
+	}
+
+	// 启用多租户之前创建的用户归属默认租户
+	if defaultTenant != "" {
+		defaultTenantRecord := model.Tenant{Slug: defaultTenant, Name: defaultTenant}
+		if err := database.Where("slug = ?", defaultTenant).FirstOrCreate(&defaultTenantRecord).Error; err != nil {
+			return fmt.Errorf("failed to create default tenant: %w", err)
+		}
+		err = database.Model(&model.User{}).Unscoped().
+			Where("tenant_id = 0").
+			Update("tenant_id", defaultTenantRecord.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to backfill user tenants: %w", err)
+		}
+	}
+
+	// 第三方身份归属所绑定用户的租户
+	err = database.Model(&model.UserIdentity{}).
+		Where("tenant_id = 0 AND user_id IN (SELECT id FROM users)").
+		Update("tenant_id", gorm.Expr("(SELECT tenant_id FROM users WHERE users.id = user_identities.user_id)")).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill identity tenants: %w", err)
+	}
+
+	log.Info("Database migration completed successfully")
 	return nil
 }
 
-// Run 启动应用
+// Run 启动后台任务和HTTP服务器，阻塞直到服务器关闭
 func (a *App) Run() error {
-	// 设置Gin模式
-	gin.SetMode(config.GlobalConfig.Server.Mode)
+	// 定期永久删除超过保留期的软删除用户
+	a.purge = job.NewUserPurgeJob(a.users, a.cfg.User.DeletedRetention, a.cfg.User.PurgeInterval, a.log)
+	a.purge.Start()
 
-	// 创建用户仓库和服务
-	userRepo := repository.NewUserRepository(db.GetDB())
-	userService := service.NewUserService(userRepo)
+	// 后台将发件箱中的事件投递到Webhook
+	if a.cfg.Webhook.Enabled {
+		a.webhooks = job.NewWebhookDispatchJob(a.webhook, a.cfg.Webhook.PollInterval, a.log)
+		a.webhooks.Start()
+	}
 
-	// 创建路由
-	router := api.SetupRoutes(userService)
+	// 诊断接口使用独立的内网监听地址
+	if debugConfig := a.cfg.Debug; debugConfig.Enabled && debugConfig.Listen != "" {
+		a.serveDebug(debugConfig)
+	}
 
 	// 创建HTTP服务器
+	serverConfig := a.cfg.Server
 	a.server = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", config.GlobalConfig.Server.Host, config.GlobalConfig.Server.Port),
-		Handler: router,
+		Addr:              fmt.Sprintf("%s:%d", serverConfig.Host, serverConfig.Port),
+		Handler:           a.router,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
 	}
 
 	// 启动服务器
-	logger.Info("Starting server", zap.String("addr", a.server.Addr))
+	if serverConfig.TLS.Enabled {
+		return a.serveTLS(serverConfig.TLS)
+	}
+	a.log.Info("Starting server", zap.String("addr", a.server.Addr))
 	if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
@@ -96,38 +427,143 @@ func (a *App) Run() error {
 	return nil
 }
 
-// Stop 停止应用
+// serveDebug 在独立地址上启动诊断接口。不设置写超时，pprof采集可以超过主服务的write_timeout
+func (a *App) serveDebug(cfg config.DebugConfig) {
+	profiler := diagnostics.NewProfiler(cfg.ProfileDir, cfg.MaxProfileDuration)
+	a.debug = &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           api.SetupDebugRoutes(profiler, a.log),
+		ReadHeaderTimeout: a.cfg.Server.ReadHeaderTimeout,
+	}
+
+	a.log.Warn("Starting debug server, do not expose it publicly", zap.String("addr", cfg.Listen))
+	go func() {
+		if err := a.debug.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			a.log.Error("Debug server failed", zap.Error(err))
+		}
+	}()
+}
+
+// serveTLS 使用HTTPS启动服务器，定期检查证书文件，变化后新连接使用新证书
+func (a *App) serveTLS(cfg config.TLSConfig) error {
+	reloader, err := certs.NewReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+	a.certs = reloader
+	if cfg.ReloadInterval > 0 {
+		reloader.Watch(cfg.ReloadInterval, func(loaded bool, err error) {
+			if err != nil {
+				a.log.Error("Failed to reload tls certificate, keeping the current one", zap.Error(err))
+			} else if loaded {
+				a.log.Info("TLS certificate reloaded", zap.String("cert_file", cfg.CertFile))
+			}
+		})
+	}
+
+	a.server.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	a.log.Info("Starting server with TLS", zap.String("addr", a.server.Addr))
+	if err := a.server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	return nil
+}
+
+// Stop 优雅关闭HTTP服务器，然后释放应用资源
 func (a *App) Stop() error {
-	logger.Info("Shutting down server...")
+	a.log.Info("Shutting down server...")
 
 	// 创建优雅关闭的上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// 关闭HTTP服务器
-	if err := a.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server shutdown failed: %w", err)
+	if a.server != nil {
+		if err := a.server.Shutdown(ctx); err != nil {
+			return fmt.Errorf("server shutdown failed: %w", err)
+		}
+	}
+	if a.debug != nil {
+		if err := a.debug.Shutdown(ctx); err != nil {
+			return fmt.Errorf("debug server shutdown failed: %w", err)
+		}
+	}
+
+	if err := a.Close(); err != nil {
+		return err
+	}
+
+	a.log.Info("Server exited properly")
+	return nil
+}
+
+// Close 停止后台任务，写入剩余审计日志并关闭数据库连接
+func (a *App) Close() error {
+	// 停止后台任务
+	if a.certs != nil {
+		a.certs.Close()
+	}
+	if a.purge != nil {
+		a.purge.Stop()
+	}
+	if a.webhooks != nil {
+		a.webhooks.Stop()
+	}
+	if a.flags != nil {
+		a.flags.Close()
+	}
+
+	// 中断正在执行的导入任务
+	if a.bulk != nil {
+		a.bulk.Close()
+	}
+
+	// 写入缓冲区中剩余的审计日志
+	if a.audit != nil {
+		if err := a.audit.Close(); err != nil {
+			return fmt.Errorf("audit log close failed: %w", err)
+		}
 	}
 
 	// 关闭数据库连接
-	if err := db.Close(); err != nil {
+	if a.replicas != nil {
+		if err := a.replicas.Close(); err != nil {
+			return fmt.Errorf("database replicas close failed: %w", err)
+		}
+	}
+	if err := db.Close(a.db); err != nil {
 		return fmt.Errorf("database close failed: %w", err)
 	}
 
 	// 同步日志
-	if logger.GlobalLogger != nil {
-		logger.GlobalLogger.Sync()
-	}
-
-	logger.Info("Server exited properly")
+	a.log.Sync()
 	return nil
 }
 
+// newBlobStore 根据配置创建文件存储
+func newBlobStore(cfg config.StorageConfig) (blob.BlobStore, error) {
+	switch cfg.Driver {
+	case "", "local":
+		store, err := blob.NewLocalStore(cfg.LocalRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create blob store: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Driver)
+	}
+}
+
 // WaitForInterrupt 等待中断信号
 func (a *App) WaitForInterrupt() {
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	logger.Info("Shutdown signal received")
+	a.log.Info("Shutdown signal received")
 }
\ No newline at end of file
@@ -1,13 +1,23 @@
 package app
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/config"
 )
 
 func TestAppCreation(t *testing.T) {
 	// 测试应用创建
-	app, err := NewApp()
+	app, err := NewApp(config.LoadOptions{})
 	if err != nil {
 		// 由于缺少实际的Go环境和数据库，这里预期会出错
 		t.Logf("Expected error when creating app without proper environment: %v", err)
@@ -33,4 +43,101 @@ func TestAppCreation(t *testing.T) {
 			t.Errorf("Failed to stop app: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// newTestServer 使用独立的临时数据库和JWT密钥创建应用，并用httptest启动
+func newTestServer(t *testing.T, secret string) *httptest.Server {
+	t.Helper()
+
+	cfg, err := config.LoadConfig(config.LoadOptions{})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	cfg.Server.Mode = gin.TestMode
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.DSN = filepath.Join(dir, "app.db")
+	cfg.Database.Replicas = nil
+	cfg.Database.AutoMigrate = true
+	cfg.Storage.LocalRoot = filepath.Join(dir, "blobs")
+	cfg.JWT.Secret = secret
+	cfg.OAuth.MockProvider = false
+	cfg.Password.Argon2.Memory = 1024
+	cfg.Password.Argon2.Iterations = 1
+	cfg.Password.Argon2.Parallelism = 1
+
+	application, err := New(cfg, nil)
+	require.NoError(t, err)
+	server := httptest.NewServer(application.Handler())
+	t.Cleanup(func() {
+		server.Close()
+		assert.NoError(t, application.Close())
+	})
+	return server
+}
+
+// postJSON 发送JSON请求并解析响应
+func postJSON(t *testing.T, url string, body, out interface{}) int {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+// profileStatus 使用令牌访问个人信息接口
+func profileStatus(t *testing.T, baseURL, token string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/api/v1/profile", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestNew_IsolatedInstances(t *testing.T) {
+	instances := []*struct {
+		name   string
+		server *httptest.Server
+		token  string
+	}{
+		{name: "first", server: newTestServer(t, "first-secret")},
+		{name: "second", server: newTestServer(t, "second-secret")},
+	}
+
+	t.Run("parallel", func(t *testing.T) {
+		for _, instance := range instances {
+			t.Run(instance.name, func(t *testing.T) {
+				t.Parallel()
+				baseURL := instance.server.URL
+
+				// 两个实例使用不同的数据库，可以注册同名用户
+				status := postJSON(t, baseURL+"/api/v1/register", map[string]string{
+					"username": "alice", "email": "alice@example.com", "password": "pass1234",
+				}, nil)
+				require.Equal(t, http.StatusCreated, status)
+
+				var login struct {
+					Token string `json:"token"`
+				}
+				status = postJSON(t, baseURL+"/api/v1/login", map[string]string{
+					"username": "alice", "password": "pass1234",
+				}, &login)
+				require.Equal(t, http.StatusOK, status)
+				require.NotEmpty(t, login.Token)
+				assert.Equal(t, http.StatusOK, profileStatus(t, baseURL, login.Token))
+				instance.token = login.Token
+			})
+		}
+	})
+
+	// 令牌由各自的密钥签发，另一个实例不接受
+	first, second := instances[0], instances[1]
+	assert.Equal(t, http.StatusUnauthorized, profileStatus(t, second.server.URL, first.token))
+	assert.Equal(t, http.StatusUnauthorized, profileStatus(t, first.server.URL, second.token))
+}
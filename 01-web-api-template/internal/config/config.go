@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -10,26 +14,75 @@ import (
 
 // Config 应用配置结构体
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Logger   LoggerConfig   `mapstructure:"logger"`
+	// Env 运行环境，决定叠加的环境配置文件
+	Env         string            `mapstructure:"env"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Logger      LoggerConfig      `mapstructure:"logger"`
+	OAuth       OAuthConfig       `mapstructure:"oauth"`
+	MFA         MFAConfig         `mapstructure:"mfa"`
+	Password    PasswordConfig    `mapstructure:"password"`
+	Audit       AuditConfig       `mapstructure:"audit"`
+	User        UserConfig        `mapstructure:"user"`
+	Storage     StorageConfig     `mapstructure:"storage"`
+	Avatar      AvatarConfig      `mapstructure:"avatar"`
+	I18n        I18nConfig        `mapstructure:"i18n"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	Tenant      TenantConfig      `mapstructure:"tenant"`
+	Import      ImportConfig      `mapstructure:"import"`
+	Webhook     WebhookConfig     `mapstructure:"webhook"`
+	Flags       FlagsConfig       `mapstructure:"flags"`
+	Debug       DebugConfig       `mapstructure:"debug"`
+
+	// files 按合并顺序排列的已加载配置文件
+	files []string
+	// settings 合并后的全部配置项
+	settings map[string]interface{}
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Host string `mapstructure:"host"`
-	Mode string `mapstructure:"mode"`
+	Port              int                   `mapstructure:"port"`
+	Host              string                `mapstructure:"host"`
+	Mode              string                `mapstructure:"mode"`
+	ReadTimeout       time.Duration         `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration         `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration         `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration         `mapstructure:"idle_timeout"`
+	MaxBodySize       int64                 `mapstructure:"max_body_size"`
+	TLS               TLSConfig             `mapstructure:"tls"`
+	Headers           SecurityHeadersConfig `mapstructure:"headers"`
+}
+
+// TLSConfig HTTPS配置，证书文件变化后自动重新加载
+type TLSConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	CertFile       string        `mapstructure:"cert_file"`
+	KeyFile        string        `mapstructure:"key_file"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// SecurityHeadersConfig 安全响应头配置，值为空时不发送对应的响应头
+type SecurityHeadersConfig struct {
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
+	ContentSecurityPolicy string        `mapstructure:"content_security_policy"`
+	FrameOptions          string        `mapstructure:"frame_options"`
+	ReferrerPolicy        string        `mapstructure:"referrer_policy"`
 }
 
-// DatabaseConfig 数据库配置
+// DatabaseConfig 数据库配置，Replicas为只读副本的DSN，驱动与主库相同
 type DatabaseConfig struct {
-	Driver          string        `mapstructure:"driver"`
-	DSN             string        `mapstructure:"dsn"`
-	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
-	MaxOpenConns    int           `mapstructure:"max_open_conns"`
-	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	Driver               string        `mapstructure:"driver"`
+	DSN                  string        `mapstructure:"dsn"`
+	MaxIdleConns         int           `mapstructure:"max_idle_conns"`
+	MaxOpenConns         int           `mapstructure:"max_open_conns"`
+	ConnMaxLifetime      time.Duration `mapstructure:"conn_max_lifetime"`
+	AutoMigrate          bool          `mapstructure:"auto_migrate"`
+	Replicas             []string      `mapstructure:"replicas"`
+	ReplicaCheckInterval time.Duration `mapstructure:"replica_check_interval"`
 }
 
 // JWTConfig JWT配置
@@ -37,6 +90,7 @@ type JWTConfig struct {
 	Secret          string        `mapstructure:"secret"`
 	AccessTokenExp  time.Duration `mapstructure:"access_token_exp"`
 	RefreshTokenExp time.Duration `mapstructure:"refresh_token_exp"`
+	MFATokenExp     time.Duration `mapstructure:"mfa_token_exp"`
 }
 
 // LoggerConfig 日志配置
@@ -48,56 +102,372 @@ type LoggerConfig struct {
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxAge     int    `mapstructure:"max_age"`
 	MaxBackups int    `mapstructure:"max_backups"`
+	Compress   bool   `mapstructure:"compress"`
+	// Sinks 同时写入的多个输出，配置后忽略output和file_path
+	Sinks []LogSinkConfig `mapstructure:"sinks"`
+	// Levels 命名日志记录器（如http）的级别，运行时可以通过管理接口调整
+	Levels   map[string]string `mapstructure:"levels"`
+	Sampling LogSamplingConfig `mapstructure:"sampling"`
+	// QuietPaths 请求日志降为Debug级别的路径，例如健康检查
+	QuietPaths []string `mapstructure:"quiet_paths"`
+}
+
+// LogSinkConfig 日志输出配置，轮转参数为0时使用logger中的值
+type LogSinkConfig struct {
+	Type       string `mapstructure:"type"`
+	Path       string `mapstructure:"path"`
+	Level      string `mapstructure:"level"`
+	Format     string `mapstructure:"format"`
+	MaxSize    int    `mapstructure:"max_size"`
+	MaxAge     int    `mapstructure:"max_age"`
+	MaxBackups int    `mapstructure:"max_backups"`
+}
+
+// LogSamplingConfig 日志采样配置
+type LogSamplingConfig struct {
+	Initial    int           `mapstructure:"initial"`
+	Thereafter int           `mapstructure:"thereafter"`
+	Tick       time.Duration `mapstructure:"tick"`
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	StateTTL     time.Duration                 `mapstructure:"state_ttl"`
+	MockProvider bool                          `mapstructure:"mock_provider"`
+	Providers    map[string]OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig OIDC身份提供方配置
+type OIDCProviderConfig struct {
+	IssuerURL    string   `mapstructure:"issuer_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// MFAConfig 二次验证配置，同一用户连续失败MaxAttempts次后在LockoutDuration内拒绝验证
+type MFAConfig struct {
+	Issuer            string        `mapstructure:"issuer"`
+	RecoveryCodeCount int           `mapstructure:"recovery_code_count"`
+	MaxAttempts       int           `mapstructure:"max_attempts"`
+	LockoutDuration   time.Duration `mapstructure:"lockout_duration"`
+}
+
+// PasswordConfig 密码哈希和强度策略配置，Algorithm为argon2id或bcrypt
+type PasswordConfig struct {
+	Algorithm  string               `mapstructure:"algorithm"`
+	Argon2     Argon2Config         `mapstructure:"argon2"`
+	BcryptCost int                  `mapstructure:"bcrypt_cost"`
+	Policy     PasswordPolicyConfig `mapstructure:"policy"`
+}
+
+// Argon2Config argon2id参数，Memory单位为KiB
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+}
+
+// PasswordPolicyConfig 密码强度策略，BreachedList为泄露密码列表文件，每行一个
+type PasswordPolicyConfig struct {
+	MinLength     int    `mapstructure:"min_length"`
+	RequireUpper  bool   `mapstructure:"require_upper"`
+	RequireLower  bool   `mapstructure:"require_lower"`
+	RequireDigit  bool   `mapstructure:"require_digit"`
+	RequireSymbol bool   `mapstructure:"require_symbol"`
+	BreachedList  string `mapstructure:"breached_list"`
+}
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	BufferSize    int           `mapstructure:"buffer_size"`
+	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// UserConfig 用户管理配置
+type UserConfig struct {
+	DeletedRetention time.Duration `mapstructure:"deleted_retention"`
+	PurgeInterval    time.Duration `mapstructure:"purge_interval"`
+}
+
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Driver        string        `mapstructure:"driver"`
+	LocalRoot     string        `mapstructure:"local_root"`
+	SigningSecret string        `mapstructure:"signing_secret"`
+	URLTTL        time.Duration `mapstructure:"url_ttl"`
+}
+
+// AvatarConfig 头像上传配置
+type AvatarConfig struct {
+	MaxSize      int64    `mapstructure:"max_size"`
+	MaxDimension int      `mapstructure:"max_dimension"`
+	AllowedTypes []string `mapstructure:"allowed_types"`
+	Sizes        []int    `mapstructure:"sizes"`
+}
+
+// I18nConfig 国际化配置
+type I18nConfig struct {
+	DefaultLocale string `mapstructure:"default_locale"`
+}
+
+// IdempotencyConfig 幂等请求配置
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// CacheConfig 用户查询缓存配置
+type CacheConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Capacity int           `mapstructure:"capacity"`
+	TTL      time.Duration `mapstructure:"ttl"`
+}
+
+// TenantConfig 多租户配置
+type TenantConfig struct {
+	Header     string `mapstructure:"header"`
+	BaseDomain string `mapstructure:"base_domain"`
+	Default    string `mapstructure:"default"`
+}
+
+// ImportConfig 批量导入用户配置
+type ImportConfig struct {
+	MaxSize   int64 `mapstructure:"max_size"`
+	MaxRows   int   `mapstructure:"max_rows"`
+	BatchSize int   `mapstructure:"batch_size"`
+	QueueSize int   `mapstructure:"queue_size"`
+}
+
+// WebhookConfig 领域事件投递配置
+type WebhookConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	Workers      int           `mapstructure:"workers"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	Backoff      time.Duration `mapstructure:"backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+	// AllowedNetworks 允许投递的内网网段或IP，默认拒绝内网、回环和链路本地地址
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+}
+
+// FlagsConfig 功能开关配置，Defaults中的开关可被管理接口写入数据库的设置覆盖
+type FlagsConfig struct {
+	RefreshInterval time.Duration         `mapstructure:"refresh_interval"`
+	Defaults        map[string]FlagConfig `mapstructure:"defaults"`
+}
+
+// DebugConfig 诊断接口配置，Listen为空时挂载到主服务的/debug下并要求默认租户的管理员
+type DebugConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	Listen             string        `mapstructure:"listen"`
+	ProfileDir         string        `mapstructure:"profile_dir"`
+	MaxProfileDuration time.Duration `mapstructure:"max_profile_duration"`
 }
 
-// GlobalConfig 全局配置实例
-var GlobalConfig *Config
+// FlagConfig 单个功能开关，Rollout为空时表示100%
+type FlagConfig struct {
+	Description string   `mapstructure:"description"`
+	Enabled     bool     `mapstructure:"enabled"`
+	Rollout     *int     `mapstructure:"rollout"`
+	Users       []uint   `mapstructure:"users"`
+	Roles       []string `mapstructure:"roles"`
+}
 
-// LoadConfig 加载配置
-func LoadConfig() (*Config, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath("../configs")
-	viper.AddConfigPath("../../configs")
+// LoadOptions 配置加载选项
+type LoadOptions struct {
+	// File 基础配置文件路径，为空时依次在./configs、../configs、../../configs中查找config.yaml
+	File string
+	// Env 运行环境，为空时读取APP_ENV环境变量或配置文件中的env
+	Env string
+}
+
+// LoadConfig 加载配置，优先级从低到高依次为：
+//  1. 默认值
+//  2. 基础配置文件（config.yaml或opts.File）
+//  3. 环境配置文件（与基础配置文件同目录的config.{env}.yaml）
+//  4. 本地配置文件（同目录的config.local.yaml，不提交到版本库）
+//  5. APP_前缀的环境变量，例如APP_SERVER_PORT
+//
+// 后加载的文件逐项覆盖之前的配置，映射按键合并，列表整体替换。环境配置文件和本地配置文件不存在时跳过
+func LoadConfig(opts LoadOptions) (*Config, error) {
+	v := viper.New()
+	if opts.File != "" {
+		v.SetConfigFile(opts.File)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath("./configs")
+		v.AddConfigPath("../configs")
+		v.AddConfigPath("../../configs")
+	}
 
 	// 设置默认值
-	viper.SetDefault("server.port", 8080)
-	viper.SetDefault("server.host", "localhost")
-	viper.SetDefault("server.mode", "debug")
+	v.SetDefault("env", "")
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.read_timeout", "30s")
+	v.SetDefault("server.read_header_timeout", "5s")
+	v.SetDefault("server.write_timeout", "60s")
+	v.SetDefault("server.idle_timeout", "120s")
+	v.SetDefault("server.max_body_size", 1<<20)
+	v.SetDefault("server.tls.reload_interval", "1m")
+	v.SetDefault("server.headers.hsts_max_age", "8760h")
+	v.SetDefault("server.headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'")
+	v.SetDefault("server.headers.frame_options", "DENY")
+	v.SetDefault("server.headers.referrer_policy", "no-referrer")
+	v.SetDefault("server.host", "localhost")
+	v.SetDefault("server.mode", "debug")
+
+	v.SetDefault("database.driver", "sqlite")
+	v.SetDefault("database.dsn", "./data/app.db")
+	v.SetDefault("database.max_idle_conns", 10)
+	v.SetDefault("database.max_open_conns", 100)
+	v.SetDefault("database.conn_max_lifetime", 3600)
+	v.SetDefault("database.auto_migrate", true)
+	v.SetDefault("database.replicas", []string{})
+	v.SetDefault("database.replica_check_interval", "5s")
+
+	v.SetDefault("jwt.secret", "your-jwt-secret-key-change-in-production")
+	v.SetDefault("jwt.access_token_exp", 3600)
+	v.SetDefault("jwt.refresh_token_exp", 86400)
+	v.SetDefault("jwt.mfa_token_exp", 300)
+
+	v.SetDefault("mfa.issuer", "Go Web API Template")
+	v.SetDefault("mfa.recovery_code_count", 10)
+	v.SetDefault("mfa.max_attempts", 5)
+	v.SetDefault("mfa.lockout_duration", "15m")
+
+	v.SetDefault("password.algorithm", "argon2id")
+	v.SetDefault("password.argon2.memory", 65536)
+	v.SetDefault("password.argon2.iterations", 3)
+	v.SetDefault("password.argon2.parallelism", 2)
+	v.SetDefault("password.bcrypt_cost", 10)
+	v.SetDefault("password.policy.min_length", 6)
+	v.SetDefault("password.policy.require_upper", false)
+	v.SetDefault("password.policy.require_lower", false)
+	v.SetDefault("password.policy.require_digit", false)
+	v.SetDefault("password.policy.require_symbol", false)
+	v.SetDefault("password.policy.breached_list", "")
+
+	v.SetDefault("i18n.default_locale", "en")
 
-	viper.SetDefault("database.driver", "sqlite")
-	viper.SetDefault("database.dsn", "./data/app.db")
-	viper.SetDefault("database.max_idle_conns", 10)
-	viper.SetDefault("database.max_open_conns", 100)
-	viper.SetDefault("database.conn_max_lifetime", 3600)
+	v.SetDefault("logger.level", "debug")
+	v.SetDefault("logger.format", "console")
+	v.SetDefault("logger.output", "stdout")
+	v.SetDefault("logger.file_path", "./logs/app.log")
+	v.SetDefault("logger.max_size", 100)
+	v.SetDefault("logger.max_age", 30)
+	v.SetDefault("logger.max_backups", 10)
+	v.SetDefault("logger.sampling.initial", 100)
+	v.SetDefault("logger.sampling.thereafter", 100)
+	v.SetDefault("logger.sampling.tick", "1s")
+	v.SetDefault("logger.quiet_paths", []string{"/health"})
 
-	viper.SetDefault("jwt.secret", "your-jwt-secret-key-change-in-production")
-	viper.SetDefault("jwt.access_token_exp", 3600)
-	viper.SetDefault("jwt.refresh_token_exp", 86400)
+	v.SetDefault("audit.buffer_size", 1024)
+	v.SetDefault("audit.batch_size", 100)
+	v.SetDefault("audit.flush_interval", "1s")
 
-	viper.SetDefault("logger.level", "debug")
-	viper.SetDefault("logger.format", "console")
-	viper.SetDefault("logger.output", "stdout")
+	v.SetDefault("user.deleted_retention", "720h")
+	v.SetDefault("user.purge_interval", "1h")
+
+	v.SetDefault("storage.driver", "local")
+	v.SetDefault("storage.local_root", "./data/blobs")
+	v.SetDefault("storage.url_ttl", "1h")
+
+	v.SetDefault("avatar.max_size", 5<<20)
+	v.SetDefault("avatar.max_dimension", 4096)
+	v.SetDefault("avatar.allowed_types", []string{"image/jpeg", "image/png", "image/gif"})
+	v.SetDefault("avatar.sizes", []int{256, 128, 64})
+
+	v.SetDefault("idempotency.ttl", "24h")
+
+	v.SetDefault("cache.enabled", true)
+	v.SetDefault("cache.capacity", 10000)
+	v.SetDefault("cache.ttl", "5m")
+
+	v.SetDefault("tenant.header", "X-Tenant-ID")
+	v.SetDefault("tenant.default", "default")
+
+	v.SetDefault("import.max_size", 10<<20)
+	v.SetDefault("import.max_rows", 10000)
+	v.SetDefault("import.batch_size", 100)
+	v.SetDefault("import.queue_size", 16)
+
+	v.SetDefault("webhook.enabled", true)
+	v.SetDefault("webhook.poll_interval", "2s")
+	v.SetDefault("webhook.batch_size", 50)
+	v.SetDefault("webhook.workers", 4)
+	v.SetDefault("webhook.timeout", "10s")
+	v.SetDefault("webhook.max_attempts", 8)
+	v.SetDefault("webhook.backoff", "30s")
+	v.SetDefault("webhook.max_backoff", "1h")
+
+	v.SetDefault("flags.refresh_interval", "30s")
+
+	v.SetDefault("debug.enabled", false)
+	v.SetDefault("debug.listen", "")
+	v.SetDefault("debug.profile_dir", "./data/profiles")
+	v.SetDefault("debug.max_profile_duration", "30s")
+
+	v.SetDefault("oauth.state_ttl", "10m")
+	v.SetDefault("oauth.mock_provider", false)
 
 	// 设置环境变量前缀
-	viper.SetEnvPrefix("APP")
+	v.SetEnvPrefix("APP")
 
 	// 自动绑定环境变量
-	viper.AutomaticEnv()
+	v.AutomaticEnv()
 
 	// 设置环境变量替换规则
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	base := v.ConfigFileUsed()
+	files := []string{base}
+
+	// 叠加环境配置文件和本地配置文件
+	if opts.Env != "" {
+		v.Set("env", opts.Env)
+	}
+	overlays := []string{overlayFile(base, "local")}
+	if env := v.GetString("env"); env != "" && env != "local" {
+		overlays = append([]string{overlayFile(base, env)}, overlays...)
+	}
+	for _, path := range overlays {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		v.SetConfigFile(path)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to merge config file %s: %w", path, err)
+		}
+		files = append(files, path)
+	}
 
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	config.files = files
+	config.settings = v.AllSettings()
 
-	GlobalConfig = &config
 	return &config, nil
-}
\ No newline at end of file
+}
+
+// overlayFile 与基础配置文件同目录的叠加文件，例如configs/config.yaml对应configs/config.production.yaml
+func overlayFile(base, name string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + name + ext
+}
+
+// Files 按合并顺序返回已加载的配置文件
+func (c *Config) Files() []string {
+	return c.files
+}
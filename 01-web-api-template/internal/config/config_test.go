@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFiles 在临时目录中写入配置文件，返回目录
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoadConfig_Layers(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+server:
+  port: 8080
+  host: "localhost"
+jwt:
+  secret: "base-secret"
+logger:
+  level: "debug"
+  quiet_paths: ["/health", "/metrics"]
+`,
+		"config.staging.yaml": `
+server:
+  host: "0.0.0.0"
+logger:
+  level: "info"
+  quiet_paths: ["/ready"]
+`,
+		"config.local.yaml": `
+logger:
+  level: "warn"
+`,
+	})
+	t.Setenv("APP_ENV", "")
+	t.Setenv("APP_SERVER_PORT", "9090")
+
+	cfg, err := LoadConfig(LoadOptions{File: filepath.Join(dir, "config.yaml"), Env: "staging"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "staging", cfg.Env)
+	// 环境变量优先于全部配置文件
+	assert.Equal(t, 9090, cfg.Server.Port)
+	// 环境配置文件覆盖基础配置，列表整体替换
+	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+	assert.Equal(t, []string{"/ready"}, cfg.Logger.QuietPaths)
+	// 本地配置文件最后合并
+	assert.Equal(t, "warn", cfg.Logger.Level)
+	// 未出现在任何文件中的配置项使用默认值
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "config.yaml"),
+		filepath.Join(dir, "config.staging.yaml"),
+		filepath.Join(dir, "config.local.yaml"),
+	}, cfg.Files())
+
+	settings := cfg.Settings()
+	assert.Equal(t, "[redacted]", settings["jwt"].(map[string]interface{})["secret"])
+	assert.Equal(t, "warn", settings["logger"].(map[string]interface{})["level"])
+}
+
+func TestLoadConfig_EnvFromEnvironment(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml":            "server:\n  mode: \"debug\"\n",
+		"config.production.yaml": "server:\n  mode: \"release\"\n",
+	})
+	base := filepath.Join(dir, "config.yaml")
+
+	t.Setenv("APP_ENV", "production")
+	cfg, err := LoadConfig(LoadOptions{File: base})
+	require.NoError(t, err)
+	assert.Equal(t, "production", cfg.Env)
+	assert.Equal(t, "release", cfg.Server.Mode)
+
+	// 环境配置文件不存在时只使用基础配置
+	t.Setenv("APP_ENV", "staging")
+	cfg, err = LoadConfig(LoadOptions{File: base})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Server.Mode)
+	assert.Equal(t, []string{base}, cfg.Files())
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	_, err := LoadConfig(LoadOptions{File: filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.Error(t, err)
+}
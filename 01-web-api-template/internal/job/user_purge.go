@@ -0,0 +1,84 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// UserPurgeJob 定期永久删除超过保留期的软删除用户
+type UserPurgeJob struct {
+	userService service.UserService
+	retention   time.Duration
+	interval    time.Duration
+	log         logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewUserPurgeJob 创建用户清理任务
+func NewUserPurgeJob(userService service.UserService, retention, interval time.Duration, log logger.Logger) *UserPurgeJob {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &UserPurgeJob{
+		userService: userService,
+		retention:   retention,
+		interval:    interval,
+		log:         log,
+	}
+}
+
+// Start 在后台启动任务，启动时立即执行一次
+func (j *UserPurgeJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 执行一次清理，清理范围包括全部租户
+func (j *UserPurgeJob) RunOnce(ctx context.Context) {
+	before := time.Now().Add(-j.retention)
+	purged, err := j.userService.PurgeDeleted(tenant.Unscoped(ctx), before)
+	if err != nil && ctx.Err() == nil {
+		j.log.Error("Failed to purge deleted users", zap.Int("purged", purged), zap.Error(err))
+		return
+	}
+	if purged > 0 {
+		j.log.Info("Purged deleted users",
+			zap.Int("count", purged),
+			zap.Time("deleted_before", before))
+	}
+}
+
+// Stop 停止任务并等待正在执行的清理结束
+func (j *UserPurgeJob) Stop() {
+	if j.cancel == nil {
+		return
+	}
+	j.cancel()
+	j.wg.Wait()
+}
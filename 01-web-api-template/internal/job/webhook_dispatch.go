@@ -0,0 +1,79 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/service"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// WebhookDispatchJob 定期将发件箱中的领域事件投递到Webhook
+type WebhookDispatchJob struct {
+	webhookService service.WebhookService
+	interval       time.Duration
+	log            logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookDispatchJob 创建Webhook投递任务
+func NewWebhookDispatchJob(webhookService service.WebhookService, interval time.Duration, log logger.Logger) *WebhookDispatchJob {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	return &WebhookDispatchJob{
+		webhookService: webhookService,
+		interval:       interval,
+		log:            log,
+	}
+}
+
+// Start 在后台启动任务，启动时立即执行一次
+func (j *WebhookDispatchJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 执行一次分发和投递，处理范围包括全部租户
+func (j *WebhookDispatchJob) RunOnce(ctx context.Context) {
+	attempted, err := j.webhookService.Dispatch(tenant.Unscoped(ctx))
+	if err != nil && ctx.Err() == nil {
+		j.log.Error("Failed to dispatch webhooks", zap.Error(err))
+		return
+	}
+	if attempted > 0 {
+		j.log.Debug("Dispatched webhooks", zap.Int("attempted", attempted))
+	}
+}
+
+// Stop 停止任务并等待正在进行的投递结束
+func (j *WebhookDispatchJob) Stop() {
+	if j.cancel == nil {
+		return
+	}
+	j.cancel()
+	j.wg.Wait()
+}
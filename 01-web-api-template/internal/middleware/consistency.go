@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/pkg/db"
+)
+
+// ConsistencyHeader 要求本次请求读取最新数据的请求头，值为strong时全部读操作使用主库
+const ConsistencyHeader = "X-Consistency"
+
+// ConsistencyMiddleware 配置了只读副本时决定请求的读一致性：
+// 修改数据的请求先读后写，全部读操作使用主库；其他请求读副本，
+// 请求中发生写操作后改读主库（读己之写），或通过X-Consistency: strong要求读主库
+func ConsistencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		switch {
+		case c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead:
+			ctx = db.WithPrimary(ctx)
+		case strings.EqualFold(c.GetHeader(ConsistencyHeader), "strong"):
+			ctx = db.WithPrimary(ctx)
+		default:
+			ctx = db.WithReadYourWrites(ctx)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/flags"
+)
+
+// FlagEvaluator 计算功能开关状态
+type FlagEvaluator interface {
+	Evaluate(subject flags.Subject) flags.Set
+}
+
+// FeatureFlagMiddleware 评估功能开关并放入请求上下文，之后可通过 flags.Enabled(ctx, key) 读取。
+// 在JWTAuthMiddleware之后使用时按当前用户和角色评估，否则按未登录请求评估；evaluator为nil时不处理
+func FeatureFlagMiddleware(evaluator FlagEvaluator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if evaluator == nil {
+			c.Next()
+			return
+		}
+
+		c.Request = c.Request.WithContext(withFlags(c.Request.Context(), evaluator))
+		c.Next()
+	}
+}
+
+// HTTPFeatureFlagMiddleware 与FeatureFlagMiddleware相同的功能开关评估，用于net/http处理器
+func HTTPFeatureFlagMiddleware(evaluator FlagEvaluator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if evaluator == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(withFlags(r.Context(), evaluator)))
+		})
+	}
+}
+
+// withFlags 按上下文中的当前用户评估功能开关，返回携带评估结果的上下文
+func withFlags(ctx context.Context, evaluator FlagEvaluator) context.Context {
+	var subject flags.Subject
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		subject = flags.Subject{UserID: claims.UserID, Role: claims.Role}
+	}
+	return flags.NewContext(ctx, evaluator.Evaluate(subject))
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// requestError 中间件拒绝请求时返回的状态码和错误信息，Gin和net/http版本的中间件共用
+type requestError struct {
+	status  int
+	message string
+}
+
+// writeError 以与Gin相同的格式写出错误响应：{"error": message}
+func writeError(w http.ResponseWriter, err *requestError) {
+	body, _ := json.Marshal(map[string]string{"error": err.message})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.status)
+	w.Write(body)
+}
@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// IdempotencyKeyHeader 客户端提供幂等键的请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 标记响应来自已保存结果的响应头
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength 幂等键最大长度
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	// ErrIdempotencyInProgress 相同幂等键的请求仍在处理中
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrIdempotencyMismatch 相同幂等键对应的请求内容不同
+	ErrIdempotencyMismatch = errors.New("idempotency key was already used with a different request")
+)
+
+// IdempotencyScope 幂等键的作用范围：同一租户中同一用户在同一路由上使用的键
+type IdempotencyScope struct {
+	Key      string
+	TenantID uint
+	UserID   uint
+	Method   string
+	Route    string
+}
+
+// IdempotentResponse 保存的响应
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyStore 幂等请求存储接口
+type IdempotencyStore interface {
+	// Begin 登记请求；已有完成的记录时返回保存的响应，处理中返回ErrIdempotencyInProgress，
+	// 请求内容不同返回ErrIdempotencyMismatch
+	Begin(ctx context.Context, scope IdempotencyScope, requestHash string) (*IdempotentResponse, error)
+	// Complete 保存请求的响应
+	Complete(ctx context.Context, scope IdempotencyScope, response *IdempotentResponse) error
+	// Release 放弃登记，允许客户端使用相同的键重试
+	Release(ctx context.Context, scope IdempotencyScope) error
+}
+
+// idempotencyWriter 在写出响应的同时保留一份副本
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// httpIdempotencyWriter net/http版本的idempotencyWriter，同时记录状态码
+type httpIdempotencyWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *httpIdempotencyWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *httpIdempotencyWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// Status 返回写出的状态码，未写出时为200
+func (w *httpIdempotencyWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// IdempotencyMiddleware 对携带Idempotency-Key的写请求保存响应，重复请求直接返回保存的响应。
+// 需要放在JWTAuthMiddleware之后，以便按用户区分幂等键
+func IdempotencyMiddleware(store IdempotencyStore, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		scope, saved, reqErr := beginIdempotent(c.Request, c.FullPath(), store, log)
+		if reqErr != nil {
+			c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+			c.Abort()
+			return
+		}
+		if scope == nil {
+			c.Next()
+			return
+		}
+
+		if saved != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(saved.StatusCode, saved.ContentType, saved.Body)
+			c.Abort()
+			return
+		}
+
+		// 处理过程中发生panic时同样放弃登记，避免该键在过期前一直处于处理中
+		defer func() {
+			if r := recover(); r != nil {
+				releaseIdempotent(ctx, store, *scope, log)
+				panic(r)
+			}
+		}()
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		finishIdempotent(ctx, store, *scope, &IdempotentResponse{
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}, log)
+	}
+}
+
+// HTTPIdempotencyMiddleware 与IdempotencyMiddleware相同的幂等处理，用于net/http处理器，
+// 需要放在HTTPAuthMiddleware之后。幂等键按ServeMux匹配的路由模式区分
+func HTTPIdempotencyMiddleware(store IdempotencyStore, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.Pattern
+			if _, path, ok := strings.Cut(route, " "); ok {
+				route = path
+			}
+			ctx := r.Context()
+			scope, saved, reqErr := beginIdempotent(r, route, store, log)
+			if reqErr != nil {
+				writeError(w, reqErr)
+				return
+			}
+			if scope == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if saved != nil {
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				if saved.ContentType != "" {
+					w.Header().Set("Content-Type", saved.ContentType)
+				}
+				w.WriteHeader(saved.StatusCode)
+				w.Write(saved.Body)
+				return
+			}
+
+			defer func() {
+				if r := recover(); r != nil {
+					releaseIdempotent(ctx, store, *scope, log)
+					panic(r)
+				}
+			}()
+
+			writer := &httpIdempotencyWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+
+			finishIdempotent(ctx, store, *scope, &IdempotentResponse{
+				StatusCode:  writer.Status(),
+				ContentType: writer.Header().Get("Content-Type"),
+				Body:        writer.body.Bytes(),
+			}, log)
+		})
+	}
+}
+
+// beginIdempotent 读取请求体并登记幂等键，请求体读取后重新放回r.Body。
+// 请求未携带幂等键或为只读请求时返回的scope为nil；saved不为nil时应直接返回保存的响应
+func beginIdempotent(r *http.Request, route string, store IdempotencyStore, log logger.Logger) (*IdempotencyScope, *IdempotentResponse, *requestError) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return nil, nil, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, nil, &requestError{http.StatusBadRequest, "Idempotency-Key is too long"}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if IsBodyTooLarge(err) {
+			return nil, nil, &requestError{http.StatusRequestEntityTooLarge, "Request body too large"}
+		}
+		return nil, nil, &requestError{http.StatusBadRequest, "Failed to read request body"}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	scope := IdempotencyScope{Key: key, Method: r.Method, Route: route}
+	scope.TenantID, _ = tenant.FromContext(r.Context())
+	if claims, ok := ClaimsFromContext(r.Context()); ok {
+		scope.UserID = claims.UserID
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Header.Get("Content-Type")))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	saved, err := store.Begin(r.Context(), scope, hex.EncodeToString(hash.Sum(nil)))
+	switch {
+	case errors.Is(err, ErrIdempotencyInProgress):
+		return nil, nil, &requestError{http.StatusConflict, err.Error()}
+	case errors.Is(err, ErrIdempotencyMismatch):
+		return nil, nil, &requestError{http.StatusUnprocessableEntity, err.Error()}
+	case err != nil:
+		log.Error("Failed to begin idempotent request", zap.String("key", key), zap.Error(err))
+		return nil, nil, &requestError{http.StatusInternalServerError, "Failed to process idempotency key"}
+	}
+	return &scope, saved, nil
+}
+
+// finishIdempotent 保存处理结果；服务端错误不保存，允许客户端使用相同的键重试。
+// 响应已经写出，保存不随请求取消而中断
+func finishIdempotent(ctx context.Context, store IdempotencyStore, scope IdempotencyScope, response *IdempotentResponse, log logger.Logger) {
+	if response.StatusCode >= http.StatusInternalServerError {
+		releaseIdempotent(ctx, store, scope, log)
+		return
+	}
+	if err := store.Complete(context.WithoutCancel(ctx), scope, response); err != nil {
+		log.Error("Failed to save idempotent response", zap.String("key", scope.Key), zap.Error(err))
+	}
+}
+
+// releaseIdempotent 放弃登记
+func releaseIdempotent(ctx context.Context, store IdempotencyStore, scope IdempotencyScope, log logger.Logger) {
+	if err := store.Release(context.WithoutCancel(ctx), scope); err != nil {
+		log.Error("Failed to release idempotency key", zap.String("key", scope.Key), zap.Error(err))
+	}
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
 	"strings"
 	"time"
@@ -8,30 +11,80 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
 	"go-practical-roadmap/01-web-api-template/pkg/logger"
 	"go.uber.org/zap"
 )
 
+const (
+	// AccessTokenSubject 访问令牌的主题
+	AccessTokenSubject = "access_token"
+	// MFAPendingSubject 等待二次验证令牌的主题，只能用于 /api/v1/login/mfa
+	MFAPendingSubject = "mfa_pending"
+)
+
+// claimsKey 认证信息在请求上下文中的键
+type claimsKey struct{}
+
 // Claims JWT声明结构体
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
+	TenantID uint   `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT令牌
-func GenerateToken(userID uint, username string) (string, error) {
+// SessionValidator 会话校验接口，用于拒绝已注销会话的令牌
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, tokenID string) error
+}
+
+// TokenIssuer 使用配置的密钥签发和校验JWT令牌，由App创建后注入会话服务和认证中间件
+type TokenIssuer struct {
+	secret []byte
+	// accessTokenExp、mfaTokenExp 有效期，单位为秒
+	accessTokenExp time.Duration
+	mfaTokenExp    time.Duration
+}
+
+// NewTokenIssuer 创建令牌签发器
+func NewTokenIssuer(cfg config.JWTConfig) *TokenIssuer {
+	return &TokenIssuer{
+		secret:         []byte(cfg.Secret),
+		accessTokenExp: cfg.AccessTokenExp,
+		mfaTokenExp:    cfg.MFATokenExp,
+	}
+}
+
+// GenerateToken 生成JWT令牌，同时返回声明以便调用方记录令牌ID
+func (t *TokenIssuer) GenerateToken(userID uint, username string, role string, tenantID uint) (string, *Claims, error) {
+	return t.signToken(userID, username, role, tenantID, AccessTokenSubject, t.accessTokenExp)
+}
+
+// GenerateMFAToken 生成等待二次验证的短期令牌
+func (t *TokenIssuer) GenerateMFAToken(userID uint, username string, tenantID uint) (string, error) {
+	token, _, err := t.signToken(userID, username, "", tenantID, MFAPendingSubject, t.mfaTokenExp)
+	return token, err
+}
+
+// signToken 按指定主题和有效期（秒）签发令牌
+func (t *TokenIssuer) signToken(userID uint, username, role string, tenantID uint, subject string, exp time.Duration) (string, *Claims, error) {
 	// 设置令牌过期时间
-	expirationTime := time.Now().Add(time.Duration(config.GlobalConfig.JWT.AccessTokenExp) * time.Second)
+	expirationTime := time.Now().Add(time.Duration(exp) * time.Second)
 
 	// 创建声明
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
+		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        generateTokenID(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   "access_token",
+			Subject:   subject,
 		},
 	}
 
@@ -39,21 +92,21 @@ func GenerateToken(userID uint, username string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// 签名令牌
-	tokenString, err := token.SignedString([]byte(config.GlobalConfig.JWT.Secret))
+	tokenString, err := token.SignedString(t.secret)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
 // ValidateToken 验证JWT令牌
-func ValidateToken(tokenString string) (*Claims, error) {
+func (t *TokenIssuer) ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	// 解析令牌
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GlobalConfig.JWT.Secret), nil
+		return t.secret, nil
 	})
 
 	if err != nil {
@@ -68,37 +121,143 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// JWTAuthMiddleware JWT认证中间件
-func JWTAuthMiddleware(c *gin.Context) {
-	// 从请求头获取Authorization字段
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		logger.Warn("Missing Authorization header")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
+// JWTAuthMiddleware JWT认证中间件，tokens用于校验令牌签名，sessions用于校验令牌对应的会话是否仍然有效
+// （为nil时不校验会话），被拒绝的请求记录到log
+func JWTAuthMiddleware(tokens *TokenIssuer, sessions SessionValidator, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticate(c, tokens, sessions, log)
+	}
+}
+
+// authenticate 校验请求中的访问令牌
+func authenticate(c *gin.Context, tokens *TokenIssuer, sessions SessionValidator, log logger.Logger) {
+	ctx, err := verifyRequest(c.Request.Context(), c.GetHeader("Authorization"), tokens, sessions, log)
+	if err != nil {
+		c.JSON(err.status, gin.H{"error": err.message})
 		c.Abort()
 		return
 	}
 
+	// 保存认证信息供后续处理器使用
+	c.Request = c.Request.WithContext(ctx)
+
+	// 调用下一个处理器
+	c.Next()
+}
+
+// HTTPAuthMiddleware 与JWTAuthMiddleware相同的令牌校验，用于net/http处理器
+func HTTPAuthMiddleware(tokens *TokenIssuer, sessions SessionValidator, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, err := verifyRequest(r.Context(), r.Header.Get("Authorization"), tokens, sessions, log)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// verifyRequest 校验Authorization请求头中的访问令牌，成功时返回携带认证信息、租户和当前用户的上下文
+func verifyRequest(ctx context.Context, authHeader string, tokens *TokenIssuer, sessions SessionValidator, log logger.Logger) (context.Context, *requestError) {
+	if authHeader == "" {
+		log.Warn("Missing Authorization header")
+		return nil, &requestError{http.StatusUnauthorized, "Missing Authorization header"}
+	}
+
 	// 检查Bearer前缀
 	if !strings.HasPrefix(authHeader, "Bearer ") {
-		logger.Warn("Invalid Authorization header format")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
-		c.Abort()
-		return
+		log.Warn("Invalid Authorization header format")
+		return nil, &requestError{http.StatusUnauthorized, "Invalid Authorization header format"}
 	}
 
 	// 提取令牌
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-	// 验证令牌
-	_, err := ValidateToken(tokenString)
+	// 验证令牌，等待二次验证的令牌不能访问受保护资源
+	claims, err := tokens.ValidateToken(tokenString)
+	if err == nil && claims.Subject != AccessTokenSubject {
+		err = jwt.ErrTokenInvalidSubject
+	}
 	if err != nil {
-		logger.Warn("Invalid token", zap.Error(err))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-		c.Abort()
-		return
+		log.Warn("Invalid token", zap.Error(err))
+		return nil, &requestError{http.StatusUnauthorized, "Invalid token"}
 	}
 
-	// 调用下一个处理器
-	c.Next()
+	// 会话被注销后令牌立即失效；未注入会话服务时（例如测试中）只校验令牌本身
+	if sessions != nil {
+		if err := sessions.ValidateSession(ctx, claims.ID); err != nil {
+			log.Warn("Session rejected", zap.String("token_id", claims.ID), zap.Error(err))
+			return nil, &requestError{http.StatusUnauthorized, "Session has been revoked"}
+		}
+	}
+
+	// 令牌只能在签发时的租户中使用，未明确指定租户的请求使用令牌中的租户
+	if claims.TenantID != 0 {
+		if current, ok := tenant.FromContext(ctx); ok && current != claims.TenantID && TenantExplicit(ctx) {
+			log.Warn("Token tenant mismatch",
+				zap.Uint("token_tenant", claims.TenantID),
+				zap.Uint("request_tenant", current))
+			return nil, &requestError{http.StatusForbidden, "Token does not belong to this tenant"}
+		}
+		ctx = tenant.WithTenant(ctx, claims.TenantID)
+	}
+
+	ctx = context.WithValue(ctx, claimsKey{}, claims)
+	return requestinfo.WithUser(ctx, claims.UserID, claims.Username), nil
+}
+
+// RequireRole 角色校验中间件，需在JWTAuthMiddleware之后使用
+func RequireRole(role string, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checkRole(c.Request.Context(), role, log); err != nil {
+			c.JSON(err.status, gin.H{"error": err.message})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HTTPRequireRole 与RequireRole相同的角色校验，用于net/http处理器，需在HTTPAuthMiddleware之后使用
+func HTTPRequireRole(role string, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := checkRole(r.Context(), role, log); err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkRole 检查当前用户的角色
+func checkRole(ctx context.Context, role string, log logger.Logger) *requestError {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.Role != role {
+		log.Warn("Insufficient role", zap.String("required", role))
+		return &requestError{http.StatusForbidden, "Forbidden"}
+	}
+	return nil
+}
+
+// CurrentClaims 获取当前请求的认证信息
+func CurrentClaims(c *gin.Context) (*Claims, bool) {
+	return ClaimsFromContext(c.Request.Context())
+}
+
+// ClaimsFromContext 从请求上下文获取认证信息，未经过认证中间件时返回false
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// generateTokenID 生成令牌唯一标识(jti)
+func generateTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
\ No newline at end of file
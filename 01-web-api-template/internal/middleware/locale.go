@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/validation"
+)
+
+// localeKey 请求上下文中保存请求语言的键
+type localeKey struct{}
+
+// LocaleMiddleware 根据Accept-Language确定响应语言，无法匹配时使用defaultLocale，为空时使用validation.DefaultLocale
+func LocaleMiddleware(defaultLocale string) gin.HandlerFunc {
+	if defaultLocale == "" {
+		defaultLocale = validation.DefaultLocale
+	}
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(withLocale(c.Request, c.Writer, defaultLocale))
+		c.Next()
+	}
+}
+
+// HTTPLocaleMiddleware 与LocaleMiddleware相同的语言协商，用于net/http处理器
+func HTTPLocaleMiddleware(defaultLocale string) func(http.Handler) http.Handler {
+	if defaultLocale == "" {
+		defaultLocale = validation.DefaultLocale
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(withLocale(r, w, defaultLocale)))
+		})
+	}
+}
+
+// withLocale 协商请求语言，设置Content-Language响应头并返回携带语言的上下文
+func withLocale(r *http.Request, w http.ResponseWriter, defaultLocale string) context.Context {
+	locale := validation.ResolveLocale(r.Header.Get("Accept-Language"), defaultLocale)
+	w.Header().Set("Content-Language", locale)
+	return context.WithValue(r.Context(), localeKey{}, locale)
+}
+
+// Locale 返回当前请求的语言，未经过LocaleMiddleware时返回validation.DefaultLocale
+func Locale(c *gin.Context) string {
+	return LocaleFromContext(c.Request.Context())
+}
+
+// LocaleFromContext 返回请求上下文中的语言，未经过语言中间件时返回validation.DefaultLocale
+func LocaleFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return validation.DefaultLocale
+}
@@ -5,13 +5,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
 	"go-practical-roadmap/01-web-api-template/pkg/logger"
 	"go.uber.org/zap"
 	"math/rand"
 )
 
-// RequestTracerMiddleware 请求追踪中间件
-func RequestTracerMiddleware() gin.HandlerFunc {
+// RequestTracerMiddleware 请求追踪中间件，请求日志使用log下名为http的日志记录器。
+// quietPaths中的路径（例如健康检查）只在Debug级别记录
+func RequestTracerMiddleware(log logger.Logger, quietPaths ...string) gin.HandlerFunc {
+	httpLogger := log.Named("http")
+	quiet := make(map[string]bool, len(quietPaths))
+	for _, path := range quietPaths {
+		quiet[path] = true
+	}
+
 	return func(c *gin.Context) {
 		// 生成请求ID
 		requestID := generateRequestID()
@@ -19,12 +27,20 @@ func RequestTracerMiddleware() gin.HandlerFunc {
 		// 将请求ID添加到上下文
 		c.Set("request_id", requestID)
 
+		// 将客户端信息写入请求上下文，供服务层记录会话、审计等使用
+		c.Request = c.Request.WithContext(requestinfo.NewContext(c.Request.Context(), requestinfo.Info{
+			RequestID: requestID,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
+
 		// 记录请求开始
 		start := time.Now()
 		method := c.Request.Method
 		url := c.Request.URL.Path
 
-		logger.Info("Request started",
+		// 请求开始只在Debug级别记录，完成日志已包含全部信息
+		httpLogger.Debug("Request started",
 			zap.String("request_id", requestID),
 			zap.String("method", method),
 			zap.String("url", url),
@@ -37,7 +53,11 @@ func RequestTracerMiddleware() gin.HandlerFunc {
 		duration := time.Since(start)
 		statusCode := c.Writer.Status()
 
-		logger.Info("Request completed",
+		logCompleted := httpLogger.Info
+		if quiet[url] {
+			logCompleted = httpLogger.Debug
+		}
+		logCompleted("Request completed",
 			zap.String("request_id", requestID),
 			zap.String("method", method),
 			zap.String("url", url),
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultTenantHeader 默认的租户请求头
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// tenantExplicitKey 请求上下文中的键，表示请求通过子域名或请求头明确指定了租户
+type tenantExplicitKey struct{}
+
+// ErrTenantNotFound 租户不存在
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// TenantResolver 根据租户标识查找租户ID
+type TenantResolver interface {
+	ResolveTenant(ctx context.Context, slug string) (uint, error)
+}
+
+// TenantMiddleware 解析当前请求的租户：依次尝试子域名、租户请求头，都没有时使用默认租户。
+// 访问令牌中的租户由JWTAuthMiddleware校验，resolver为nil时不解析租户
+func TenantMiddleware(resolver TenantResolver, cfg config.TenantConfig, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if resolver == nil {
+			c.Next()
+			return
+		}
+
+		ctx, err := resolveTenant(c.Request, resolver, cfg, log)
+		if err != nil {
+			c.JSON(err.status, gin.H{"error": err.message})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// HTTPTenantMiddleware 与TenantMiddleware相同的租户解析，用于net/http处理器
+func HTTPTenantMiddleware(resolver TenantResolver, cfg config.TenantConfig, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if resolver == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, err := resolveTenant(r, resolver, cfg, log)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// resolveTenant 解析请求的租户，返回携带租户的上下文
+func resolveTenant(r *http.Request, resolver TenantResolver, cfg config.TenantConfig, log logger.Logger) (context.Context, *requestError) {
+	header := cfg.Header
+	if header == "" {
+		header = DefaultTenantHeader
+	}
+
+	slug := subdomainTenant(r.Host, cfg.BaseDomain)
+	if slug == "" {
+		slug = strings.ToLower(strings.TrimSpace(r.Header.Get(header)))
+	}
+	explicit := slug != ""
+	if !explicit {
+		slug = cfg.Default
+	}
+	if slug == "" {
+		return nil, &requestError{http.StatusBadRequest, "Tenant is required"}
+	}
+
+	tenantID, err := resolver.ResolveTenant(r.Context(), slug)
+	if errors.Is(err, ErrTenantNotFound) {
+		return nil, &requestError{http.StatusNotFound, "Tenant not found"}
+	}
+	if err != nil {
+		log.Error("Failed to resolve tenant", zap.String("tenant", slug), zap.Error(err))
+		return nil, &requestError{http.StatusInternalServerError, "Failed to resolve tenant"}
+	}
+
+	ctx := context.WithValue(r.Context(), tenantExplicitKey{}, explicit)
+	return tenant.WithTenant(ctx, tenantID), nil
+}
+
+// TenantExplicit 请求是否通过子域名或请求头明确指定了租户
+func TenantExplicit(ctx context.Context) bool {
+	explicit, _ := ctx.Value(tenantExplicitKey{}).(bool)
+	return explicit
+}
+
+// subdomainTenant 从Host中提取基础域名下的一级子域名，例如 acme.api.example.com 中的 acme
+func subdomainTenant(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(host)
+	suffix := "." + strings.ToLower(strings.TrimPrefix(baseDomain, "."))
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	label := strings.TrimSuffix(host, suffix)
+	if label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// RequireTenant 限定只有指定租户的请求可以访问，例如平台管理接口只对默认租户开放
+func RequireTenant(resolver TenantResolver, slug string, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, ok := tenant.FromContext(c.Request.Context())
+		required, err := resolver.ResolveTenant(c.Request.Context(), slug)
+		if !ok || err != nil || current != required {
+			log.Warn("Tenant not allowed", zap.String("required", slug), zap.Uint("tenant", current))
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
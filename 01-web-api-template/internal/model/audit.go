@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// AuditLog 审计日志模型，只允许追加写入，按租户隔离
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+	TenantID   uint      `gorm:"index;not null;default:0" json:"-"`
+	Action     string    `gorm:"index;size:50;not null" json:"action"`
+	Success    bool      `json:"success"`
+	ActorID    *uint     `gorm:"index" json:"actor_id,omitempty"`
+	ActorName  string    `gorm:"size:50" json:"actor_name"`
+	TargetType string    `gorm:"index:idx_audit_target;size:50" json:"target_type"`
+	TargetID   string    `gorm:"index:idx_audit_target;size:100" json:"target_id"`
+	IP         string    `gorm:"size:45" json:"ip"`
+	RequestID  string    `gorm:"size:64" json:"request_id"`
+	Diff       string    `gorm:"type:text" json:"diff,omitempty"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeatureFlag 管理员在运行时设置的功能开关，覆盖配置文件中的同名开关。开关对全部租户生效；
+// key在MySQL中是保留字，列名使用flag_key
+type FeatureFlag struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Key         string    `gorm:"column:flag_key;uniqueIndex;size:100;not null" json:"key"`
+	Description string    `gorm:"size:255" json:"description"`
+	Enabled     bool      `gorm:"not null;default:false" json:"enabled"`
+	Rollout     int       `gorm:"not null" json:"rollout"`
+	UserIDs     string    `gorm:"size:1000" json:"-"`
+	Roles       string    `gorm:"size:255" json:"-"`
+	UpdatedBy   uint      `json:"updated_by"`
+}
+
+// TableName 指定表名
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
+
+// TargetUserIDs 始终开启的用户ID
+func (f *FeatureFlag) TargetUserIDs() []uint {
+	ids := []uint{}
+	for _, value := range strings.Split(f.UserIDs, ",") {
+		if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// SetTargetUserIDs 设置始终开启的用户ID
+func (f *FeatureFlag) SetTargetUserIDs(ids []uint) {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatUint(uint64(id), 10))
+	}
+	f.UserIDs = strings.Join(values, ",")
+}
+
+// TargetRoles 始终开启的角色
+func (f *FeatureFlag) TargetRoles() []string {
+	if f.Roles == "" {
+		return []string{}
+	}
+	return strings.Split(f.Roles, ",")
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// IdempotencyRecord 幂等请求记录，StatusCode为0表示请求仍在处理中。
+// 幂等键在租户内唯一，查询时由租户回调自动追加条件
+type IdempotencyRecord struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `gorm:"index;not null" json:"expires_at"`
+	TenantID    uint      `gorm:"uniqueIndex:idx_idempotency_tenant_scope,priority:1;not null;default:0" json:"-"`
+	Key         string    `gorm:"column:idempotency_key;uniqueIndex:idx_idempotency_tenant_scope,priority:2;size:255;not null" json:"key"`
+	UserID      uint      `gorm:"uniqueIndex:idx_idempotency_tenant_scope,priority:3;not null" json:"user_id"`
+	Method      string    `gorm:"uniqueIndex:idx_idempotency_tenant_scope,priority:4;size:10;not null" json:"method"`
+	Route       string    `gorm:"uniqueIndex:idx_idempotency_tenant_scope,priority:5;size:255;not null" json:"route"`
+	RequestHash string    `gorm:"size:64;not null" json:"request_hash"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `gorm:"size:100" json:"content_type"`
+	Body        []byte    `json:"-"`
+}
+
+// TableName 指定表名
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_records"
+}
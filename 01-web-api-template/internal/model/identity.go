@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// UserIdentity 第三方身份绑定模型，同一第三方身份可以在每个租户中各绑定一个用户
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	TenantID  uint      `gorm:"uniqueIndex:idx_identity_tenant_provider_subject,priority:1;not null;default:0" json:"-"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Provider  string    `gorm:"uniqueIndex:idx_identity_tenant_provider_subject,priority:2;size:50;not null" json:"provider"`
+	Subject   string    `gorm:"uniqueIndex:idx_identity_tenant_provider_subject,priority:3;size:255;not null" json:"subject"`
+	Email     string    `gorm:"size:100" json:"email"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// OutboxEvent 领域事件发件箱，与业务数据在同一事务中写入，由投递任务异步分发到Webhook
+type OutboxEvent struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	TenantID     uint       `gorm:"index;not null;default:0" json:"tenant_id"`
+	Type         string     `gorm:"size:50;not null" json:"type"`
+	SubjectID    string     `gorm:"size:64" json:"subject_id"`
+	Payload      string     `gorm:"type:text;not null" json:"-"`
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at,omitempty"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
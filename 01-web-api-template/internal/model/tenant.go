@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Tenant 租户（客户组织），用户名和邮箱在租户内唯一
+type Tenant struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Slug      string    `gorm:"uniqueIndex;size:63;not null" json:"slug"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+}
+
+// TableName 指定表名
+func (Tenant) TableName() string {
+	return "tenants"
+}
@@ -6,18 +6,46 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// RoleUser 普通用户角色
+	RoleUser = "user"
+	// RoleAdmin 管理员角色
+	RoleAdmin = "admin"
+)
+
 // User 用户模型
 type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
-	Username  string         `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Email     string         `gorm:"uniqueIndex;size:100;not null" json:"email"`
+	Username  string         `gorm:"uniqueIndex:idx_users_tenant_username;size:50;not null" json:"username"`
+	Email     string         `gorm:"uniqueIndex:idx_users_tenant_email;size:100;not null" json:"email"`
 	Password  string         `gorm:"size:255;not null" json:"-"`
 	FirstName string         `gorm:"size:50" json:"first_name"`
 	LastName  string         `gorm:"size:50" json:"last_name"`
 	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	Role      string         `gorm:"size:20;default:user;not null" json:"role"`
+	AvatarKey string         `gorm:"size:255" json:"-"`
+
+	// 账号停用：停用后不能登录，重新启用时清空停用时间和原因
+	DeactivatedAt      *time.Time `json:"deactivated_at,omitempty"`
+	DeactivationReason string     `gorm:"size:255" json:"deactivation_reason,omitempty"`
+
+	// 二次验证(TOTP)：密钥在确认首个验证码前处于待启用状态
+	MFAEnabled  bool   `gorm:"default:false" json:"mfa_enabled"`
+	MFASecret   string `gorm:"size:64" json:"-"`
+	MFALastStep int64  `json:"-"`
+	// 二次验证连续失败次数，达到上限后在MFALockedUntil之前拒绝验证
+	MFAFailedAttempts int        `gorm:"not null;default:0" json:"-"`
+	MFALockedUntil    *time.Time `json:"-"`
+
+	// DeletedID 软删除时置为用户ID，未删除时为0。与用户名、邮箱组成联合唯一索引，
+	// 使已删除用户不再占用用户名和邮箱（NULL在唯一索引中互不相等，因此不能直接使用DeletedAt）
+	DeletedID uint `gorm:"uniqueIndex:idx_users_tenant_username;uniqueIndex:idx_users_tenant_email;not null;default:0" json:"-"`
+
+	// TenantID 所属租户，用户名和邮箱在租户内唯一。查询时由租户回调自动追加条件
+	TenantID uint `gorm:"uniqueIndex:idx_users_tenant_username,priority:1;uniqueIndex:idx_users_tenant_email,priority:1;not null;default:0" json:"tenant_id"`
 }
 
 // TableName 指定表名
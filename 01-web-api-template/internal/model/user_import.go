@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// 导入任务状态
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// UserImportJob 批量导入用户的异步任务，按租户隔离
+type UserImportJob struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	TenantID   uint       `gorm:"index;not null;default:0" json:"-"`
+	CreatedBy  uint       `gorm:"not null" json:"created_by"`
+	Filename   string     `gorm:"size:255" json:"filename"`
+	Locale     string     `gorm:"size:10" json:"-"`
+	DryRun     bool       `json:"dry_run"`
+	Status     string     `gorm:"size:20;not null" json:"status"`
+	TotalRows  int        `json:"total_rows"`
+	Imported   int        `json:"imported"`
+	Failed     int        `json:"failed"`
+	Error      string     `gorm:"size:500" json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName 指定表名
+func (UserImportJob) TableName() string {
+	return "user_import_jobs"
+}
+
+// UserImportError 导入任务中单行数据的错误，Row为CSV中的行号（表头为第1行）
+type UserImportError struct {
+	ID      uint   `gorm:"primaryKey"`
+	JobID   uint   `gorm:"index;not null"`
+	Row     int    `gorm:"not null"`
+	Field   string `gorm:"size:50"`
+	Message string `gorm:"size:500;not null"`
+}
+
+// TableName 指定表名
+func (UserImportError) TableName() string {
+	return "user_import_errors"
+}
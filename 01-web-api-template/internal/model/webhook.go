@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 投递状态
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook 租户管理员登记的事件订阅，Secret用于对请求体签名
+type Webhook struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	TenantID    uint           `gorm:"index;not null;default:0" json:"-"`
+	URL         string         `gorm:"size:2048;not null" json:"url"`
+	Secret      string         `gorm:"size:128;not null" json:"-"`
+	Events      string         `gorm:"size:500" json:"-"`
+	Description string         `gorm:"size:255" json:"description"`
+	Active      bool           `gorm:"not null;default:true" json:"active"`
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// EventTypes 订阅的事件类型，为空表示订阅全部事件
+func (w *Webhook) EventTypes() []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// Subscribes 是否订阅指定类型的事件
+func (w *Webhook) Subscribes(eventType string) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, subscribed := range w.EventTypes() {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery 单个事件到单个Webhook的投递记录，同时作为重试队列和投递日志
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	TenantID       uint       `gorm:"index;not null;default:0" json:"-"`
+	WebhookID      uint       `gorm:"uniqueIndex:idx_webhook_deliveries_webhook_event;not null" json:"webhook_id"`
+	EventID        uint       `gorm:"uniqueIndex:idx_webhook_deliveries_webhook_event;not null" json:"event_id"`
+	EventType      string     `gorm:"size:50;not null" json:"event_type"`
+	Status         string     `gorm:"size:20;not null;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `gorm:"size:500" json:"error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,36 @@
+// Package blob 提供与具体存储无关的二进制对象存储接口及本地文件系统实现
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("blob not found")
+	// ErrInvalidKey 对象键不合法（为空、绝对路径或包含 ..）
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore 二进制对象存储接口，键使用 / 分隔的相对路径
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// CleanKey 校验并规范化对象键，防止路径穿越
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
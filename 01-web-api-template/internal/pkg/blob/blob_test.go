@@ -0,0 +1,62 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "avatars/1/a.png", strings.NewReader("hello")))
+
+	r, err := store.Get(ctx, "avatars/1/a.png")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "avatars/1/a.png"))
+	_, err = store.Get(ctx, "avatars/1/a.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 重复删除不报错
+	assert.NoError(t, store.Delete(ctx, "avatars/1/a.png"))
+}
+
+func TestCleanKey_RejectsTraversal(t *testing.T) {
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", "a\\b", "."} {
+		_, err := CleanKey(key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+
+	key, err := CleanKey("avatars/1/a.png")
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/1/a.png", key)
+}
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("secret", "/api/v1/files/", time.Minute)
+	now := time.Unix(1700000000, 0)
+
+	signed := signer.SignURL("avatars/1/a.png", now)
+	assert.True(t, strings.HasPrefix(signed, "/api/v1/files/avatars/1/a.png?"))
+
+	expires := "1700000060"
+	signature := signer.sign("avatars/1/a.png", 1700000060)
+	assert.Contains(t, signed, "signature="+signature)
+
+	assert.NoError(t, signer.Verify("avatars/1/a.png", expires, signature, now))
+	assert.ErrorIs(t, signer.Verify("avatars/1/b.png", expires, signature, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify("avatars/1/a.png", expires, signature, now.Add(2*time.Minute)), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify("avatars/1/a.png", "bogus", signature, now), ErrInvalidSignature)
+}
@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature 签名不匹配或已过期
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// URLSigner 为对象生成带过期时间的签名访问地址
+type URLSigner struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+// NewURLSigner 创建签名器，baseURL为文件访问端点前缀，例如 /api/v1/files
+func NewURLSigner(secret, baseURL string, ttl time.Duration) *URLSigner {
+	return &URLSigner{
+		secret:  []byte(secret),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		ttl:     ttl,
+	}
+}
+
+// SignURL 生成对象的签名访问地址
+func (s *URLSigner) SignURL(key string, now time.Time) string {
+	expires := now.Add(s.ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(key, expires))
+	return s.baseURL + "/" + key + "?" + query.Encode()
+}
+
+// Verify 校验签名和过期时间
+func (s *URLSigner) Verify(key, expires, signature string, now time.Time) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, exp))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// sign 计算 key 和过期时间的 HMAC-SHA256
+func (s *URLSigner) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
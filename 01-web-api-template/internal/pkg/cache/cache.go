@@ -0,0 +1,24 @@
+// Package cache 提供进程内缓存抽象及带过期时间的LRU实现
+package cache
+
+// Cache 键值缓存接口
+type Cache interface {
+	// Get 获取缓存值，不存在或已过期时返回false
+	Get(key string) (interface{}, bool)
+	// Set 写入缓存值
+	Set(key string, value interface{})
+	// Delete 删除缓存值
+	Delete(key string)
+	// Stats 返回命中统计
+	Stats() Stats
+}
+
+// Stats 缓存命中统计
+type Stats struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	Size      int     `json:"size"`
+	Capacity  int     `json:"capacity"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
@@ -0,0 +1,123 @@
+// Package certs 提供证书文件变化时自动重新加载的TLS证书
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileState 证书文件的修改时间和大小，用于判断文件是否变化
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Reloader 从磁盘加载证书和私钥，文件变化后重新加载。
+// 通过tls.Config.GetCertificate使用，已建立的连接不受影响，新连接使用新证书
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu    sync.RWMutex
+	cert  *tls.Certificate
+	state [2]fileState
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewReloader 加载证书和私钥，文件不存在或不匹配时返回错误
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 返回当前证书，用于tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload 证书或私钥文件变化时重新加载，返回是否加载了新证书。
+// 加载失败时继续使用原证书，避免替换文件的中间状态导致服务不可用
+func (r *Reloader) Reload() (bool, error) {
+	state, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && state == r.state
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.state = state
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch 每隔interval检查一次文件，onReload在每次检查后调用（可为nil），
+// loaded表示是否加载了新证书
+func (r *Reloader) Watch(interval time.Duration, onReload func(loaded bool, err error)) {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				loaded, err := r.Reload()
+				if onReload != nil {
+					onReload(loaded, err)
+				}
+			}
+		}
+	}()
+}
+
+// Close 停止Watch启动的检查
+func (r *Reloader) Close() {
+	r.once.Do(func() {
+		if r.stop != nil {
+			close(r.stop)
+			<-r.done
+		}
+	})
+}
+
+// stat 读取证书和私钥文件的状态
+func (r *Reloader) stat() ([2]fileState, error) {
+	var state [2]fileState
+	for i, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return state, fmt.Errorf("failed to stat certificate file: %w", err)
+		}
+		state[i] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return state, nil
+}
@@ -0,0 +1,143 @@
+// Package diagnostics 提供运行时诊断信息和CPU性能分析文件的采集
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrProfileInProgress 已有CPU性能分析正在进行，同一进程同时只能进行一个
+	ErrProfileInProgress = errors.New("cpu profile already in progress")
+	// ErrInvalidDuration 采集时长不在允许范围内
+	ErrInvalidDuration = errors.New("invalid profile duration")
+	// ErrProfileNotFound 性能分析文件不存在
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// profileName 性能分析文件名，只允许Profiler生成的格式，防止访问目录外的文件
+var profileName = regexp.MustCompile(`^cpu-\d{8}T\d{6}(\.\d+)?Z\.pprof$`)
+
+// Profile 已保存的性能分析文件
+type Profile struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Profiler 采集CPU性能分析并保存到目录
+type Profiler struct {
+	dir         string
+	maxDuration time.Duration
+
+	mu sync.Mutex
+}
+
+// NewProfiler 创建性能分析采集器，maxDuration为单次采集的最长时间
+func NewProfiler(dir string, maxDuration time.Duration) *Profiler {
+	if maxDuration <= 0 {
+		maxDuration = 30 * time.Second
+	}
+	return &Profiler{dir: dir, maxDuration: maxDuration}
+}
+
+// MaxDuration 单次采集的最长时间
+func (p *Profiler) MaxDuration() time.Duration {
+	return p.maxDuration
+}
+
+// CaptureCPU 采集duration时长的CPU性能分析并保存，ctx取消时提前结束并保存已采集的部分
+func (p *Profiler) CaptureCPU(ctx context.Context, duration time.Duration) (*Profile, error) {
+	if duration <= 0 || duration > p.maxDuration {
+		return nil, fmt.Errorf("%w: must be between 0 and %s", ErrInvalidDuration, p.maxDuration)
+	}
+	if !p.mu.TryLock() {
+		return nil, ErrProfileInProgress
+	}
+	defer p.mu.Unlock()
+
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	name := "cpu-" + time.Now().UTC().Format("20060102T150405.000Z") + ".pprof"
+	path := filepath.Join(p.dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create profile: %w", err)
+	}
+
+	// /debug/pprof/profile等其他采集正在进行时StartCPUProfile返回错误
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("%w: %v", ErrProfileInProgress, err)
+	}
+
+	timer := time.NewTimer(duration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	pprof.StopCPUProfile()
+
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write profile: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{Name: name, Size: info.Size(), CreatedAt: info.ModTime()}, nil
+}
+
+// List 列出已保存的性能分析文件，最新的在前
+func (p *Profiler) List() ([]Profile, error) {
+	entries, err := os.ReadDir(p.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Profile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]Profile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !profileName.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		profiles = append(profiles, Profile{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.Compare(profiles[i].Name, profiles[j].Name) > 0
+	})
+	return profiles, nil
+}
+
+// Path 返回性能分析文件的路径
+func (p *Profiler) Path(name string) (string, error) {
+	if !profileName.MatchString(name) {
+		return "", ErrProfileNotFound
+	}
+	path := filepath.Join(p.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrProfileNotFound
+		}
+		return "", err
+	}
+	return path, nil
+}
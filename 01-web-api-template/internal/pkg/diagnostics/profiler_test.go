@@ -0,0 +1,86 @@
+package diagnostics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiler_CaptureCPU(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	profiler := NewProfiler(dir, time.Second)
+
+	profiles, err := profiler.List()
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	_, err = profiler.CaptureCPU(context.Background(), 2*time.Second)
+	assert.ErrorIs(t, err, ErrInvalidDuration)
+
+	// 采集期间不能开始新的采集
+	started := make(chan struct{})
+	result := make(chan *Profile, 1)
+	go func() {
+		close(started)
+		profile, err := profiler.CaptureCPU(context.Background(), 200*time.Millisecond)
+		assert.NoError(t, err)
+		result <- profile
+	}()
+	<-started
+	require.Eventually(t, func() bool {
+		_, err := profiler.CaptureCPU(context.Background(), time.Millisecond)
+		return err == ErrProfileInProgress
+	}, time.Second, 5*time.Millisecond)
+
+	profile := <-result
+	require.NotNil(t, profile)
+	assert.Greater(t, profile.Size, int64(0))
+
+	profiles, err = profiler.List()
+	require.NoError(t, err)
+	require.NotEmpty(t, profiles)
+	assert.Equal(t, profile.Name, profiles[0].Name)
+
+	path, err := profiler.Path(profile.Name)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, profile.Name), path)
+
+	// 取消上下文时提前结束
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	_, err = profiler.CaptureCPU(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestProfiler_Path(t *testing.T) {
+	dir := t.TempDir()
+	profiler := NewProfiler(dir, time.Second)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("x"), 0o600))
+
+	for _, name := range []string{"secret.txt", "../secret.txt", "cpu-20240101T000000.000Z.pprof"} {
+		_, err := profiler.Path(name)
+		assert.ErrorIs(t, err, ErrProfileNotFound, name)
+	}
+
+	profiles, err := profiler.List()
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+}
+
+func TestReadRuntimeStats(t *testing.T) {
+	stats := ReadRuntimeStats()
+	assert.Greater(t, stats.Goroutines, 0)
+	assert.Greater(t, stats.GOMAXPROCS, 0)
+	assert.Greater(t, stats.Memory.Sys, uint64(0))
+	assert.LessOrEqual(t, len(stats.GC.RecentPauses), recentPauses)
+
+	info := ReadBuildInfo()
+	assert.NotEmpty(t, info.GoVersion)
+}
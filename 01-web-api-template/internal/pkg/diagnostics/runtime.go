@@ -0,0 +1,125 @@
+package diagnostics
+
+import (
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+)
+
+// recentPauses 返回的最近GC暂停次数
+const recentPauses = 10
+
+// RuntimeStats 运行时状态
+type RuntimeStats struct {
+	Goroutines int      `json:"goroutines"`
+	GOMAXPROCS int      `json:"gomaxprocs"`
+	NumCPU     int      `json:"num_cpu"`
+	Memory     MemStats `json:"memory"`
+	GC         GCStats  `json:"gc"`
+}
+
+// MemStats 内存统计，单位字节
+type MemStats struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	Sys          uint64 `json:"sys"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Mallocs      uint64 `json:"mallocs"`
+	Frees        uint64 `json:"frees"`
+}
+
+// GCStats 垃圾回收统计
+type GCStats struct {
+	NumGC         int64           `json:"num_gc"`
+	LastGC        time.Time       `json:"last_gc"`
+	PauseTotal    time.Duration   `json:"pause_total"`
+	RecentPauses  []time.Duration `json:"recent_pauses"`
+	NextGC        uint64          `json:"next_gc"`
+	GCCPUFraction float64         `json:"gc_cpu_fraction"`
+	GOGC          int             `json:"gogc"` // -1表示关闭了GC
+	MemoryLimit   int64           `json:"memory_limit"`
+}
+
+// ReadRuntimeStats 读取当前的运行时状态，ReadMemStats会短暂暂停程序，不宜高频调用
+func ReadRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var gc debug.GCStats
+	gc.Pause = make([]time.Duration, recentPauses)
+	debug.ReadGCStats(&gc)
+	if len(gc.Pause) > recentPauses {
+		gc.Pause = gc.Pause[:recentPauses]
+	}
+
+	// debug.SetGCPercent传入负数会关闭GC，通过runtime/metrics读取当前设置
+	samples := []metrics.Sample{{Name: "/gc/gogc:percent"}}
+	metrics.Read(samples)
+	gogc := -1
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		gogc = int(samples[0].Value.Uint64())
+	}
+	// 传入负数只读取当前设置
+	memoryLimit := debug.SetMemoryLimit(-1)
+
+	return RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+		Memory: MemStats{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapIdle:     mem.HeapIdle,
+			HeapReleased: mem.HeapReleased,
+			HeapObjects:  mem.HeapObjects,
+			StackInuse:   mem.StackInuse,
+			Sys:          mem.Sys,
+			TotalAlloc:   mem.TotalAlloc,
+			Mallocs:      mem.Mallocs,
+			Frees:        mem.Frees,
+		},
+		GC: GCStats{
+			NumGC:         gc.NumGC,
+			LastGC:        gc.LastGC,
+			PauseTotal:    gc.PauseTotal,
+			RecentPauses:  gc.Pause,
+			NextGC:        mem.NextGC,
+			GCCPUFraction: mem.GCCPUFraction,
+			GOGC:          gogc,
+			MemoryLimit:   memoryLimit,
+		},
+	}
+}
+
+// BuildInfo 构建信息
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings"`
+	Deps      map[string]string `json:"deps"`
+}
+
+// ReadBuildInfo 读取二进制中嵌入的构建信息，包括VCS版本和依赖版本
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version(), Settings: map[string]string{}, Deps: map[string]string{}}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Path = build.Main.Path
+	info.Version = build.Main.Version
+	for _, setting := range build.Settings {
+		info.Settings[setting.Key] = setting.Value
+	}
+	for _, dep := range build.Deps {
+		info.Deps[dep.Path] = dep.Version
+	}
+	return info
+}
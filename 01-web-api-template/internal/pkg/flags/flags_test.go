@@ -0,0 +1,55 @@
+package flags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlag_Evaluate(t *testing.T) {
+	flag := Flag{Key: "new-checkout", Enabled: true, UserIDs: []uint{7}, Roles: []string{"admin"}}
+
+	assert.True(t, flag.Evaluate(Subject{UserID: 7, Role: "user"}))
+	assert.True(t, flag.Evaluate(Subject{UserID: 8, Role: "admin"}))
+	assert.False(t, flag.Evaluate(Subject{UserID: 8, Role: "user"}))
+	assert.False(t, flag.Evaluate(Subject{}))
+
+	flag.Rollout = 100
+	assert.True(t, flag.Evaluate(Subject{}))
+
+	flag.Enabled = false
+	assert.False(t, flag.Evaluate(Subject{UserID: 7, Role: "admin"}))
+}
+
+func TestFlag_RolloutIsStableAndProportional(t *testing.T) {
+	flag := Flag{Key: "new-checkout", Enabled: true, Rollout: 30}
+
+	enabled := 0
+	for id := uint(1); id <= 10000; id++ {
+		result := flag.Evaluate(Subject{UserID: id})
+		assert.Equal(t, result, flag.Evaluate(Subject{UserID: id}))
+		if result {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 3000, enabled, 300)
+
+	// 提高比例时已开启的用户保持开启
+	wider := Flag{Key: "new-checkout", Enabled: true, Rollout: 60}
+	for id := uint(1); id <= 1000; id++ {
+		if flag.Evaluate(Subject{UserID: id}) {
+			assert.True(t, wider.Evaluate(Subject{UserID: id}))
+		}
+	}
+	assert.False(t, flag.Evaluate(Subject{}), "anonymous requests are outside partial rollouts")
+}
+
+func TestEnabled_ReadsContext(t *testing.T) {
+	assert.False(t, Enabled(context.Background(), "new-checkout"))
+
+	ctx := NewContext(context.Background(), Set{"new-checkout": true, "beta": false})
+	assert.True(t, Enabled(ctx, "new-checkout"))
+	assert.False(t, Enabled(ctx, "beta"))
+	assert.False(t, Enabled(ctx, "missing"))
+}
@@ -0,0 +1,234 @@
+// Package password 提供密码哈希（argon2id、bcrypt）和密码强度策略
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrMismatch 密码与哈希不匹配
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnsupportedHash 哈希的算法不受支持
+	ErrUnsupportedHash = errors.New("unsupported password hash")
+	// ErrInvalidHash 哈希格式错误
+	ErrInvalidHash = errors.New("invalid password hash")
+)
+
+// Hasher 密码哈希算法
+type Hasher interface {
+	// Hash 使用随机盐计算密码哈希
+	Hash(password string) (string, error)
+	// Verify 校验密码，不匹配时返回ErrMismatch
+	Verify(password, hash string) error
+	// Supports 哈希是否为该算法生成
+	Supports(hash string) bool
+	// NeedsRehash 哈希的参数与当前配置不同，应使用当前配置重新计算
+	NeedsRehash(hash string) bool
+}
+
+// Argon2id argon2id算法，哈希为PHC格式：$argon2id$v=19$m=65536,t=3,p=2$<盐>$<哈希>
+type Argon2id struct {
+	// Memory 内存开销，单位KiB
+	Memory uint32
+	// Iterations 迭代次数
+	Iterations uint32
+	// Parallelism 并行度
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// argon2Prefix argon2id哈希的前缀
+const argon2Prefix = "$argon2id$"
+
+// phcEncoding PHC格式使用不带填充的标准Base64
+var phcEncoding = base64.RawStdEncoding
+
+// NewArgon2id 创建argon2id哈希，为0的参数使用OWASP推荐的默认值
+func NewArgon2id(memory, iterations uint32, parallelism uint8) *Argon2id {
+	if memory == 0 {
+		memory = 64 * 1024
+	}
+	if iterations == 0 {
+		iterations = 3
+	}
+	if parallelism == 0 {
+		parallelism = 2
+	}
+	return &Argon2id{Memory: memory, Iterations: iterations, Parallelism: parallelism, SaltLength: 16, KeyLength: 32}
+}
+
+// Hash 实现Hasher
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		a.Memory, a.Iterations, a.Parallelism, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)), nil
+}
+
+// Verify 实现Hasher，按哈希中记录的参数计算
+func (a *Argon2id) Verify(password, hash string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// Supports 实现Hasher
+func (a *Argon2id) Supports(hash string) bool {
+	return strings.HasPrefix(hash, argon2Prefix)
+}
+
+// NeedsRehash 实现Hasher
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.Memory || params.Iterations != a.Iterations || params.Parallelism != a.Parallelism ||
+		uint32(len(salt)) != a.SaltLength || uint32(len(key)) != a.KeyLength
+}
+
+// decodeArgon2id 解析PHC格式的argon2id哈希
+func decodeArgon2id(hash string) (*Argon2id, []byte, []byte, error) {
+	if !strings.HasPrefix(hash, argon2Prefix) {
+		return nil, nil, nil, ErrUnsupportedHash
+	}
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", 盐, 哈希
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	params := &Argon2id{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := phcEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	key, err := phcEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	return params, salt, key, nil
+}
+
+// Bcrypt bcrypt算法，哈希为模块化加密格式：$2a$10$<盐和哈希>
+type Bcrypt struct {
+	Cost int
+}
+
+// NewBcrypt 创建bcrypt哈希，cost为0时使用bcrypt.DefaultCost
+func NewBcrypt(cost int) *Bcrypt {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &Bcrypt{Cost: cost}
+}
+
+// Hash 实现Hasher
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify 实现Hasher
+func (b *Bcrypt) Verify(password, hash string) error {
+	if !b.Supports(hash) {
+		return ErrUnsupportedHash
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	return nil
+}
+
+// Supports 实现Hasher
+func (b *Bcrypt) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// NeedsRehash 实现Hasher
+func (b *Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
+
+// Multi 使用当前算法计算新哈希，并能校验其他算法生成的旧哈希
+type Multi struct {
+	current Hasher
+	legacy  []Hasher
+}
+
+// NewMulti 创建多算法哈希，current用于新密码，legacy用于校验旧哈希
+func NewMulti(current Hasher, legacy ...Hasher) *Multi {
+	return &Multi{current: current, legacy: legacy}
+}
+
+// Hash 实现Hasher
+func (m *Multi) Hash(password string) (string, error) {
+	return m.current.Hash(password)
+}
+
+// Verify 实现Hasher，按哈希的格式选择算法
+func (m *Multi) Verify(password, hash string) error {
+	for _, hasher := range m.hashers() {
+		if hasher.Supports(hash) {
+			return hasher.Verify(password, hash)
+		}
+	}
+	return ErrUnsupportedHash
+}
+
+// Supports 实现Hasher
+func (m *Multi) Supports(hash string) bool {
+	for _, hasher := range m.hashers() {
+		if hasher.Supports(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsRehash 实现Hasher，不是当前算法生成的哈希都需要重新计算
+func (m *Multi) NeedsRehash(hash string) bool {
+	return !m.current.Supports(hash) || m.current.NeedsRehash(hash)
+}
+
+// hashers 当前算法和旧算法
+func (m *Multi) hashers() []Hasher {
+	return append([]Hasher{m.current}, m.legacy...)
+}
@@ -0,0 +1,80 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2id_HashAndVerify(t *testing.T) {
+	hasher := NewArgon2id(1024, 1, 1)
+
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, hasher.Supports(hash))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	assert.NoError(t, hasher.Verify("password123", hash))
+	assert.ErrorIs(t, hasher.Verify("wrong", hash), ErrMismatch)
+
+	// 相同密码每次使用不同的盐
+	other, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestArgon2id_VerifiesWithStoredParameters(t *testing.T) {
+	old := NewArgon2id(1024, 1, 1)
+	hash, err := old.Hash("password123")
+	require.NoError(t, err)
+
+	// 参数调整后旧哈希仍能校验，但需要重新计算
+	current := NewArgon2id(2048, 2, 1)
+	assert.NoError(t, current.Verify("password123", hash))
+	assert.True(t, current.NeedsRehash(hash))
+}
+
+func TestArgon2id_InvalidHash(t *testing.T) {
+	hasher := NewArgon2id(1024, 1, 1)
+
+	assert.ErrorIs(t, hasher.Verify("password123", "$2a$10$abc"), ErrUnsupportedHash)
+	assert.ErrorIs(t, hasher.Verify("password123", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"), ErrInvalidHash)
+	assert.ErrorIs(t, hasher.Verify("password123", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"), ErrInvalidHash)
+	assert.ErrorIs(t, hasher.Verify("password123", "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5"), ErrInvalidHash)
+}
+
+func TestBcrypt_NeedsRehashOnCostChange(t *testing.T) {
+	hasher := NewBcrypt(bcrypt.MinCost)
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+
+	assert.NoError(t, hasher.Verify("password123", hash))
+	assert.ErrorIs(t, hasher.Verify("wrong", hash), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(hash))
+}
+
+func TestMulti_VerifiesLegacyHashes(t *testing.T) {
+	argon := NewArgon2id(1024, 1, 1)
+	legacy := NewBcrypt(bcrypt.MinCost)
+	hasher := NewMulti(argon, legacy)
+
+	bcryptHash, err := legacy.Hash("password123")
+	require.NoError(t, err)
+	assert.NoError(t, hasher.Verify("password123", bcryptHash))
+	assert.ErrorIs(t, hasher.Verify("wrong", bcryptHash), ErrMismatch)
+	assert.True(t, hasher.NeedsRehash(bcryptHash))
+
+	// 新哈希使用当前算法
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, argon.Supports(hash))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	assert.ErrorIs(t, hasher.Verify("password123", "plaintext"), ErrUnsupportedHash)
+	assert.False(t, hasher.Supports("plaintext"))
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword 密码不符合强度策略，错误信息包含具体原因
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// Policy 密码强度策略
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// breached 泄露密码列表，按小写比较
+	breached map[string]struct{}
+}
+
+// LoadBreachedList 从文件加载泄露密码列表，每行一个密码，忽略空行和#开头的行
+func (p *Policy) LoadBreachedList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	p.breached = breached
+	return nil
+}
+
+// BreachedCount 已加载的泄露密码数量
+func (p *Policy) BreachedCount() int {
+	return len(p.breached)
+}
+
+// Check 检查密码强度，userInputs为用户名、邮箱等，密码中不能包含这些值
+func (p *Policy) Check(password string, userInputs ...string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return fmt.Errorf("%w: must contain an uppercase letter", ErrWeakPassword)
+	case p.RequireLower && !lower:
+		return fmt.Errorf("%w: must contain a lowercase letter", ErrWeakPassword)
+	case p.RequireDigit && !digit:
+		return fmt.Errorf("%w: must contain a digit", ErrWeakPassword)
+	case p.RequireSymbol && !symbol:
+		return fmt.Errorf("%w: must contain a symbol", ErrWeakPassword)
+	}
+
+	lowered := strings.ToLower(password)
+	for _, input := range userInputs {
+		// 邮箱只比较@之前的部分
+		input, _, _ = strings.Cut(strings.ToLower(input), "@")
+		if len(input) >= 3 && strings.Contains(lowered, input) {
+			return fmt.Errorf("%w: must not contain the username or email", ErrWeakPassword)
+		}
+	}
+
+	if _, ok := p.breached[lowered]; ok {
+		return fmt.Errorf("%w: has appeared in a data breach", ErrWeakPassword)
+	}
+	return nil
+}
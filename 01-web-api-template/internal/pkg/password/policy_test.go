@@ -0,0 +1,54 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	policy := &Policy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "Correct-Horse1", false},
+		{"too short", "Aa1-", true},
+		{"no upper", "correct-horse1", true},
+		{"no lower", "CORRECT-HORSE1", true},
+		{"no digit", "Correct-Horse", true},
+		{"no symbol", "CorrectHorse1", true},
+		{"contains username", "Alice-Secret1", true},
+		{"contains email local part", "Secret-Bob-123", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password, "alice", "bob@example.com")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrWeakPassword)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPolicy_BreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# 常见密码\npassword123\n\nQwerty123\n"), 0o644))
+
+	policy := &Policy{MinLength: 6}
+	require.NoError(t, policy.LoadBreachedList(path))
+	assert.Equal(t, 2, policy.BreachedCount())
+
+	assert.ErrorIs(t, policy.Check("Password123"), ErrWeakPassword)
+	assert.ErrorIs(t, policy.Check("qwerty123"), ErrWeakPassword)
+	assert.NoError(t, policy.Check("correct horse"))
+
+	assert.Error(t, policy.LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")))
+}
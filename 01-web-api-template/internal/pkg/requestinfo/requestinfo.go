@@ -0,0 +1,38 @@
+package requestinfo
+
+import (
+	"context"
+)
+
+// Info 当前请求的客户端信息
+type Info struct {
+	RequestID string
+	IP        string
+	UserAgent string
+
+	// 认证通过后由JWT中间件填充
+	UserID   uint
+	Username string
+}
+
+// contextKey 上下文键类型，避免与其他包冲突
+type contextKey struct{}
+
+// NewContext 返回携带请求信息的上下文
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext 从上下文获取请求信息，不存在时返回零值
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
+
+// WithUser 返回填充了当前认证用户的上下文
+func WithUser(ctx context.Context, userID uint, username string) context.Context {
+	info := FromContext(ctx)
+	info.UserID = userID
+	info.Username = username
+	return NewContext(ctx, info)
+}
@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FieldName 模型中标识所属租户的字段，包含该字段的模型自动按租户隔离
+const FieldName = "TenantID"
+
+// RegisterCallbacks 注册租户隔离回调：
+// 查询、更新、删除自动追加 tenant_id 条件，创建时填充租户ID并拒绝写入其他租户的数据。
+// 上下文中没有租户时返回 ErrTenantRequired，需要跨租户访问时使用 Unscoped
+func RegisterCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeQuery); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", scopeQuery); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeQuery); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeQuery); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenant:create", assignTenant)
+}
+
+// tenantField 返回模型的租户字段，模型不区分租户时返回nil
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(FieldName)
+}
+
+// currentTenant 获取语句上下文中的租户，skip为true表示无需限定
+func currentTenant(db *gorm.DB) (tenantID uint, skip bool) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if IsUnscoped(ctx) {
+		return 0, true
+	}
+	tenantID, ok := FromContext(ctx)
+	if !ok {
+		db.AddError(ErrTenantRequired)
+		return 0, true
+	}
+	return tenantID, false
+}
+
+// scopeQuery 为租户数据的查询、更新和删除追加租户条件
+func scopeQuery(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+	tenantID, skip := currentTenant(db)
+	if skip {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+// assignTenant 创建租户数据时填充租户ID，已指定其他租户时拒绝写入
+func assignTenant(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+	tenantID, skip := currentTenant(db)
+	if skip {
+		return
+	}
+
+	ctx := db.Statement.Context
+	assign := func(value reflect.Value) {
+		current, zero := field.ValueOf(ctx, value)
+		if zero {
+			if err := field.Set(ctx, value, tenantID); err != nil {
+				db.AddError(err)
+			}
+			return
+		}
+		if id, ok := current.(uint); !ok || id != tenantID {
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			assign(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		assign(value)
+	}
+}
@@ -0,0 +1,42 @@
+// Package tenant 在上下文中传递当前租户，并通过GORM回调自动限定租户数据的查询范围
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrTenantRequired 查询租户数据时上下文中没有租户
+	ErrTenantRequired = errors.New("tenant is required for tenant scoped query")
+	// ErrTenantMismatch 写入的数据不属于上下文中的租户
+	ErrTenantMismatch = errors.New("record belongs to another tenant")
+)
+
+// contextKey 上下文键类型，避免与其他包冲突
+type contextKey struct{}
+
+// unscopedKey 跨租户访问标记的上下文键
+type unscopedKey struct{}
+
+// WithTenant 返回携带租户ID的上下文
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext 从上下文获取租户ID
+func FromContext(ctx context.Context) (uint, bool) {
+	tenantID, ok := ctx.Value(contextKey{}).(uint)
+	return tenantID, ok && tenantID != 0
+}
+
+// Unscoped 返回不限定租户的上下文，仅用于后台任务、迁移等需要跨租户访问的场景
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// IsUnscoped 上下文是否允许跨租户访问
+func IsUnscoped(ctx context.Context) bool {
+	unscoped, _ := ctx.Value(unscopedKey{}).(bool)
+	return unscoped
+}
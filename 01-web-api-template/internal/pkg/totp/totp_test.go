@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret RFC 6238 附录B中SHA1测试向量使用的密钥
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAt_RFC6238Vectors(t *testing.T) {
+	// RFC给出的是8位验证码，6位验证码为其后6位
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := CodeAt(rfcSecret, unix/Period)
+		require.NoError(t, err)
+		assert.Equal(t, expected[2:], code, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	code, err := CodeAt(secret, Step(now))
+	require.NoError(t, err)
+
+	step, ok := Validate(secret, code, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// 允许一个时间步的时钟偏移
+	_, ok = Validate(secret, code, now.Add(Period*time.Second), 0)
+	assert.True(t, ok)
+	_, ok = Validate(secret, code, now.Add(3*Period*time.Second), 0)
+	assert.False(t, ok)
+
+	// 已使用过的时间步不能重放
+	_, ok = Validate(secret, code, now, step)
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("My App", "alice", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/My%20App:alice?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=My+App")
+}
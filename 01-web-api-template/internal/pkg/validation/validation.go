@@ -0,0 +1,207 @@
+// Package validation 将请求绑定和校验错误转换为按语言本地化的字段级错误信息
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// 支持的语言
+const (
+	LocaleEN = "en"
+	LocaleZH = "zh"
+)
+
+// DefaultLocale 未配置时使用的语言
+const DefaultLocale = LocaleEN
+
+// FieldError 单个字段的校验错误，字段名使用JSON（或查询参数）名称
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// messages 非校验规则类错误的本地化文案
+var messages = map[string]map[string]string{
+	LocaleEN: {
+		"validation_failed": "Validation failed",
+		"invalid_request":   "Malformed request",
+		"invalid_type":      "{0} must be a valid {1}",
+		"type_string":       "string",
+		"type_number":       "number",
+		"type_boolean":      "boolean",
+		"type_array":        "array",
+		"type_object":       "object",
+	},
+	LocaleZH: {
+		"validation_failed": "请求参数校验失败",
+		"invalid_request":   "请求格式错误",
+		"invalid_type":      "{0}必须是有效的{1}",
+		"type_string":       "字符串",
+		"type_number":       "数字",
+		"type_boolean":      "布尔值",
+		"type_array":        "数组",
+		"type_object":       "对象",
+	},
+}
+
+var universal = ut.New(en.New(), en.New(), zh.New())
+
+// sharedTranslator 多个校验器共用同一组翻译文案，重复注册时覆盖而不是报冲突，
+// 例如Gin的校验器和批量导入使用的校验器
+type sharedTranslator struct {
+	ut.Translator
+}
+
+// Add 添加翻译，已存在时覆盖
+func (t sharedTranslator) Add(key interface{}, text string, _ bool) error {
+	return t.Translator.Add(key, text, true)
+}
+
+// AddCardinal 添加基数复数翻译，已存在时覆盖
+func (t sharedTranslator) AddCardinal(key interface{}, text string, rule locales.PluralRule, _ bool) error {
+	return t.Translator.AddCardinal(key, text, rule, true)
+}
+
+// AddOrdinal 添加序数复数翻译，已存在时覆盖
+func (t sharedTranslator) AddOrdinal(key interface{}, text string, rule locales.PluralRule, _ bool) error {
+	return t.Translator.AddOrdinal(key, text, rule, true)
+}
+
+// AddRange 添加范围复数翻译，已存在时覆盖
+func (t sharedTranslator) AddRange(key interface{}, text string, rule locales.PluralRule, _ bool) error {
+	return t.Translator.AddRange(key, text, rule, true)
+}
+
+// translator 指定语言的翻译器，注册和翻译必须使用同一个值
+func translator(locale string) ut.Translator {
+	trans, _ := universal.GetTranslator(locale)
+	return sharedTranslator{trans}
+}
+
+// Register 为校验器注册中英文翻译，并使用json/form标签作为错误中的字段名，可对多个校验器调用
+func Register(v *validator.Validate) error {
+	v.RegisterTagNameFunc(fieldName)
+
+	if err := en_translations.RegisterDefaultTranslations(v, translator(LocaleEN)); err != nil {
+		return err
+	}
+	return zh_translations.RegisterDefaultTranslations(v, translator(LocaleZH))
+}
+
+// Translate 将绑定错误转换为本地化的摘要和字段错误列表
+func Translate(err error, locale string) (string, []FieldError) {
+	locale = normalize(locale)
+	text := messages[locale]
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		trans := translator(locale)
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, FieldError{Field: fe.Field(), Message: fe.Translate(trans)})
+		}
+		return text["validation_failed"], fields
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return text["validation_failed"], []FieldError{{
+			Field:   typeError.Field,
+			Message: format(text["invalid_type"], typeError.Field, text[jsonType(typeError.Type.Kind())]),
+		}}
+	}
+
+	// 请求体为空、JSON语法错误、查询参数无法解析等
+	return text["invalid_request"], nil
+}
+
+// ResolveLocale 根据Accept-Language选择支持的语言，无匹配时使用fallback
+func ResolveLocale(acceptLanguage, fallback string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := messages[primary]; ok && q > 0 {
+			candidates = append(candidates, candidate{locale: primary, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return normalize(fallback)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// normalize 不支持的语言回退到默认语言
+func normalize(locale string) string {
+	if _, ok := messages[locale]; ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// fieldName 错误中的字段名优先使用json标签，其次使用form标签
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// jsonType 返回Go类型对应的JSON类型文案键
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "type_string"
+	case reflect.Bool:
+		return "type_boolean"
+	case reflect.Slice, reflect.Array:
+		return "type_array"
+	case reflect.Struct, reflect.Map:
+		return "type_object"
+	default:
+		return "type_number"
+	}
+}
+
+// format 替换文案中的 {0}、{1} 占位符
+func format(message string, args ...string) string {
+	for i, arg := range args {
+		message = strings.ReplaceAll(message, "{"+strconv.Itoa(i)+"}", arg)
+	}
+	return message
+}
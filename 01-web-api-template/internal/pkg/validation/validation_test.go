@@ -0,0 +1,90 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signupRequest struct {
+	Username string `json:"username" binding:"required,min=3"`
+	Email    string `json:"email" binding:"required,email"`
+	Age      int    `json:"age"`
+}
+
+func newValidator(t *testing.T) *validator.Validate {
+	t.Helper()
+
+	v := validator.New()
+	v.SetTagName("binding")
+	require.NoError(t, Register(v))
+	return v
+}
+
+func TestTranslate_ValidationErrors(t *testing.T) {
+	v := newValidator(t)
+	err := v.Struct(signupRequest{Username: "ab", Email: "not-an-email"})
+	require.Error(t, err)
+
+	message, fields := Translate(err, LocaleEN)
+	assert.Equal(t, "Validation failed", message)
+	require.Len(t, fields, 2)
+	assert.Equal(t, FieldError{Field: "username", Message: "username must be at least 3 characters in length"}, fields[0])
+	assert.Equal(t, "email", fields[1].Field)
+
+	message, fields = Translate(err, LocaleZH)
+	assert.Equal(t, "请求参数校验失败", message)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "username", fields[0].Field)
+	assert.Equal(t, "username长度必须至少为3个字符", fields[0].Message)
+}
+
+func TestRegister_MultipleValidators(t *testing.T) {
+	// 共用翻译文案的多个校验器都能得到本地化的错误信息
+	for i := 0; i < 2; i++ {
+		v := newValidator(t)
+		err := v.Struct(signupRequest{Username: "ab", Email: "alice@example.com"})
+		require.Error(t, err)
+
+		_, fields := Translate(err, LocaleEN)
+		require.Len(t, fields, 1)
+		assert.Equal(t, "username must be at least 3 characters in length", fields[0].Message)
+	}
+}
+
+func TestTranslate_DecodeErrors(t *testing.T) {
+	var req signupRequest
+	err := json.Unmarshal([]byte(`{"age": "ten"}`), &req)
+
+	message, fields := Translate(err, LocaleZH)
+	assert.Equal(t, "请求参数校验失败", message)
+	assert.Equal(t, []FieldError{{Field: "age", Message: "age必须是有效的数字"}}, fields)
+
+	err = json.Unmarshal([]byte(`{`), &req)
+	message, fields = Translate(err, "fr")
+	assert.Equal(t, "Malformed request", message)
+	assert.Empty(t, fields)
+}
+
+func TestResolveLocale(t *testing.T) {
+	tests := []struct {
+		header   string
+		fallback string
+		want     string
+	}{
+		{"", LocaleZH, LocaleZH},
+		{"zh-CN,zh;q=0.9,en;q=0.8", LocaleEN, LocaleZH},
+		{"en-US,en;q=0.9", LocaleZH, LocaleEN},
+		{"fr-FR, en;q=0.5, zh;q=0.7", LocaleEN, LocaleZH},
+		{"fr-FR", LocaleZH, LocaleZH},
+		{"zh;q=0, en", LocaleZH, LocaleEN},
+		{"", "fr", DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ResolveLocale(tt.header, tt.fallback), tt.header)
+	}
+}
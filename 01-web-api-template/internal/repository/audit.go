@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// AuditFilter 审计日志查询条件，零值字段不参与过滤
+type AuditFilter struct {
+	Action     string
+	ActorID    uint
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditRepository 审计日志数据访问接口，不提供修改和删除
+type AuditRepository interface {
+	CreateBatch(ctx context.Context, logs []model.AuditLog) error
+	Query(ctx context.Context, filter AuditFilter, limit, offset int) ([]model.AuditLog, int64, error)
+	Iterate(ctx context.Context, filter AuditFilter, fn func(log *model.AuditLog) error) error
+}
+
+// auditRepository 审计日志数据访问实现
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository 创建审计日志数据访问实例
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// CreateBatch 批量写入审计日志
+func (r *auditRepository) CreateBatch(ctx context.Context, logs []model.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(&logs).Error
+}
+
+// Query 分页查询审计日志，返回当前页和总数
+func (r *auditRepository) Query(ctx context.Context, filter AuditFilter, limit, offset int) ([]model.AuditLog, int64, error) {
+	var total int64
+	if err := r.filtered(ctx, filter).Model(&model.AuditLog{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []model.AuditLog
+	err := r.filtered(ctx, filter).Order("id DESC").Limit(limit).Offset(offset).Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// Iterate 逐行遍历符合条件的审计日志，用于流式导出
+func (r *auditRepository) Iterate(ctx context.Context, filter AuditFilter, fn func(log *model.AuditLog) error) error {
+	db := conn(ctx, r.db)
+	rows, err := r.filtered(ctx, filter).Model(&model.AuditLog{}).Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log model.AuditLog
+		if err := db.ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filtered 根据查询条件构建查询
+func (r *auditRepository) filtered(ctx context.Context, filter AuditFilter) *gorm.DB {
+	query := conn(ctx, r.db)
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
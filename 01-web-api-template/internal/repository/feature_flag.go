@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeatureFlagRepository 功能开关数据访问接口
+type FeatureFlagRepository interface {
+	List(ctx context.Context) ([]model.FeatureFlag, error)
+	Save(ctx context.Context, flag *model.FeatureFlag) error
+	Delete(ctx context.Context, key string) error
+}
+
+// featureFlagRepository 功能开关数据访问实现
+type featureFlagRepository struct {
+	db *gorm.DB
+}
+
+// NewFeatureFlagRepository 创建功能开关数据访问实例
+func NewFeatureFlagRepository(db *gorm.DB) FeatureFlagRepository {
+	return &featureFlagRepository{db: db}
+}
+
+// List 按键名获取全部开关
+func (r *featureFlagRepository) List(ctx context.Context) ([]model.FeatureFlag, error) {
+	var flags []model.FeatureFlag
+	if err := r.db.WithContext(ctx).Order("flag_key").Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Save 按键名创建或更新开关
+func (r *featureFlagRepository) Save(ctx context.Context, flag *model.FeatureFlag) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "flag_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "description", "enabled", "rollout", "user_ids", "roles", "updated_by"}),
+	}).Create(flag).Error
+}
+
+// Delete 删除开关，不存在时返回 gorm.ErrRecordNotFound
+func (r *featureFlagRepository) Delete(ctx context.Context, key string) error {
+	result := r.db.WithContext(ctx).Where("flag_key = ?", key).Delete(&model.FeatureFlag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
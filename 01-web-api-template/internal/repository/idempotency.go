@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository 幂等请求记录数据访问接口
+type IdempotencyRepository interface {
+	CreateIfAbsent(ctx context.Context, record *model.IdempotencyRecord) (bool, error)
+	Get(ctx context.Context, key string, userID uint, method, route string) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, id uint, statusCode int, contentType string, body []byte) error
+	Delete(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// idempotencyRepository 幂等请求记录数据访问实现
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository 创建幂等请求记录数据访问实例
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// CreateIfAbsent 记录不存在时创建，依赖唯一索引保证并发下只有一个请求创建成功
+func (r *idempotencyRepository) CreateIfAbsent(ctx context.Context, record *model.IdempotencyRecord) (bool, error) {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Get 根据幂等键、用户和路由获取记录
+func (r *idempotencyRepository) Get(ctx context.Context, key string, userID uint, method, route string) (*model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	err := conn(ctx, r.db).Where("idempotency_key = ? AND user_id = ? AND method = ? AND route = ?", key, userID, method, route).
+		First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete 保存请求的响应
+func (r *idempotencyRepository) Complete(ctx context.Context, id uint, statusCode int, contentType string, body []byte) error {
+	return conn(ctx, r.db).Model(&model.IdempotencyRecord{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"body":         body,
+	}).Error
+}
+
+// Delete 删除记录
+func (r *idempotencyRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&model.IdempotencyRecord{}, id).Error
+}
+
+// DeleteExpired 删除已过期的记录
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("expires_at <= ?", now).Delete(&model.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository 第三方身份数据访问接口，按上下文中的租户隔离
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	ListByUserID(ctx context.Context, userID uint) ([]model.UserIdentity, error)
+}
+
+// identityRepository 第三方身份数据访问实现
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository 创建第三方身份数据访问实例
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// Create 创建身份绑定
+func (r *identityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return conn(ctx, r.db).Create(identity).Error
+}
+
+// GetByProviderSubject 根据提供方和主体标识获取当前租户的身份绑定
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := conn(ctx, r.db).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ListByUserID 获取用户的全部身份绑定
+func (r *identityRepository) ListByUserID(ctx context.Context, userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
\ No newline at end of file
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository 二次验证恢复码数据访问接口
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uint, codeHashes []string) error
+	Consume(ctx context.Context, userID uint, codeHash string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// recoveryCodeRepository 二次验证恢复码数据访问实现
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository 创建恢复码数据访问实例
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// Replace 删除用户的旧恢复码并写入新的恢复码
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uint, codeHashes []string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.MFARecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.MFARecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume 将未使用的恢复码标记为已使用，返回是否成功
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	// 条件更新保证并发请求下同一恢复码只会成功一次
+	result := conn(ctx, r.db).Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteByUserID 删除用户的全部恢复码
+func (r *recoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// OutboxRepository 领域事件发件箱数据访问接口，在事务上下文中调用时使用该事务
+type OutboxRepository interface {
+	Create(ctx context.Context, event *model.OutboxEvent) error
+	ListPending(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkDispatched(ctx context.Context, ids []uint, at time.Time) error
+	GetByIDs(ctx context.Context, ids []uint) ([]model.OutboxEvent, error)
+}
+
+// outboxRepository 领域事件发件箱数据访问实现
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository 创建领域事件发件箱数据访问实例
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Create 写入事件
+func (r *outboxRepository) Create(ctx context.Context, event *model.OutboxEvent) error {
+	return conn(ctx, r.db).Create(event).Error
+}
+
+// ListPending 按写入顺序获取尚未分发的事件
+func (r *outboxRepository) ListPending(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := conn(ctx, r.db).Where("dispatched_at IS NULL").Order("id").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MarkDispatched 标记事件已分发
+func (r *outboxRepository) MarkDispatched(ctx context.Context, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Model(&model.OutboxEvent{}).
+		Where("id IN ? AND dispatched_at IS NULL", ids).
+		Update("dispatched_at", at).Error
+}
+
+// GetByIDs 根据ID批量获取事件
+func (r *outboxRepository) GetByIDs(ctx context.Context, ids []uint) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	if len(ids) == 0 {
+		return events, nil
+	}
+	if err := conn(ctx, r.db).Where("id IN ?", ids).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话数据访问接口
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByTokenID(ctx context.Context, tokenID string) (*model.Session, error)
+	ListActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]model.Session, error)
+	Revoke(ctx context.Context, userID, sessionID uint, at time.Time) (bool, error)
+	RevokeAll(ctx context.Context, userID uint, at time.Time) error
+	Touch(ctx context.Context, sessionID uint, at time.Time) error
+}
+
+// sessionRepository 登录会话数据访问实现
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建登录会话数据访问实例
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create 创建会话
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	return conn(ctx, r.db).Create(session).Error
+}
+
+// GetByTokenID 根据令牌ID获取会话
+func (r *sessionRepository) GetByTokenID(ctx context.Context, tokenID string) (*model.Session, error) {
+	var session model.Session
+	err := conn(ctx, r.db).Where("token_id = ?", tokenID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveByUserID 获取用户未注销且未过期的会话，最近活跃的在前
+func (r *sessionRepository) ListActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := conn(ctx, r.db).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Revoke 注销用户的指定会话，返回是否找到可注销的会话
+func (r *sessionRepository) Revoke(ctx context.Context, userID, sessionID uint, at time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&model.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeAll 注销用户的全部会话
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID uint, at time.Time) error {
+	return conn(ctx, r.db).Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// Touch 更新会话最后活跃时间
+func (r *sessionRepository) Touch(ctx context.Context, sessionID uint, at time.Time) error {
+	return conn(ctx, r.db).Model(&model.Session{}).Where("id = ?", sessionID).Update("last_seen_at", at).Error
+}
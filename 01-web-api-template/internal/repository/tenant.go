@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// TenantRepository 租户数据访问接口
+type TenantRepository interface {
+	Create(tenant *model.Tenant) error
+	GetBySlug(slug string) (*model.Tenant, error)
+	List() ([]model.Tenant, error)
+}
+
+// tenantRepository 租户数据访问实现
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantRepository 创建租户数据访问实例
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+// Create 创建租户
+func (r *tenantRepository) Create(tenant *model.Tenant) error {
+	return r.db.Create(tenant).Error
+}
+
+// GetBySlug 根据标识获取租户
+func (r *tenantRepository) GetBySlug(slug string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	err := r.db.Where("slug = ?", slug).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// List 获取全部租户
+func (r *tenantRepository) List() ([]model.Tenant, error) {
+	var tenants []model.Tenant
+	if err := r.db.Order("id").Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txKey 事务的上下文键
+type txKey struct{}
+
+// afterCommitKey 事务提交后回调的上下文键
+type afterCommitKey struct{}
+
+// afterCommitHooks 最外层事务提交后执行的回调
+type afterCommitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// Transactor 在一个数据库事务中执行多个仓库操作
+type Transactor interface {
+	// Transaction 开启事务并以携带事务的上下文调用fn，fn返回错误时回滚。
+	// 支持事务感知的仓库（用户、事件发件箱）在该上下文中的操作都在同一事务内
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// transactor 基于GORM的事务实现
+type transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor 创建事务执行器
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// Transaction 开启事务，已在事务中时使用保存点嵌套；
+// 最外层事务提交后执行事务中登记的 afterCommit 回调
+func (t *transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTransaction(ctx) {
+		return conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	}
+
+	hooks := &afterCommitHooks{}
+	ctx = context.WithValue(ctx, afterCommitKey{}, hooks)
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil {
+		return err
+	}
+	hooks.mu.Lock()
+	fns := hooks.fns
+	hooks.mu.Unlock()
+	for _, f := range fns {
+		f()
+	}
+	return nil
+}
+
+// inTransaction 上下文是否携带事务
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// afterCommit 在事务中时登记提交后执行的fn，事务回滚时不执行；不在事务中时立即执行
+func afterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks)
+	if !ok || !inTransaction(ctx) {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
+
+// conn 返回上下文中的事务，不在事务中时返回db，两者都绑定ctx
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -1,19 +1,32 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"go-practical-roadmap/01-web-api-template/internal/model"
 	"gorm.io/gorm"
 )
 
-// UserRepository 用户数据访问接口
+// UserRepository 用户数据访问接口，查询范围由上下文中的租户限定（见 tenant.RegisterCallbacks），
+// 在 Transactor 开启的事务上下文中调用时使用该事务
 type UserRepository interface {
-	Create(user *model.User) error
-	GetByID(id uint) (*model.User, error)
-	GetByUsername(username string) (*model.User, error)
-	GetByEmail(email string) (*model.User, error)
-	Update(user *model.User) error
-	Delete(id uint) error
-	List(limit, offset int) ([]model.User, error)
+	Create(ctx context.Context, user *model.User) error
+	CreateBatch(ctx context.Context, users []model.User) error
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, limit, offset int) ([]model.User, error)
+	Iterate(ctx context.Context, fn func(user *model.User) error) error
+	GetDeletedByID(ctx context.Context, id uint) (*model.User, error)
+	ListDeleted(ctx context.Context, limit, offset int) ([]model.User, int64, error)
+	Restore(ctx context.Context, id uint) error
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) ([]model.User, error)
+	UpdateMFAStep(ctx context.Context, id uint, step int64) (bool, error)
+	RecordMFAFailure(ctx context.Context, id uint, maxAttempts int, lockUntil time.Time) (bool, error)
+	ResetMFAFailures(ctx context.Context, id uint) error
 }
 
 // userRepository 用户数据访问实现
@@ -27,14 +40,24 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 // Create 创建用户
-func (r *userRepository) Create(user *model.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *model.User) error {
+	return conn(ctx, r.db).Create(user).Error
+}
+
+// CreateBatch 在一个事务中批量创建用户，任一用户失败时全部回滚
+func (r *userRepository) CreateBatch(ctx context.Context, users []model.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&users).Error
+	})
 }
 
 // GetByID 根据ID获取用户
-func (r *userRepository) GetByID(id uint) (*model.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := conn(ctx, r.db).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -42,9 +65,9 @@ func (r *userRepository) GetByID(id uint) (*model.User, error) {
 }
 
 // GetByUsername 根据用户名获取用户
-func (r *userRepository) GetByUsername(username string) (*model.User, error) {
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := conn(ctx, r.db).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -52,9 +75,9 @@ func (r *userRepository) GetByUsername(username string) (*model.User, error) {
 }
 
 // GetByEmail 根据邮箱获取用户
-func (r *userRepository) GetByEmail(email string) (*model.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -62,21 +85,153 @@ func (r *userRepository) GetByEmail(email string) (*model.User, error) {
 }
 
 // Update 更新用户
-func (r *userRepository) Update(user *model.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	return conn(ctx, r.db).Save(user).Error
 }
 
-// Delete 删除用户
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
+// Delete 软删除用户，同时释放其用户名和邮箱
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", id).Update("deleted_id", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, id).Error
+	})
 }
 
 // List 获取用户列表
-func (r *userRepository) List(limit, offset int) ([]model.User, error) {
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]model.User, error) {
+	var users []model.User
+	err := conn(ctx, r.db).Limit(limit).Offset(offset).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Iterate 按ID顺序逐行遍历用户，用于流式导出
+func (r *userRepository) Iterate(ctx context.Context, fn func(user *model.User) error) error {
+	rows, err := conn(ctx, r.db).Model(&model.User{}).Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+		if err := conn(ctx, r.db).ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetDeletedByID 根据ID获取已软删除的用户
+func (r *userRepository) GetDeletedByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	err := conn(ctx, r.db).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListDeleted 按删除时间倒序分页获取已软删除的用户
+func (r *userRepository) ListDeleted(ctx context.Context, limit, offset int) ([]model.User, int64, error) {
+	query := conn(ctx, r.db).Unscoped().Model(&model.User{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []model.User
+	if err := query.Order("deleted_at DESC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Restore 恢复已软删除的用户
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	result := conn(ctx, r.db).Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_id": 0})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted 永久删除在指定时间之前软删除的用户及其关联数据，每次最多处理limit个，
+// 返回被删除的用户，供调用方清理头像等数据库之外的数据
+func (r *userRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) ([]model.User, error) {
 	var users []model.User
-	err := r.db.Limit(limit).Offset(offset).Find(&users).Error
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Order("id").Limit(limit).Find(&users).Error
+		if err != nil || len(users) == 0 {
+			return err
+		}
+
+		ids := make([]uint, 0, len(users))
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+
+		// 审计日志只追加，不随用户删除
+		for _, dependent := range []interface{}{&model.UserIdentity{}, &model.MFARecoveryCode{}, &model.Session{}} {
+			if err := tx.Where("user_id IN ?", ids).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&model.User{}).Error
+	})
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
-}
\ No newline at end of file
+}
+
+// UpdateMFAStep 记录已使用的TOTP时间步，仅当step大于已记录的时间步时更新，返回是否更新成功。
+// 条件更新保证并发请求下同一验证码只会成功一次
+func (r *userRepository) UpdateMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ? AND mfa_last_step < ?", id, step).
+		Update("mfa_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordMFAFailure 累加二次验证失败次数，达到maxAttempts时清零并锁定到lockUntil，返回是否被锁定
+func (r *userRepository) RecordMFAFailure(ctx context.Context, id uint, maxAttempts int, lockUntil time.Time) (bool, error) {
+	var locked bool
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", id).
+			Update("mfa_failed_attempts", gorm.Expr("mfa_failed_attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND mfa_failed_attempts >= ?", id, maxAttempts).
+			Updates(map[string]interface{}{"mfa_failed_attempts": 0, "mfa_locked_until": lockUntil})
+		locked = result.RowsAffected > 0
+		return result.Error
+	})
+	return locked, err
+}
+
+// ResetMFAFailures 验证成功后清除失败次数和锁定
+func (r *userRepository) ResetMFAFailures(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Model(&model.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"mfa_failed_attempts": 0, "mfa_locked_until": nil}).Error
+}
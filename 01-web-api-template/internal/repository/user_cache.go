@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/pkg/db"
+	"golang.org/x/sync/singleflight"
+)
+
+// cachedUserRepository 带读穿透缓存的用户数据访问实现
+// 缓存键包含租户ID；按ID保存用户，按用户名只保存对应的ID，读取时校验用户名仍然一致。
+// 要求读主库的查询和事务中的查询不经过缓存；未命中时从主库加载，避免把副本上的旧数据写入缓存
+type cachedUserRepository struct {
+	UserRepository
+	cache cache.Cache
+	group singleflight.Group
+
+	// mu 保护epoch与缓存写入的先后顺序；epoch在每次失效时递增，
+	// 加载期间发生过失效的查询结果可能是旧数据，不写入缓存
+	mu    sync.Mutex
+	epoch uint64
+}
+
+// NewCachedUserRepository 为用户数据访问增加缓存，Update、Delete等写操作会使对应条目失效
+func NewCachedUserRepository(repo UserRepository, c cache.Cache) UserRepository {
+	return &cachedUserRepository{UserRepository: repo, cache: c}
+}
+
+// GetByID 根据ID获取用户，并发的未命中请求合并为一次数据库查询
+func (r *cachedUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	// 跨租户访问、要求读主库的访问和事务中的访问不经过缓存
+	if r.bypass(ctx) {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+
+	key := userIDKey(ctx, id)
+	if value, ok := r.cache.Get(key); ok {
+		return copyUser(value.(*model.User)), nil
+	}
+
+	return r.load(ctx, key, func(ctx context.Context) (*model.User, error) {
+		return r.UserRepository.GetByID(ctx, id)
+	})
+}
+
+// GetByUsername 根据用户名获取用户
+func (r *cachedUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	if r.bypass(ctx) {
+		return r.UserRepository.GetByUsername(ctx, username)
+	}
+
+	key := usernameKey(ctx, username)
+	if value, ok := r.cache.Get(key); ok {
+		if user, ok := r.cache.Get(userIDKey(ctx, value.(uint))); ok && user.(*model.User).Username == username {
+			return copyUser(user.(*model.User)), nil
+		}
+	}
+
+	return r.load(ctx, key, func(ctx context.Context) (*model.User, error) {
+		return r.UserRepository.GetByUsername(ctx, username)
+	})
+}
+
+// Update 更新用户并使缓存失效
+func (r *cachedUserRepository) Update(ctx context.Context, user *model.User) error {
+	defer r.invalidate(ctx, user.ID, user.Username)
+	return r.UserRepository.Update(ctx, user)
+}
+
+// Delete 软删除用户并使缓存失效
+func (r *cachedUserRepository) Delete(ctx context.Context, id uint) error {
+	defer r.invalidate(ctx, id, "")
+	return r.UserRepository.Delete(ctx, id)
+}
+
+// Restore 恢复已软删除的用户并使缓存失效
+func (r *cachedUserRepository) Restore(ctx context.Context, id uint) error {
+	defer r.invalidate(ctx, id, "")
+	return r.UserRepository.Restore(ctx, id)
+}
+
+// PurgeDeleted 永久删除用户并使缓存失效
+func (r *cachedUserRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) ([]model.User, error) {
+	users, err := r.UserRepository.PurgeDeleted(ctx, before, limit)
+	for _, user := range users {
+		r.invalidate(ctx, user.ID, "")
+	}
+	return users, err
+}
+
+// UpdateMFAStep 记录已使用的TOTP时间步并使缓存失效
+func (r *cachedUserRepository) UpdateMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	defer r.invalidate(ctx, id, "")
+	return r.UserRepository.UpdateMFAStep(ctx, id, step)
+}
+
+// RecordMFAFailure 累加二次验证失败次数并使缓存失效
+func (r *cachedUserRepository) RecordMFAFailure(ctx context.Context, id uint, maxAttempts int, lockUntil time.Time) (bool, error) {
+	defer r.invalidate(ctx, id, "")
+	return r.UserRepository.RecordMFAFailure(ctx, id, maxAttempts, lockUntil)
+}
+
+// ResetMFAFailures 清除二次验证失败次数并使缓存失效
+func (r *cachedUserRepository) ResetMFAFailures(ctx context.Context, id uint) error {
+	defer r.invalidate(ctx, id, "")
+	return r.UserRepository.ResetMFAFailures(ctx, id)
+}
+
+// load 从主库加载用户并写入缓存，同一键的并发加载合并为一次查询。
+// 共享的查询使用不随调用方取消的上下文，保留租户和一致性等值；
+// 调用方的上下文取消时只结束自己的等待
+func (r *cachedUserRepository) load(ctx context.Context, key string, query func(ctx context.Context) (*model.User, error)) (*model.User, error) {
+	loadCtx := db.WithPrimary(context.WithoutCancel(ctx))
+	result := r.group.DoChan(key, func() (interface{}, error) {
+		epoch := r.currentEpoch()
+		user, err := query(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		r.store(loadCtx, user, epoch)
+		return user, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return copyUser(res.Val.(*model.User)), nil
+	}
+}
+
+// bypass 查询是否绕过缓存
+func (r *cachedUserRepository) bypass(ctx context.Context) bool {
+	return tenant.IsUnscoped(ctx) || db.UsesPrimary(ctx) || inTransaction(ctx)
+}
+
+// currentEpoch 返回当前的失效计数，在查询数据库前调用
+func (r *cachedUserRepository) currentEpoch() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.epoch
+}
+
+// store 保存用户副本，避免调用方修改返回值影响缓存内容；
+// 查询开始后发生过失效时放弃写入
+func (r *cachedUserRepository) store(ctx context.Context, user *model.User, epoch uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.epoch != epoch {
+		return
+	}
+	r.cache.Set(userIDKey(ctx, user.ID), copyUser(user))
+	r.cache.Set(usernameKey(ctx, user.Username), user.ID)
+}
+
+// invalidate 使用户的缓存条目失效。事务中的写操作立即失效一次，
+// 提交后再失效一次，清除提交前其他请求读到并写入的旧数据
+func (r *cachedUserRepository) invalidate(ctx context.Context, id uint, username string) {
+	if inTransaction(ctx) {
+		r.evict(ctx, id, username)
+	}
+	afterCommit(ctx, func() { r.evict(ctx, id, username) })
+}
+
+// evict 删除用户的缓存条目并递增失效计数，使进行中的查询不再写入缓存
+// 用户名索引读取时会校验用户名，修改前的用户名索引无需查找删除
+func (r *cachedUserRepository) evict(ctx context.Context, id uint, username string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.epoch++
+	key := userIDKey(ctx, id)
+	r.cache.Delete(key)
+	r.group.Forget(key)
+	if username != "" {
+		r.cache.Delete(usernameKey(ctx, username))
+		r.group.Forget(usernameKey(ctx, username))
+	}
+}
+
+// copyUser 复制用户结构体
+func copyUser(user *model.User) *model.User {
+	copied := *user
+	return &copied
+}
+
+// tenantPrefix 缓存键的租户前缀，跨租户访问时为0
+func tenantPrefix(ctx context.Context) string {
+	tenantID, _ := tenant.FromContext(ctx)
+	return "user:" + strconv.FormatUint(uint64(tenantID), 10)
+}
+
+// userIDKey 按ID缓存用户的键
+func userIDKey(ctx context.Context, id uint) string {
+	return tenantPrefix(ctx) + ":id:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// usernameKey 按用户名缓存用户ID的键
+func usernameKey(ctx context.Context, username string) string {
+	return tenantPrefix(ctx) + ":username:" + username
+}
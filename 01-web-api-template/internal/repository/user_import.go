@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// UserImportRepository 用户导入任务数据访问接口，任务按上下文中的租户隔离
+type UserImportRepository interface {
+	CreateJob(ctx context.Context, job *model.UserImportJob) error
+	GetJob(ctx context.Context, id uint) (*model.UserImportJob, error)
+	UpdateJob(ctx context.Context, job *model.UserImportJob) error
+	CreateErrors(rowErrors []model.UserImportError) error
+	ListErrors(jobID uint) ([]model.UserImportError, error)
+}
+
+// userImportRepository 用户导入任务数据访问实现
+type userImportRepository struct {
+	db *gorm.DB
+}
+
+// NewUserImportRepository 创建用户导入任务数据访问实例
+func NewUserImportRepository(db *gorm.DB) UserImportRepository {
+	return &userImportRepository{db: db}
+}
+
+// CreateJob 创建导入任务
+func (r *userImportRepository) CreateJob(ctx context.Context, job *model.UserImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// GetJob 根据ID获取导入任务
+func (r *userImportRepository) GetJob(ctx context.Context, id uint) (*model.UserImportJob, error) {
+	var job model.UserImportJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UpdateJob 更新导入任务的状态和统计
+func (r *userImportRepository) UpdateJob(ctx context.Context, job *model.UserImportJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// CreateErrors 批量写入行错误
+func (r *userImportRepository) CreateErrors(rowErrors []model.UserImportError) error {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(&rowErrors, 500).Error
+}
+
+// ListErrors 按行号获取导入任务的全部行错误，调用方需先确认任务属于当前租户
+func (r *userImportRepository) ListErrors(jobID uint) ([]model.UserImportError, error) {
+	var rowErrors []model.UserImportError
+	if err := r.db.Where("job_id = ?", jobID).Order("row, id").Find(&rowErrors).Error; err != nil {
+		return nil, err
+	}
+	return rowErrors, nil
+}
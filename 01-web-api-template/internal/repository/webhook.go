@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository Webhook订阅和投递记录数据访问接口，按上下文中的租户隔离
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	GetByID(ctx context.Context, id uint) (*model.Webhook, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]model.Webhook, error)
+	List(ctx context.Context) ([]model.Webhook, error)
+	ListActive(ctx context.Context) ([]model.Webhook, error)
+	Update(ctx context.Context, webhook *model.Webhook) error
+	Delete(ctx context.Context, id uint) error
+
+	CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	ClaimDelivery(ctx context.Context, delivery *model.WebhookDelivery, now, until time.Time) (bool, error)
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]model.WebhookDelivery, int64, error)
+}
+
+// webhookRepository Webhook数据访问实现
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建Webhook数据访问实例
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// Create 创建订阅
+func (r *webhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	return conn(ctx, r.db).Create(webhook).Error
+}
+
+// GetByID 根据ID获取订阅
+func (r *webhookRepository) GetByID(ctx context.Context, id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&webhook).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetByIDs 根据ID批量获取订阅
+func (r *webhookRepository) GetByIDs(ctx context.Context, ids []uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if len(ids) == 0 {
+		return webhooks, nil
+	}
+	if err := conn(ctx, r.db).Where("id IN ?", ids).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// List 按创建顺序获取全部订阅
+func (r *webhookRepository) List(ctx context.Context) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := conn(ctx, r.db).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// ListActive 获取启用的订阅
+func (r *webhookRepository) ListActive(ctx context.Context) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := conn(ctx, r.db).Where("active = ?", true).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Update 更新订阅
+func (r *webhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	return conn(ctx, r.db).Save(webhook).Error
+}
+
+// Delete 删除订阅，尚未完成的投递标记为失败，已有的投递日志保留
+func (r *webhookRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.Webhook{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("webhook_id = ? AND status = ?", id, model.DeliveryStatusPending).
+			Updates(map[string]interface{}{"status": model.DeliveryStatusFailed, "error": "webhook deleted"}).Error
+	})
+}
+
+// CreateDeliveries 批量创建投递记录，同一事件和订阅已存在记录时跳过
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// ListDueDeliveries 获取到期待投递的记录，停用或已删除订阅的投递暂不处理
+func (r *webhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := conn(ctx, r.db).
+		Joins("JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id").
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", model.DeliveryStatusPending, now).
+		Where("webhooks.active = ? AND webhooks.deleted_at IS NULL", true).
+		Order("webhook_deliveries.next_attempt_at, webhook_deliveries.id").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery 将到期的投递推迟到until，避免多个实例同时投递。返回false表示已被其他实例领取
+func (r *webhookRepository) ClaimDelivery(ctx context.Context, delivery *model.WebhookDelivery, now, until time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ?",
+			delivery.ID, model.DeliveryStatusPending, delivery.Attempts, now).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateDelivery 保存投递结果
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return conn(ctx, r.db).Save(delivery).Error
+}
+
+// ListDeliveries 按时间倒序分页获取订阅的投递记录
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]model.WebhookDelivery, int64, error) {
+	query := conn(ctx, r.db).Model(&model.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []model.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 审计事件类型
+const (
+	AuditUserRegister        = "user.register"
+	AuditUserLoginSuccess    = "user.login.success"
+	AuditUserLoginFailure    = "user.login.failure"
+	AuditUserPasswordChange  = "user.password.change"
+	AuditUserEmailChange     = "user.email.change"
+	AuditUserMFAEnable       = "user.mfa.enable"
+	AuditUserSessionRevoke   = "user.session.revoke"
+	AuditUserDeactivate      = "user.deactivate"
+	AuditAdminRoleChange     = "admin.user.role.change"
+	AuditAdminMFAReset       = "admin.user.mfa.reset"
+	AuditAdminPasswordReset  = "admin.user.password.reset"
+	AuditAdminUserDelete     = "admin.user.delete"
+	AuditAdminUserRestore    = "admin.user.restore"
+	AuditAdminUserReactivate = "admin.user.reactivate"
+	AuditUserPurge           = "user.purge"
+	AuditAdminUserImport     = "admin.user.import"
+	AuditAdminWebhookCreate  = "admin.webhook.create"
+	AuditAdminWebhookUpdate  = "admin.webhook.update"
+	AuditAdminWebhookDelete  = "admin.webhook.delete"
+	AuditAdminFlagUpdate     = "admin.flag.update"
+	AuditAdminFlagReset      = "admin.flag.reset"
+)
+
+// 审计目标类型
+const (
+	AuditTargetUser    = "user"
+	AuditTargetWebhook = "webhook"
+	AuditTargetFlag    = "flag"
+)
+
+// Change 字段变更前后的值
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEntry 待记录的审计事件，操作者、IP和请求ID从上下文中获取
+type AuditEntry struct {
+	Action     string
+	Success    bool
+	TargetType string
+	TargetID   string
+	Diff       map[string]interface{}
+}
+
+// AuditService 审计日志服务接口
+type AuditService interface {
+	Record(ctx context.Context, entry AuditEntry)
+	Query(ctx context.Context, query *dto.AuditQuery) (*dto.AuditListResponse, error)
+	Export(ctx context.Context, query *dto.AuditQuery, fn func(entry *dto.AuditLogResponse) error) error
+	Close() error
+}
+
+// auditService 审计日志服务实现，通过带缓冲的通道异步批量写入
+type auditService struct {
+	auditRepo     repository.AuditRepository
+	log           logger.Logger
+	batchSize     int
+	flushInterval time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	entries chan model.AuditLog
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// NewAuditService 创建审计日志服务实例并启动后台写入协程
+func NewAuditService(auditRepo repository.AuditRepository, cfg config.AuditConfig, log logger.Logger) AuditService {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1024
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	s := &auditService{
+		auditRepo:     auditRepo,
+		log:           log,
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		entries:       make(chan model.AuditLog, cfg.BufferSize),
+		done:          make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Record 记录审计事件，事件归属上下文中的租户。缓冲区已满时丢弃事件，不阻塞请求
+func (s *auditService) Record(ctx context.Context, entry AuditEntry) {
+	info := requestinfo.FromContext(ctx)
+	tenantID, _ := tenant.FromContext(ctx)
+	log := model.AuditLog{
+		CreatedAt:  time.Now(),
+		TenantID:   tenantID,
+		Action:     entry.Action,
+		Success:    entry.Success,
+		ActorName:  info.Username,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         info.IP,
+		RequestID:  info.RequestID,
+	}
+	if info.UserID != 0 {
+		actorID := info.UserID
+		log.ActorID = &actorID
+	}
+	if len(entry.Diff) > 0 {
+		diff, err := json.Marshal(entry.Diff)
+		if err != nil {
+			s.log.Warn("Failed to encode audit diff", zap.String("action", entry.Action), zap.Error(err))
+		} else {
+			log.Diff = string(diff)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.entries <- log:
+	default:
+		dropped := s.dropped.Add(1)
+		s.log.Warn("Audit buffer full, entry dropped",
+			zap.String("action", entry.Action),
+			zap.Int64("dropped_total", dropped))
+	}
+}
+
+// Query 按条件分页查询审计日志
+func (s *auditService) Query(ctx context.Context, query *dto.AuditQuery) (*dto.AuditListResponse, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	logs, total, err := s.auditRepo.Query(ctx, auditFilter(query), limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.AuditLogResponse, 0, len(logs))
+	for i := range logs {
+		items = append(items, toAuditLogResponse(&logs[i]))
+	}
+
+	return &dto.AuditListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// Export 流式遍历符合条件的全部审计日志（忽略分页参数）
+func (s *auditService) Export(ctx context.Context, query *dto.AuditQuery, fn func(entry *dto.AuditLogResponse) error) error {
+	return s.auditRepo.Iterate(ctx, auditFilter(query), func(log *model.AuditLog) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry := toAuditLogResponse(log)
+		return fn(&entry)
+	})
+}
+
+// Close 停止接收新事件并写入缓冲区中剩余的事件
+func (s *auditService) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.entries)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// run 后台协程：攒够一批或到达刷新间隔时写库。同一批事件可能属于不同租户，记录时已填充租户ID
+func (s *auditService) run() {
+	defer close(s.done)
+
+	ctx := tenant.Unscoped(context.Background())
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.AuditLog, 0, s.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.auditRepo.CreateBatch(ctx, batch); err != nil {
+			s.log.Error("Failed to write audit logs", zap.Int("count", len(batch)), zap.Error(err))
+		}
+		batch = make([]model.AuditLog, 0, s.batchSize)
+	}
+
+	for {
+		select {
+		case log, ok := <-s.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, log)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// auditFilter 将查询参数转换为仓库过滤条件
+func auditFilter(query *dto.AuditQuery) repository.AuditFilter {
+	return repository.AuditFilter{
+		Action:     query.Action,
+		ActorID:    query.ActorID,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+		From:       query.From,
+		To:         query.To,
+	}
+}
+
+// toAuditLogResponse 转换审计日志响应
+func toAuditLogResponse(log *model.AuditLog) dto.AuditLogResponse {
+	response := dto.AuditLogResponse{
+		ID:         log.ID,
+		CreatedAt:  log.CreatedAt,
+		Action:     log.Action,
+		Success:    log.Success,
+		ActorID:    log.ActorID,
+		ActorName:  log.ActorName,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		IP:         log.IP,
+		RequestID:  log.RequestID,
+	}
+	if log.Diff != "" {
+		response.Diff = json.RawMessage(log.Diff)
+	}
+	return response
+}
+
+// nopAuditService 不记录任何事件的审计服务，用于测试和工具命令
+type nopAuditService struct{}
+
+// NewNopAuditService 创建空实现的审计服务
+func NewNopAuditService() AuditService {
+	return nopAuditService{}
+}
+
+// Record 忽略事件
+func (nopAuditService) Record(ctx context.Context, entry AuditEntry) {}
+
+// Query 返回空结果
+func (nopAuditService) Query(ctx context.Context, query *dto.AuditQuery) (*dto.AuditListResponse, error) {
+	return &dto.AuditListResponse{Items: []dto.AuditLogResponse{}}, nil
+}
+
+// Export 不输出任何记录
+func (nopAuditService) Export(ctx context.Context, query *dto.AuditQuery, fn func(entry *dto.AuditLogResponse) error) error {
+	return nil
+}
+
+// Close 无需释放资源
+func (nopAuditService) Close() error {
+	return nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+)
+
+func TestAuditService_RecordAndQuery(t *testing.T) {
+	database := newTestDB(t)
+	auditService := NewAuditService(repository.NewAuditRepository(database), config.AuditConfig{
+		BufferSize:    16,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}, logger.NewNop())
+
+	ctx := requestinfo.NewContext(context.Background(), requestinfo.Info{RequestID: "req-1", IP: "203.0.113.10"})
+	ctx = requestinfo.WithUser(ctx, 1, "admin")
+
+	auditService.Record(ctx, AuditEntry{
+		Action:     AuditAdminRoleChange,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   "2",
+		Diff:       map[string]interface{}{"role": Change{Old: model.RoleUser, New: model.RoleAdmin}},
+	})
+	auditService.Record(context.Background(), AuditEntry{
+		Action:     AuditUserLoginFailure,
+		TargetType: AuditTargetUser,
+		Diff:       map[string]interface{}{"username": "ghost"},
+	})
+	auditService.Record(context.Background(), AuditEntry{Action: AuditUserLoginFailure, TargetType: AuditTargetUser})
+
+	// 关闭时写入未满一批的剩余事件
+	require.NoError(t, auditService.Close())
+
+	result, err := auditService.Query(context.Background(), &dto.AuditQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+
+	result, err = auditService.Query(context.Background(), &dto.AuditQuery{ActorID: 1})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+
+	entry := result.Items[0]
+	assert.Equal(t, AuditAdminRoleChange, entry.Action)
+	assert.Equal(t, "admin", entry.ActorName)
+	assert.Equal(t, "203.0.113.10", entry.IP)
+	assert.Equal(t, "req-1", entry.RequestID)
+
+	var diff map[string]Change
+	require.NoError(t, json.Unmarshal(entry.Diff, &diff))
+	assert.Equal(t, Change{Old: model.RoleUser, New: model.RoleAdmin}, diff["role"])
+
+	var exported []string
+	err = auditService.Export(context.Background(), &dto.AuditQuery{Action: AuditUserLoginFailure}, func(entry *dto.AuditLogResponse) error {
+		exported = append(exported, entry.Action)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, exported, 2)
+
+	// 关闭后的事件被忽略
+	auditService.Record(context.Background(), AuditEntry{Action: AuditUserRegister})
+}
+
+func TestAuditService_ScopesByTenant(t *testing.T) {
+	database, _, acme, globex := setupTenants(t)
+	auditService := NewAuditService(repository.NewAuditRepository(database), config.AuditConfig{}, logger.NewNop())
+
+	acmeCtx := tenant.WithTenant(context.Background(), acme)
+	globexCtx := tenant.WithTenant(context.Background(), globex)
+	auditService.Record(acmeCtx, AuditEntry{Action: AuditAdminRoleChange, TargetType: AuditTargetUser, TargetID: "1"})
+	auditService.Record(globexCtx, AuditEntry{Action: AuditAdminUserDelete, TargetType: AuditTargetUser, TargetID: "2"})
+	require.NoError(t, auditService.Close())
+
+	// 只能查询和导出本租户的记录
+	result, err := auditService.Query(acmeCtx, &dto.AuditQuery{})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, AuditAdminRoleChange, result.Items[0].Action)
+
+	var exported []string
+	err = auditService.Export(globexCtx, &dto.AuditQuery{}, func(entry *dto.AuditLogResponse) error {
+		exported = append(exported, entry.Action)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{AuditAdminUserDelete}, exported)
+}
+
+// blockingAuditRepository 写入时阻塞，用于模拟缓慢的数据库
+type blockingAuditRepository struct {
+	repository.AuditRepository
+	release chan struct{}
+}
+
+func (r *blockingAuditRepository) CreateBatch(ctx context.Context, logs []model.AuditLog) error {
+	<-r.release
+	return nil
+}
+
+func TestAuditService_RecordDoesNotBlock(t *testing.T) {
+	repo := &blockingAuditRepository{release: make(chan struct{})}
+	auditService := NewAuditService(repo, config.AuditConfig{
+		BufferSize:    1,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	}, logger.NewNop())
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			auditService.Record(context.Background(), AuditEntry{Action: AuditUserRegister})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked while the writer was busy")
+	}
+
+	close(repo.release)
+	require.NoError(t, auditService.Close())
+}
+
+func TestUserService_RecordsAuditEvents(t *testing.T) {
+	database := newTestDB(t)
+	auditService := NewAuditService(repository.NewAuditRepository(database), config.AuditConfig{}, logger.NewNop())
+	userService := NewUserService(
+		repository.NewUserRepository(database),
+		NewSessionService(repository.NewSessionRepository(database), auditService, testTokens, logger.NewNop()),
+		auditService,
+		nil,
+		NewNopEventOutbox(),
+		newTestPasswords(t),
+		logger.NewNop(),
+	)
+
+	ctx := requestinfo.NewContext(context.Background(), requestinfo.Info{RequestID: "req-2", IP: "198.51.100.7"})
+	user, err := userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "wrong-password"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	err = userService.ChangePassword(ctx, user.ID, &dto.ChangePasswordRequest{OldPassword: "password123", NewPassword: "password456"})
+	require.NoError(t, err)
+
+	require.NoError(t, auditService.Close())
+
+	var actions []string
+	err = auditService.Export(context.Background(), &dto.AuditQuery{TargetType: AuditTargetUser}, func(entry *dto.AuditLogResponse) error {
+		actions = append(actions, entry.Action)
+		assert.Equal(t, "198.51.100.7", entry.IP)
+		assert.Equal(t, "req-2", entry.RequestID)
+		assert.NotContains(t, string(entry.Diff), "password123")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		AuditUserRegister,
+		AuditUserLoginFailure,
+		AuditUserLoginSuccess,
+		AuditUserPasswordChange,
+	}, actions)
+}
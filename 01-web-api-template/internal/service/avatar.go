@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	// 注册GIF解码器
+	_ "image/gif"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/blob"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+)
+
+var (
+	// ErrAvatarTooLarge 上传文件超过大小限制
+	ErrAvatarTooLarge = errors.New("avatar file is too large")
+	// ErrUnsupportedImageType 文件内容不是允许的图片类型
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	// ErrInvalidImage 图片无法解码或尺寸超出限制
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// AvatarService 用户头像服务接口
+type AvatarService interface {
+	Upload(ctx context.Context, userID uint, r io.Reader) (*dto.UserProfileResponse, error)
+	URLs(key string) (string, map[string]string)
+	Open(ctx context.Context, key, expires, signature string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string)
+}
+
+// avatarService 用户头像服务实现
+type avatarService struct {
+	userRepo repository.UserRepository
+	store    blob.BlobStore
+	signer   *blob.URLSigner
+	cfg      config.AvatarConfig
+	log      logger.Logger
+}
+
+// NewAvatarService 创建用户头像服务实例
+func NewAvatarService(userRepo repository.UserRepository, store blob.BlobStore, signer *blob.URLSigner, cfg config.AvatarConfig, log logger.Logger) AvatarService {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 5 << 20
+	}
+	if cfg.MaxDimension <= 0 {
+		cfg.MaxDimension = 4096
+	}
+	if len(cfg.AllowedTypes) == 0 {
+		cfg.AllowedTypes = []string{"image/jpeg", "image/png", "image/gif"}
+	}
+	if len(cfg.Sizes) == 0 {
+		cfg.Sizes = []int{256, 128, 64}
+	}
+	return &avatarService{userRepo: userRepo, store: store, signer: signer, cfg: cfg, log: log}
+}
+
+// Upload 校验并缩放上传的图片，保存各尺寸缩略图后替换用户的旧头像
+func (s *avatarService) Upload(ctx context.Context, userID uint, r io.Reader) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.cfg.MaxSize {
+		return nil, ErrAvatarTooLarge
+	}
+
+	// 按内容识别类型，不信任客户端声明的Content-Type
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(s.cfg.AllowedTypes, contentType) {
+		return nil, ErrUnsupportedImageType
+	}
+
+	// 解码前先检查尺寸，防止超大像素的图片耗尽内存
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if imgCfg.Width > s.cfg.MaxDimension || imgCfg.Height > s.cfg.MaxDimension {
+		return nil, ErrInvalidImage
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// 照片使用JPEG，其余格式保留透明通道使用PNG；重新编码同时去除EXIF等元数据
+	ext := ".png"
+	if contentType == "image/jpeg" {
+		ext = ".jpg"
+	}
+	key := fmt.Sprintf("avatars/%d/%s%s", user.ID, randomToken(8), ext)
+
+	written := make([]string, 0, len(s.cfg.Sizes))
+	for _, size := range s.cfg.Sizes {
+		var buf bytes.Buffer
+		if err := encodeImage(&buf, thumbnail(img, size), ext); err != nil {
+			s.deleteKeys(ctx, written)
+			return nil, err
+		}
+		thumbKey := thumbnailKey(key, size)
+		if err := s.store.Put(ctx, thumbKey, &buf); err != nil {
+			s.deleteKeys(ctx, written)
+			return nil, err
+		}
+		written = append(written, thumbKey)
+	}
+
+	oldKey := user.AvatarKey
+	user.AvatarKey = key
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.deleteKeys(ctx, written)
+		return nil, err
+	}
+	if oldKey != "" {
+		s.deleteKeys(ctx, s.thumbnailKeys(oldKey))
+	}
+
+	avatarURL, avatarURLs := s.URLs(key)
+	return &dto.UserProfileResponse{
+		ID:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		AvatarURL:  avatarURL,
+		AvatarURLs: avatarURLs,
+	}, nil
+}
+
+// URLs 返回默认尺寸和全部尺寸的签名访问地址，未设置头像时返回空值
+func (s *avatarService) URLs(key string) (string, map[string]string) {
+	if key == "" {
+		return "", nil
+	}
+
+	now := time.Now()
+	urls := make(map[string]string, len(s.cfg.Sizes))
+	for _, size := range s.cfg.Sizes {
+		urls[strconv.Itoa(size)] = s.signer.SignURL(thumbnailKey(key, size), now)
+	}
+	return urls[strconv.Itoa(s.cfg.Sizes[0])], urls
+}
+
+// Open 校验签名后打开文件，调用方负责关闭
+func (s *avatarService) Open(ctx context.Context, key, expires, signature string) (io.ReadCloser, error) {
+	if err := s.signer.Verify(key, expires, signature, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.store.Get(ctx, key)
+}
+
+// Delete 删除头像全部尺寸的文件，失败只记录日志
+func (s *avatarService) Delete(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	s.deleteKeys(ctx, s.thumbnailKeys(key))
+}
+
+// thumbnailKeys 返回头像所有尺寸的存储键
+func (s *avatarService) thumbnailKeys(key string) []string {
+	keys := make([]string, 0, len(s.cfg.Sizes))
+	for _, size := range s.cfg.Sizes {
+		keys = append(keys, thumbnailKey(key, size))
+	}
+	return keys
+}
+
+// deleteKeys 尽力删除文件，失败只记录日志
+func (s *avatarService) deleteKeys(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.log.Warn("Failed to delete avatar file", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// thumbnailKey 在扩展名前插入尺寸，例如 avatars/1/ab.png -> avatars/1/ab_128.png
+func thumbnailKey(key string, size int) string {
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_" + strconv.Itoa(size) + ext
+}
+
+// thumbnail 居中裁剪为正方形后缩放到指定边长
+func thumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	return dst
+}
+
+// encodeImage 按扩展名编码图片
+func encodeImage(w io.Writer, img image.Image, ext string) error {
+	if ext == ".jpg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/blob"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+)
+
+// testPNG 生成指定尺寸的PNG图片
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// openSigned 解析签名地址并通过服务读取文件
+func openSigned(t *testing.T, avatarService AvatarService, signedURL string) ([]byte, error) {
+	t.Helper()
+
+	u, err := url.Parse(signedURL)
+	require.NoError(t, err)
+	key := strings.TrimPrefix(u.Path, "/api/v1/files/")
+
+	file, err := avatarService.Open(context.Background(), key, u.Query().Get("expires"), u.Query().Get("signature"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(file)
+	return buf.Bytes(), err
+}
+
+func TestAvatarService_Upload(t *testing.T) {
+	database := newTestDB(t)
+	userRepo := repository.NewUserRepository(database)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	store, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	avatarService := NewAvatarService(userRepo, store, blob.NewURLSigner("secret", "/api/v1/files", time.Hour), config.AvatarConfig{
+		MaxSize:      1 << 20,
+		MaxDimension: 1000,
+		Sizes:        []int{128, 32},
+	}, logger.NewNop())
+
+	profile, err := avatarService.Upload(context.Background(), user.ID, bytes.NewReader(testPNG(t, 300, 200)))
+	require.NoError(t, err)
+	require.Len(t, profile.AvatarURLs, 2)
+	assert.Equal(t, profile.AvatarURLs["128"], profile.AvatarURL)
+
+	for size, signedURL := range map[int]string{128: profile.AvatarURLs["128"], 32: profile.AvatarURLs["32"]} {
+		data, err := openSigned(t, avatarService, signedURL)
+		require.NoError(t, err)
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, size, cfg.Width)
+		assert.Equal(t, size, cfg.Height)
+	}
+
+	// 篡改签名无法访问
+	_, err = openSigned(t, avatarService, profile.AvatarURL+"0")
+	assert.ErrorIs(t, err, blob.ErrInvalidSignature)
+
+	// 替换头像后删除旧文件
+	_, err = avatarService.Upload(context.Background(), user.ID, bytes.NewReader(testPNG(t, 64, 64)))
+	require.NoError(t, err)
+	_, err = openSigned(t, avatarService, profile.AvatarURL)
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+}
+
+func TestAvatarService_RejectsInvalidUploads(t *testing.T) {
+	database := newTestDB(t)
+	userRepo := repository.NewUserRepository(database)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	store, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	avatarService := NewAvatarService(userRepo, store, blob.NewURLSigner("secret", "/api/v1/files", time.Hour), config.AvatarConfig{
+		MaxSize:      4 << 10,
+		MaxDimension: 50,
+	}, logger.NewNop())
+
+	_, err = avatarService.Upload(context.Background(), user.ID, strings.NewReader("<html><body>not an image</body></html>"))
+	assert.ErrorIs(t, err, ErrUnsupportedImageType)
+
+	_, err = avatarService.Upload(context.Background(), user.ID, bytes.NewReader(make([]byte, 8<<10)))
+	assert.ErrorIs(t, err, ErrAvatarTooLarge)
+
+	// 声明为PNG但内容截断
+	_, err = avatarService.Upload(context.Background(), user.ID, bytes.NewReader(testPNG(t, 20, 20)[:40]))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	_, err = avatarService.Upload(context.Background(), user.ID, bytes.NewReader(testPNG(t, 60, 20)))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	stored, err := userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.AvatarKey)
+}
+
+func TestUserService_PurgeDeletesAvatar(t *testing.T) {
+	database := newTestDB(t)
+	userRepo := repository.NewUserRepository(database)
+	store, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	avatarService := NewAvatarService(userRepo, store, blob.NewURLSigner("secret", "/api/v1/files", time.Hour), config.AvatarConfig{
+		Sizes: []int{64, 32},
+	}, logger.NewNop())
+	userService := NewUserService(userRepo, nil, NewNopAuditService(), avatarService, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
+	ctx := context.Background()
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, userRepo.Create(ctx, user))
+	_, err = avatarService.Upload(ctx, user.ID, bytes.NewReader(testPNG(t, 64, 64)))
+	require.NoError(t, err)
+	uploaded, err := userRepo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	// 软删除时保留头像，便于恢复用户
+	require.NoError(t, userRepo.Delete(ctx, user.ID))
+	file, err := store.Get(ctx, thumbnailKey(uploaded.AvatarKey, 64))
+	require.NoError(t, err)
+	file.Close()
+
+	require.NoError(t, database.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+	purged, err := userService.PurgeDeleted(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	for _, size := range []int{64, 32} {
+		_, err := store.Get(ctx, thumbnailKey(uploaded.AvatarKey, size))
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testTokens 测试使用的令牌签发器
+var testTokens = middleware.NewTokenIssuer(config.JWTConfig{Secret: "test-secret", AccessTokenExp: 3600, MFATokenExp: 300})
+
+// newTestPasswords 使用低开销参数的密码哈希，加快测试
+func newTestPasswords(t *testing.T) *Passwords {
+	t.Helper()
+
+	passwords, err := NewPasswords(config.PasswordConfig{
+		Argon2:     config.Argon2Config{Memory: 1024, Iterations: 1, Parallelism: 1},
+		BcryptCost: bcrypt.MinCost,
+	})
+	require.NoError(t, err)
+	return passwords
+}
+
+// newTestDB 创建内存SQLite数据库并迁移全部模型
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	// 内存数据库只在单个连接内可见
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, database.AutoMigrate(
+		&model.User{},
+		&model.UserIdentity{},
+		&model.MFARecoveryCode{},
+		&model.Session{},
+		&model.AuditLog{},
+		&model.IdempotencyRecord{},
+		&model.Tenant{},
+		&model.UserImportJob{},
+		&model.UserImportError{},
+		&model.OutboxEvent{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.FeatureFlag{},
+	))
+	return database
+}
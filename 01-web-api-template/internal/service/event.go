@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+)
+
+// 领域事件类型，也是Webhook订阅时使用的事件名
+const (
+	EventUserRegistered   = "user.registered"
+	EventUserEmailChanged = "user.email_changed"
+	EventUserDeleted      = "user.deleted"
+	EventUserDeactivated  = "user.deactivated"
+	EventUserReactivated  = "user.reactivated"
+)
+
+// EventTypes 全部可订阅的事件类型
+var EventTypes = []string{EventUserRegistered, EventUserEmailChanged, EventUserDeleted, EventUserDeactivated, EventUserReactivated}
+
+// DomainEvent 领域事件，Data序列化为JSON后作为Webhook请求体中的data
+type DomainEvent struct {
+	Type      string
+	SubjectID string
+	Data      interface{}
+}
+
+// EventOutbox 领域事件发件箱：事件与业务数据在同一事务中写入，提交后由投递任务异步分发
+type EventOutbox interface {
+	// Transaction 在事务中执行fn，fn内的仓库操作和 Publish 写入的事件一同提交或回滚
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Publish 写入事件，需在 Transaction 的上下文中调用才能与业务数据保持一致
+	Publish(ctx context.Context, event DomainEvent) error
+}
+
+// eventOutbox 基于数据库发件箱表的实现
+type eventOutbox struct {
+	tx   repository.Transactor
+	repo repository.OutboxRepository
+}
+
+// NewEventOutbox 创建领域事件发件箱
+func NewEventOutbox(tx repository.Transactor, repo repository.OutboxRepository) EventOutbox {
+	return &eventOutbox{tx: tx, repo: repo}
+}
+
+// Transaction 在数据库事务中执行fn
+func (o *eventOutbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return o.tx.Transaction(ctx, fn)
+}
+
+// Publish 写入事件，租户由上下文决定
+func (o *eventOutbox) Publish(ctx context.Context, event DomainEvent) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	return o.repo.Create(ctx, &model.OutboxEvent{
+		Type:      event.Type,
+		SubjectID: event.SubjectID,
+		Payload:   string(payload),
+	})
+}
+
+// nopEventOutbox 不记录事件的发件箱，用于测试和工具命令
+type nopEventOutbox struct{}
+
+// NewNopEventOutbox 创建空实现的发件箱，Transaction直接执行fn
+func NewNopEventOutbox() EventOutbox {
+	return nopEventOutbox{}
+}
+
+// Transaction 直接执行fn
+func (nopEventOutbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Publish 忽略事件
+func (nopEventOutbox) Publish(ctx context.Context, event DomainEvent) error {
+	return nil
+}
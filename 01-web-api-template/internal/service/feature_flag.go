@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/flags"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 功能开关的来源
+const (
+	FlagSourceConfig = "config"
+	FlagSourceDB     = "db"
+)
+
+var (
+	// ErrInvalidFlagKey 开关键名格式错误
+	ErrInvalidFlagKey = errors.New("flag key may only contain lowercase letters, digits, '.', '_' and '-'")
+	// ErrInvalidFlagRole 定向的角色不存在
+	ErrInvalidFlagRole = errors.New("flag targets an unknown role")
+)
+
+// flagKeyPattern 开关键名格式
+var flagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// FlagService 功能开关服务：合并配置文件和数据库中的开关，在内存中评估。
+// 管理员的修改写入数据库后立即在本实例生效，其他实例在下次刷新时生效
+type FlagService interface {
+	// Evaluate 计算全部开关对指定对象的状态
+	Evaluate(subject flags.Subject) flags.Set
+	List(ctx context.Context) ([]dto.FeatureFlagResponse, error)
+	// Set 创建或覆盖开关
+	Set(ctx context.Context, key string, req *dto.UpdateFeatureFlagRequest) (*dto.FeatureFlagResponse, error)
+	// Reset 删除管理员的设置，恢复为配置文件中的开关（没有则移除开关）
+	Reset(ctx context.Context, key string) error
+	// Refresh 从数据库重新加载开关
+	Refresh(ctx context.Context) error
+	Close()
+}
+
+// flagEntry 内存中的开关及其元数据
+type flagEntry struct {
+	flag        flags.Flag
+	description string
+	source      string
+	updatedBy   uint
+	updatedAt   *time.Time
+}
+
+// flagService 功能开关服务实现
+type flagService struct {
+	repo     repository.FeatureFlagRepository
+	audit    AuditService
+	log      logger.Logger
+	defaults map[string]flagEntry
+
+	mu      sync.RWMutex
+	entries map[string]flagEntry
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewFlagService 创建功能开关服务，加载开关并按cfg.RefreshInterval定期刷新
+func NewFlagService(repo repository.FeatureFlagRepository, audit AuditService, cfg config.FlagsConfig, log logger.Logger) FlagService {
+	defaults := make(map[string]flagEntry, len(cfg.Defaults))
+	for key, flagConfig := range cfg.Defaults {
+		if !flagKeyPattern.MatchString(key) {
+			log.Warn("Ignoring feature flag with invalid key", zap.String("key", key))
+			continue
+		}
+		rollout := 100
+		if flagConfig.Rollout != nil {
+			rollout = *flagConfig.Rollout
+		}
+		defaults[key] = flagEntry{
+			flag: flags.Flag{
+				Key:     key,
+				Enabled: flagConfig.Enabled,
+				Rollout: rollout,
+				UserIDs: flagConfig.Users,
+				Roles:   flagConfig.Roles,
+			},
+			description: flagConfig.Description,
+			source:      FlagSourceConfig,
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &flagService{
+		repo:     repo,
+		audit:    audit,
+		log:      log,
+		defaults: defaults,
+		entries:  defaults,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	if err := s.Refresh(ctx); err != nil {
+		s.log.Error("Failed to load feature flags", zap.Error(err))
+	}
+
+	if cfg.RefreshInterval > 0 {
+		go s.run(ctx, cfg.RefreshInterval)
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// run 定期刷新开关
+func (s *flagService) run(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				s.log.Error("Failed to refresh feature flags", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Refresh 从数据库加载开关，覆盖配置文件中的同名开关
+func (s *flagService) Refresh(ctx context.Context) error {
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]flagEntry, len(s.defaults)+len(stored))
+	for key, entry := range s.defaults {
+		entries[key] = entry
+	}
+	for i := range stored {
+		updatedAt := stored[i].UpdatedAt
+		entries[stored[i].Key] = flagEntry{
+			flag: flags.Flag{
+				Key:     stored[i].Key,
+				Enabled: stored[i].Enabled,
+				Rollout: stored[i].Rollout,
+				UserIDs: stored[i].TargetUserIDs(),
+				Roles:   stored[i].TargetRoles(),
+			},
+			description: stored[i].Description,
+			source:      FlagSourceDB,
+			updatedBy:   stored[i].UpdatedBy,
+			updatedAt:   &updatedAt,
+		}
+	}
+
+	s.mu.Lock()
+	s.entries = entries
+	s.mu.Unlock()
+	return nil
+}
+
+// Evaluate 计算全部开关对指定对象的状态
+func (s *flagService) Evaluate(subject flags.Subject) flags.Set {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := make(flags.Set, len(s.entries))
+	for key, entry := range s.entries {
+		set[key] = entry.flag.Evaluate(subject)
+	}
+	return set
+}
+
+// List 重新加载后按键名列出全部开关
+func (s *flagService) List(ctx context.Context) ([]dto.FeatureFlagResponse, error) {
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	items := make([]dto.FeatureFlagResponse, 0, len(s.entries))
+	for _, entry := range s.entries {
+		items = append(items, *flagResponse(entry))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items, nil
+}
+
+// Set 创建或覆盖开关，立即在本实例生效
+func (s *flagService) Set(ctx context.Context, key string, req *dto.UpdateFeatureFlagRequest) (*dto.FeatureFlagResponse, error) {
+	if !flagKeyPattern.MatchString(key) {
+		return nil, ErrInvalidFlagKey
+	}
+	roles := make([]string, 0, len(req.Roles))
+	for _, role := range req.Roles {
+		if role != model.RoleUser && role != model.RoleAdmin {
+			return nil, ErrInvalidFlagRole
+		}
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	rollout := 100
+	if req.Rollout != nil {
+		rollout = *req.Rollout
+	}
+
+	s.mu.RLock()
+	previous, existed := s.entries[key]
+	s.mu.RUnlock()
+
+	stored := &model.FeatureFlag{
+		Key:         key,
+		Description: req.Description,
+		Enabled:     *req.Enabled,
+		Rollout:     rollout,
+		Roles:       strings.Join(roles, ","),
+		UpdatedBy:   requestinfo.FromContext(ctx).UserID,
+	}
+	stored.SetTargetUserIDs(req.Users)
+	if err := s.repo.Save(ctx, stored); err != nil {
+		return nil, err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	diff := map[string]interface{}{
+		"enabled": Change{New: stored.Enabled},
+		"rollout": Change{New: stored.Rollout},
+	}
+	if existed {
+		diff["enabled"] = Change{Old: previous.flag.Enabled, New: stored.Enabled}
+		diff["rollout"] = Change{Old: previous.flag.Rollout, New: stored.Rollout}
+	}
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminFlagUpdate,
+		Success:    true,
+		TargetType: AuditTargetFlag,
+		TargetID:   key,
+		Diff:       diff,
+	})
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return flagResponse(s.entries[key]), nil
+}
+
+// Reset 删除数据库中的开关设置
+func (s *flagService) Reset(ctx context.Context, key string) error {
+	if err := s.repo.Delete(ctx, key); err != nil {
+		return err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminFlagReset,
+		Success:    true,
+		TargetType: AuditTargetFlag,
+		TargetID:   key,
+	})
+	return nil
+}
+
+// Close 停止定期刷新
+func (s *flagService) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// flagResponse 转换开关信息响应
+func flagResponse(entry flagEntry) *dto.FeatureFlagResponse {
+	users := entry.flag.UserIDs
+	if users == nil {
+		users = []uint{}
+	}
+	roles := entry.flag.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return &dto.FeatureFlagResponse{
+		Key:         entry.flag.Key,
+		Description: entry.description,
+		Enabled:     entry.flag.Enabled,
+		Rollout:     entry.flag.Rollout,
+		Users:       users,
+		Roles:       roles,
+		Source:      entry.source,
+		UpdatedBy:   entry.updatedBy,
+		UpdatedAt:   entry.updatedAt,
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/flags"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+func TestFlagService_MergesConfigAndRuntimeOverrides(t *testing.T) {
+	database := newTestDB(t)
+	zero := 0
+	cfg := config.FlagsConfig{Defaults: map[string]config.FlagConfig{
+		"dark-mode": {Enabled: true},
+		"beta":      {Enabled: true, Rollout: &zero, Roles: []string{"admin"}},
+		"Bad Key":   {Enabled: true},
+	}}
+	flagService := NewFlagService(repository.NewFeatureFlagRepository(database), NewNopAuditService(), cfg, logger.NewNop())
+	t.Cleanup(flagService.Close)
+	// 模拟另一个实例，只在刷新后看到修改
+	other := NewFlagService(repository.NewFeatureFlagRepository(database), NewNopAuditService(), cfg, logger.NewNop())
+	t.Cleanup(other.Close)
+
+	anonymous := flags.Subject{}
+	admin := flags.Subject{UserID: 1, Role: "admin"}
+	user := flags.Subject{UserID: 5, Role: "user"}
+	assert.Equal(t, flags.Set{"dark-mode": true, "beta": false}, flagService.Evaluate(anonymous))
+	assert.True(t, flagService.Evaluate(admin)["beta"])
+	assert.False(t, flagService.Evaluate(user)["beta"])
+
+	enabled := true
+	ctx := context.Background()
+	flag, err := flagService.Set(ctx, "beta", &dto.UpdateFeatureFlagRequest{Enabled: &enabled, Rollout: &zero, Users: []uint{5}})
+	require.NoError(t, err)
+	assert.Equal(t, FlagSourceDB, flag.Source)
+	assert.Equal(t, []uint{5}, flag.Users)
+	assert.Empty(t, flag.Roles)
+
+	// 数据库中的设置整体覆盖配置文件，立即在本实例生效
+	assert.True(t, flagService.Evaluate(user)["beta"])
+	assert.False(t, flagService.Evaluate(admin)["beta"])
+	assert.False(t, other.Evaluate(user)["beta"])
+	require.NoError(t, other.Refresh(ctx))
+	assert.True(t, other.Evaluate(user)["beta"])
+
+	items, err := flagService.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "beta", items[0].Key)
+	assert.Equal(t, FlagSourceDB, items[0].Source)
+	assert.Equal(t, "dark-mode", items[1].Key)
+	assert.Equal(t, FlagSourceConfig, items[1].Source)
+	assert.Equal(t, 100, items[1].Rollout)
+
+	// 重置后恢复配置文件中的开关
+	require.NoError(t, flagService.Reset(ctx, "beta"))
+	assert.True(t, flagService.Evaluate(admin)["beta"])
+	assert.False(t, flagService.Evaluate(user)["beta"])
+	assert.ErrorIs(t, flagService.Reset(ctx, "beta"), gorm.ErrRecordNotFound)
+
+	_, err = flagService.Set(ctx, "Bad Key", &dto.UpdateFeatureFlagRequest{Enabled: &enabled})
+	assert.ErrorIs(t, err, ErrInvalidFlagKey)
+	_, err = flagService.Set(ctx, "beta", &dto.UpdateFeatureFlagRequest{Enabled: &enabled, Roles: []string{"owner"}})
+	assert.ErrorIs(t, err, ErrInvalidFlagRole)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// idempotencySweepInterval 清理过期幂等记录的最小间隔
+const idempotencySweepInterval = 10 * time.Minute
+
+// idempotencyService 基于数据库的幂等请求存储，实现middleware.IdempotencyStore
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+	log  logger.Logger
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewIdempotencyService 创建幂等请求存储，记录在ttl后过期
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl time.Duration, log logger.Logger) middleware.IdempotencyStore {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &idempotencyService{repo: repo, ttl: ttl, log: log}
+}
+
+// Begin 登记请求，已有记录时根据状态返回保存的响应或错误
+func (s *idempotencyService) Begin(ctx context.Context, scope middleware.IdempotencyScope, requestHash string) (*middleware.IdempotentResponse, error) {
+	now := time.Now()
+	s.sweep(ctx, now)
+
+	// 记录可能在查询间隙被释放或过期删除，有限次数重试
+	for attempt := 0; attempt < 3; attempt++ {
+		created, err := s.repo.CreateIfAbsent(ctx, &model.IdempotencyRecord{
+			ExpiresAt:   now.Add(s.ttl),
+			TenantID:    scope.TenantID,
+			Key:         scope.Key,
+			UserID:      scope.UserID,
+			Method:      scope.Method,
+			Route:       scope.Route,
+			RequestHash: requestHash,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return nil, nil
+		}
+
+		record, err := s.repo.Get(ctx, scope.Key, scope.UserID, scope.Method, scope.Route)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 记录在两次查询之间被释放，重新登记
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 过期的记录视为不存在
+		if !record.ExpiresAt.After(now) {
+			if err := s.repo.Delete(ctx, record.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if record.RequestHash != requestHash {
+			return nil, middleware.ErrIdempotencyMismatch
+		}
+		if record.StatusCode == 0 {
+			return nil, middleware.ErrIdempotencyInProgress
+		}
+		return &middleware.IdempotentResponse{
+			StatusCode:  record.StatusCode,
+			ContentType: record.ContentType,
+			Body:        record.Body,
+		}, nil
+	}
+	return nil, middleware.ErrIdempotencyInProgress
+}
+
+// Complete 保存请求的响应
+func (s *idempotencyService) Complete(ctx context.Context, scope middleware.IdempotencyScope, response *middleware.IdempotentResponse) error {
+	record, err := s.repo.Get(ctx, scope.Key, scope.UserID, scope.Method, scope.Route)
+	if err != nil {
+		return err
+	}
+	return s.repo.Complete(ctx, record.ID, response.StatusCode, response.ContentType, response.Body)
+}
+
+// Release 删除处理中的记录
+func (s *idempotencyService) Release(ctx context.Context, scope middleware.IdempotencyScope) error {
+	record, err := s.repo.Get(ctx, scope.Key, scope.UserID, scope.Method, scope.Route)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, record.ID)
+}
+
+// sweep 按间隔清理全部租户的过期记录
+func (s *idempotencyService) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	if _, err := s.repo.DeleteExpired(tenant.Unscoped(ctx), now); err != nil {
+		s.log.Warn("Failed to delete expired idempotency records", zap.Error(err))
+	}
+}
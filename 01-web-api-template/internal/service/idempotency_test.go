@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+)
+
+// setupIdempotencyTest 创建挂载幂等中间件的路由，处理函数返回调用次数
+func setupIdempotencyTest(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	store := NewIdempotencyService(repository.NewIdempotencyRepository(newTestDB(t)), time.Hour, logger.NewNop())
+
+	r := gin.New()
+	r.POST("/orders", middleware.IdempotencyMiddleware(store, logger.NewNop()), handler)
+	return r
+}
+
+// postOrder 发送携带幂等键的请求
+func postOrder(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	var calls atomic.Int32
+	r := setupIdempotencyTest(t, func(c *gin.Context) {
+		n := calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+
+	first := postOrder(r, "key-1", `{"item": "book"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+
+	second := postOrder(r, "key-1", `{"item": "book"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 同一个键用于不同的请求内容
+	mismatch := postOrder(r, "key-1", `{"item": "pen"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+
+	// 不同的键正常处理
+	assert.Equal(t, http.StatusCreated, postOrder(r, "key-2", `{"item": "pen"}`).Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMiddleware_ConcurrentDuplicate(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := setupIdempotencyTest(t, func(c *gin.Context) {
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- postOrder(r, "key-1", `{}`)
+	}()
+	<-started
+
+	assert.Equal(t, http.StatusConflict, postOrder(r, "key-1", `{}`).Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, http.StatusCreated, postOrder(r, "key-1", `{}`).Code)
+}
+
+func TestIdempotencyMiddleware_ServerErrorAllowsRetry(t *testing.T) {
+	var calls atomic.Int32
+	r := setupIdempotencyTest(t, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "temporary failure"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, postOrder(r, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, postOrder(r, "key-1", `{}`).Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyService_ExpiredRecord(t *testing.T) {
+	store := NewIdempotencyService(repository.NewIdempotencyRepository(newTestDB(t)), time.Millisecond, logger.NewNop())
+	scope := middleware.IdempotencyScope{Key: "key-1", UserID: 1, Method: "POST", Route: "/orders"}
+
+	saved, err := store.Begin(context.Background(), scope, "hash-a")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+	require.NoError(t, store.Complete(context.Background(), scope, &middleware.IdempotentResponse{StatusCode: 201, Body: []byte("{}")}))
+
+	time.Sleep(5 * time.Millisecond)
+
+	// 过期后可以用相同的键发送不同的请求
+	saved, err = store.Begin(context.Background(), scope, "hash-b")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+}
+
+func TestIdempotencyService_ScopesKeysByTenant(t *testing.T) {
+	database, _, acme, globex := setupTenants(t)
+	store := NewIdempotencyService(repository.NewIdempotencyRepository(database), time.Hour, logger.NewNop())
+	acmeCtx := tenant.WithTenant(context.Background(), acme)
+	globexCtx := tenant.WithTenant(context.Background(), globex)
+
+	// 不同租户中的用户ID可能相同，幂等键互不影响
+	acmeScope := middleware.IdempotencyScope{Key: "key-1", TenantID: acme, UserID: 1, Method: "POST", Route: "/orders"}
+	globexScope := acmeScope
+	globexScope.TenantID = globex
+
+	saved, err := store.Begin(acmeCtx, acmeScope, "hash-a")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+	require.NoError(t, store.Complete(acmeCtx, acmeScope, &middleware.IdempotentResponse{StatusCode: 201, Body: []byte(`{"tenant":"acme"}`)}))
+
+	saved, err = store.Begin(globexCtx, globexScope, "hash-b")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+	require.NoError(t, store.Complete(globexCtx, globexScope, &middleware.IdempotentResponse{StatusCode: 201, Body: []byte(`{"tenant":"globex"}`)}))
+
+	saved, err = store.Begin(acmeCtx, acmeScope, "hash-a")
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, `{"tenant":"acme"}`, string(saved.Body))
+
+	var records int64
+	database.WithContext(tenant.Unscoped(context.Background())).Model(&model.IdempotencyRecord{}).Count(&records)
+	assert.Equal(t, int64(2), records)
+}
+
+func TestHTTPIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	store := NewIdempotencyService(repository.NewIdempotencyRepository(newTestDB(t)), time.Hour, logger.NewNop())
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("POST /orders", middleware.HTTPIdempotencyMiddleware(store, logger.NewNop())(handler))
+
+	first := postOrder(mux, "key-1", `{"item": "book"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+
+	second := postOrder(mux, "key-1", `{"item": "book"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+
+	mismatch := postOrder(mux, "key-1", `{"item": "pen"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	assert.JSONEq(t, `{"error":"`+middleware.ErrIdempotencyMismatch.Error()+`"}`, mismatch.Body.String())
+}
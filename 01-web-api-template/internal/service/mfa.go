@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/totp"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+)
+
+var (
+	// ErrMFAAlreadyEnabled 二次验证已启用
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrMFANotEnrolled 尚未登记二次验证密钥
+	ErrMFANotEnrolled = errors.New("two-factor authentication is not enrolled")
+	// ErrInvalidMFACode 验证码或恢复码错误
+	ErrInvalidMFACode = errors.New("invalid verification code")
+	// ErrInvalidMFAToken 等待验证令牌无效或已过期
+	ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+	// ErrTooManyMFAAttempts 验证失败次数过多，二次验证暂时被锁定
+	ErrTooManyMFAAttempts = errors.New("too many verification attempts, please try again later")
+)
+
+// recoveryCodeEncoding 恢复码使用小写Base32字符，避免易混淆的0/1/8/9
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// MFAService 二次验证服务接口
+type MFAService interface {
+	Enroll(ctx context.Context, userID uint) (*dto.MFAEnrollResponse, error)
+	Confirm(ctx context.Context, userID uint, code string) (*dto.MFAConfirmResponse, error)
+	VerifyLogin(ctx context.Context, req *dto.MFALoginRequest) (string, error)
+	Reset(ctx context.Context, userID uint) error
+}
+
+// mfaService 二次验证服务实现
+type mfaService struct {
+	tx       repository.Transactor
+	userRepo repository.UserRepository
+	codeRepo repository.RecoveryCodeRepository
+	sessions SessionService
+	audit    AuditService
+	cfg      config.MFAConfig
+}
+
+// NewMFAService 创建二次验证服务实例，恢复码与用户的二次验证状态在tx开启的同一事务中写入
+func NewMFAService(tx repository.Transactor, userRepo repository.UserRepository, codeRepo repository.RecoveryCodeRepository, sessions SessionService, audit AuditService, cfg config.MFAConfig) MFAService {
+	if cfg.Issuer == "" {
+		cfg.Issuer = "Go Web API Template"
+	}
+	if cfg.RecoveryCodeCount <= 0 {
+		cfg.RecoveryCodeCount = 10
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = 15 * time.Minute
+	}
+
+	return &mfaService{
+		tx:       tx,
+		userRepo: userRepo,
+		codeRepo: codeRepo,
+		sessions: sessions,
+		audit:    audit,
+		cfg:      cfg,
+	}
+}
+
+// Enroll 生成新的TOTP密钥，确认前不会生效
+func (s *mfaService) Enroll(ctx context.Context, userID uint) (*dto.MFAEnrollResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFASecret = secret
+	user.MFALastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &dto.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.cfg.Issuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm 校验首个验证码后启用二次验证并生成恢复码
+func (s *mfaService) Confirm(ctx context.Context, userID uint, code string) (*dto.MFAConfirmResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := totp.Validate(user.MFASecret, code, time.Now(), user.MFALastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes(s.cfg.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFAEnabled = true
+	user.MFALastStep = step
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.codeRepo.Replace(ctx, user.ID, hashes); err != nil {
+			return err
+		}
+		return s.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditUserMFAEnable,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff:       map[string]interface{}{"mfa_enabled": Change{Old: false, New: true}},
+	})
+
+	return &dto.MFAConfirmResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyLogin 校验等待验证令牌和验证码，成功后签发访问令牌。
+// 失败次数按用户记录在数据库中，重新登录获取新的等待验证令牌不会重置
+func (s *mfaService) VerifyLogin(ctx context.Context, req *dto.MFALoginRequest) (string, error) {
+	claims, err := s.sessions.ParseToken(req.MFAToken)
+	if err != nil || claims.Subject != middleware.MFAPendingSubject {
+		return "", ErrInvalidMFAToken
+	}
+
+	// 等待验证令牌记录了用户所属的租户
+	if claims.TenantID != 0 {
+		ctx = tenant.WithTenant(ctx, claims.TenantID)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || !user.MFAEnabled {
+		// 二次验证已被重置时，旧的等待验证令牌同样失效
+		return "", ErrInvalidMFAToken
+	}
+	// 等待验证期间账号被停用
+	if !user.IsActive {
+		return "", ErrAccountDeactivated
+	}
+	if user.MFALockedUntil != nil && time.Now().Before(*user.MFALockedUntil) {
+		return "", ErrTooManyMFAAttempts
+	}
+
+	ok, err := s.verifyCode(ctx, user, req.Code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		if _, err := s.userRepo.RecordMFAFailure(ctx, user.ID, s.cfg.MaxAttempts, time.Now().Add(s.cfg.LockoutDuration)); err != nil {
+			return "", err
+		}
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditUserLoginFailure,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+			Diff: map[string]interface{}{
+				"username": user.Username,
+				"reason":   "invalid mfa code",
+			},
+		})
+		return "", ErrInvalidMFACode
+	}
+
+	if user.MFAFailedAttempts > 0 || user.MFALockedUntil != nil {
+		if err := s.userRepo.ResetMFAFailures(ctx, user.ID); err != nil {
+			return "", err
+		}
+	}
+
+	return s.sessions.Issue(ctx, user, "mfa")
+}
+
+// Reset 关闭用户的二次验证并清除密钥和恢复码，供管理员处理用户无法登录的情况
+func (s *mfaService) Reset(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	wasEnabled := user.MFAEnabled
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	user.MFALastStep = 0
+	user.MFAFailedAttempts = 0
+	user.MFALockedUntil = nil
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.codeRepo.DeleteByUserID(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminMFAReset,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff:       map[string]interface{}{"mfa_enabled": Change{Old: wasEnabled, New: false}},
+	})
+	return nil
+}
+
+// verifyCode 依次尝试TOTP验证码和恢复码
+func (s *mfaService) verifyCode(ctx context.Context, user *model.User, code string) (bool, error) {
+	if step, ok := totp.Validate(user.MFASecret, code, time.Now(), user.MFALastStep); ok {
+		// 记录已使用的时间步，同一验证码不能再次使用；并发请求中只有一个能更新成功
+		return s.userRepo.UpdateMFAStep(ctx, user.ID, step)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	return s.codeRepo.Consume(ctx, user.ID, hashRecoveryCode(normalized))
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文（xxxxx-xxxxx格式）和哈希
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := recoveryCodeEncoding.EncodeToString(b)[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode 去除分隔符并统一小写
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != 10 {
+		return ""
+	}
+	return code
+}
+
+// hashRecoveryCode 恢复码为高熵随机值，使用SHA-256即可
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
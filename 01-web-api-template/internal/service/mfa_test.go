@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/totp"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// setupMFATest 创建一个已注册用户以及用户服务和二次验证服务
+func setupMFATest(t *testing.T) (UserService, MFAService, *model.User, *gorm.DB) {
+	t.Helper()
+
+	database := newTestDB(t)
+	userRepo := repository.NewUserRepository(database)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: string(hashed), Role: model.RoleUser}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	mfaService := NewMFAService(repository.NewTransactor(database), userRepo, repository.NewRecoveryCodeRepository(database), sessions, NewNopAuditService(), config.MFAConfig{
+		Issuer:            "Test",
+		RecoveryCodeCount: 3,
+		MaxAttempts:       2,
+	})
+	return NewUserService(userRepo, sessions, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop()), mfaService, user, database
+}
+
+// enableMFA 登记并确认二次验证，返回密钥和恢复码
+func enableMFA(t *testing.T, mfaService MFAService, userID uint) (string, []string) {
+	t.Helper()
+
+	enrollment, err := mfaService.Enroll(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/Test:alice")
+
+	// 使用上一个时间步的验证码确认，留出当前时间步供登录使用
+	code, err := totp.CodeAt(enrollment.Secret, totp.Step(time.Now())-1)
+	require.NoError(t, err)
+	result, err := mfaService.Confirm(context.Background(), userID, code)
+	require.NoError(t, err)
+	require.Len(t, result.RecoveryCodes, 3)
+
+	return enrollment.Secret, result.RecoveryCodes
+}
+
+// loginForMFAToken 密码登录并返回等待验证令牌
+func loginForMFAToken(t *testing.T, userService UserService) string {
+	t.Helper()
+
+	result, err := userService.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	require.True(t, result.MFARequired)
+	assert.Empty(t, result.Token)
+	return result.MFAToken
+}
+
+func TestMFAService_LoginWithTOTP(t *testing.T) {
+	userService, mfaService, user, _ := setupMFATest(t)
+	secret, _ := enableMFA(t, mfaService, user.ID)
+
+	mfaToken := loginForMFAToken(t, userService)
+
+	// 等待验证令牌不能当作访问令牌使用
+	claims, err := testTokens.ValidateToken(mfaToken)
+	require.NoError(t, err)
+	assert.Equal(t, middleware.MFAPendingSubject, claims.Subject)
+
+	code, err := totp.CodeAt(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	token, err := mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: mfaToken, Code: code})
+	require.NoError(t, err)
+
+	claims, err = testTokens.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, middleware.AccessTokenSubject, claims.Subject)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	// 同一验证码不能重复使用
+	_, err = mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: mfaToken, Code: code})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: loginForMFAToken(t, userService), Code: code})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestMFAService_RecoveryCodeIsSingleUse(t *testing.T) {
+	userService, mfaService, user, _ := setupMFATest(t)
+	_, recoveryCodes := enableMFA(t, mfaService, user.ID)
+
+	token, err := mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{
+		MFAToken: loginForMFAToken(t, userService),
+		Code:     recoveryCodes[0],
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	_, err = mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{
+		MFAToken: loginForMFAToken(t, userService),
+		Code:     recoveryCodes[0],
+	})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestMFAService_TooManyAttempts(t *testing.T) {
+	userService, mfaService, user, database := setupMFATest(t)
+	secret, _ := enableMFA(t, mfaService, user.ID)
+
+	// 每次失败都使用新的等待验证令牌，失败次数仍按用户累计
+	for i := 0; i < 2; i++ {
+		_, err := mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: loginForMFAToken(t, userService), Code: "000000"})
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+
+	// 锁定期间正确的验证码同样被拒绝
+	code, err := totp.CodeAt(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: loginForMFAToken(t, userService), Code: code})
+	assert.ErrorIs(t, err, ErrTooManyMFAAttempts)
+
+	// 锁定到期后可以继续验证，成功后清除失败记录
+	require.NoError(t, database.Model(&model.User{}).Where("id = ?", user.ID).
+		Update("mfa_locked_until", time.Now().Add(-time.Second)).Error)
+	_, err = mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: loginForMFAToken(t, userService), Code: code})
+	require.NoError(t, err)
+
+	var stored model.User
+	require.NoError(t, database.First(&stored, user.ID).Error)
+	assert.Zero(t, stored.MFAFailedAttempts)
+	assert.Nil(t, stored.MFALockedUntil)
+}
+
+func TestUserRepository_UpdateMFAStepIsConditional(t *testing.T) {
+	_, _, user, database := setupMFATest(t)
+	userRepo := repository.NewUserRepository(database)
+	ctx := context.Background()
+
+	updated, err := userRepo.UpdateMFAStep(ctx, user.ID, 100)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// 已使用的时间步和更早的时间步不能再次记录
+	updated, err = userRepo.UpdateMFAStep(ctx, user.ID, 100)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	updated, err = userRepo.UpdateMFAStep(ctx, user.ID, 99)
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
+
+func TestMFAService_ConfirmRejectsWrongCode(t *testing.T) {
+	userService, mfaService, user, _ := setupMFATest(t)
+
+	_, err := mfaService.Confirm(context.Background(), user.ID, "123456")
+	assert.ErrorIs(t, err, ErrMFANotEnrolled)
+
+	_, err = mfaService.Enroll(context.Background(), user.ID)
+	require.NoError(t, err)
+	_, err = mfaService.Confirm(context.Background(), user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// 未确认时登录不需要二次验证
+	result, err := userService.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	assert.False(t, result.MFARequired)
+	assert.NotEmpty(t, result.Token)
+}
+
+// failingEnableUserRepository 启用二次验证的更新总是失败
+type failingEnableUserRepository struct {
+	repository.UserRepository
+}
+
+func (r failingEnableUserRepository) Update(ctx context.Context, user *model.User) error {
+	if user.MFAEnabled {
+		return errors.New("update failed")
+	}
+	return r.UserRepository.Update(ctx, user)
+}
+
+func TestMFAService_ConfirmRollsBackRecoveryCodes(t *testing.T) {
+	_, _, user, database := setupMFATest(t)
+	userRepo := failingEnableUserRepository{UserRepository: repository.NewUserRepository(database)}
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	mfaService := NewMFAService(repository.NewTransactor(database), userRepo, repository.NewRecoveryCodeRepository(database), sessions, NewNopAuditService(), config.MFAConfig{})
+
+	enrollment, err := mfaService.Enroll(context.Background(), user.ID)
+	require.NoError(t, err)
+	code, err := totp.CodeAt(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = mfaService.Confirm(context.Background(), user.ID, code)
+	require.Error(t, err)
+
+	// 启用失败时不保留恢复码
+	var codes int64
+	database.Model(&model.MFARecoveryCode{}).Where("user_id = ?", user.ID).Count(&codes)
+	assert.Zero(t, codes)
+}
+
+func TestMFAService_AdminReset(t *testing.T) {
+	userService, mfaService, user, database := setupMFATest(t)
+	enableMFA(t, mfaService, user.ID)
+	mfaToken := loginForMFAToken(t, userService)
+
+	require.NoError(t, mfaService.Reset(context.Background(), user.ID))
+
+	var codes int64
+	database.Model(&model.MFARecoveryCode{}).Where("user_id = ?", user.ID).Count(&codes)
+	assert.Equal(t, int64(0), codes)
+
+	// 重置前签发的等待验证令牌失效，重新登录直接获得访问令牌
+	_, err := mfaService.VerifyLogin(context.Background(), &dto.MFALoginRequest{MFAToken: mfaToken, Code: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidMFAToken)
+
+	result, err := userService.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	assert.False(t, result.MFARequired)
+}
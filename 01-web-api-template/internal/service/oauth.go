@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownProvider 未配置的第三方登录提供方
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	// ErrInvalidOAuthState state不存在、已使用或已过期
+	ErrInvalidOAuthState = errors.New("invalid or expired oauth state")
+)
+
+// usernameInvalidChars 用户名中不允许出现的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// OAuthService 第三方登录服务接口
+type OAuthService interface {
+	Providers() []string
+	AuthCodeURL(ctx context.Context, provider string) (string, error)
+	HandleCallback(ctx context.Context, provider, state, code string) (*dto.LoginResponse, error)
+}
+
+// oidcProvider 已完成发现的OIDC提供方
+type oidcProvider struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// pendingAuth 等待回调的授权请求，tenantID为发起登录时解析的租户
+type pendingAuth struct {
+	provider  string
+	tenantID  uint
+	verifier  string
+	nonce     string
+	expiresAt time.Time
+}
+
+// oidcClaims ID令牌中使用到的声明
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+// oauthService 第三方登录服务实现
+type oauthService struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.IdentityRepository
+	sessions     SessionService
+	events       EventOutbox
+	passwords    *Passwords
+	configs      map[string]config.OIDCProviderConfig
+	stateTTL     time.Duration
+
+	mu        sync.Mutex
+	providers map[string]*oidcProvider
+	states    map[string]pendingAuth
+}
+
+// NewOAuthService 创建第三方登录服务实例，首次登录创建的用户与注册一样通过events写入注册事件
+func NewOAuthService(userRepo repository.UserRepository, identityRepo repository.IdentityRepository, sessions SessionService, events EventOutbox, passwords *Passwords, cfg config.OAuthConfig) OAuthService {
+	stateTTL := cfg.StateTTL
+	if stateTTL <= 0 {
+		stateTTL = 10 * time.Minute
+	}
+
+	return &oauthService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		sessions:     sessions,
+		events:       events,
+		passwords:    passwords,
+		configs:      cfg.Providers,
+		stateTTL:     stateTTL,
+		providers:    make(map[string]*oidcProvider),
+		states:       make(map[string]pendingAuth),
+	}
+}
+
+// Providers 返回已配置的提供方名称
+func (s *oauthService) Providers() []string {
+	names := make([]string, 0, len(s.configs))
+	for name := range s.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthCodeURL 生成带PKCE的授权地址
+func (s *oauthService) AuthCodeURL(ctx context.Context, provider string) (string, error) {
+	p, err := s.provider(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+
+	state := randomToken(16)
+	nonce := randomToken(16)
+	verifier := oauth2.GenerateVerifier()
+
+	tenantID, _ := tenant.FromContext(ctx)
+
+	s.mu.Lock()
+	s.purgeExpiredStates()
+	s.states[state] = pendingAuth{
+		provider:  provider,
+		tenantID:  tenantID,
+		verifier:  verifier,
+		nonce:     nonce,
+		expiresAt: time.Now().Add(s.stateTTL),
+	}
+	s.mu.Unlock()
+
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)), nil
+}
+
+// HandleCallback 兑换授权码、校验ID令牌并签发本系统的登录令牌
+func (s *oauthService) HandleCallback(ctx context.Context, provider, state, code string) (*dto.LoginResponse, error) {
+	// state只能使用一次
+	s.mu.Lock()
+	pending, ok := s.states[state]
+	delete(s.states, state)
+	s.mu.Unlock()
+
+	if !ok || pending.provider != provider || time.Now().After(pending.expiresAt) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	// 回调地址通常不携带租户，使用发起登录时的租户；回调明确指定了其他租户时拒绝
+	if pending.tenantID != 0 {
+		if current, ok := tenant.FromContext(ctx); ok && current != pending.tenantID && middleware.TenantExplicit(ctx) {
+			return nil, ErrInvalidOAuthState
+		}
+		ctx = tenant.WithTenant(ctx, pending.tenantID)
+	}
+
+	p, err := s.provider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(pending.verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("id_token missing from token response")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+	if idToken.Nonce != pending.nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse id_token claims: %w", err)
+	}
+
+	user, err := s.resolveUser(ctx, provider, idToken.Subject, &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与密码登录一致，启用二次验证的用户需要继续完成验证
+	return issueLoginToken(ctx, s.sessions, user, "oidc:"+provider)
+}
+
+// provider 获取提供方，首次使用时执行OIDC发现
+func (s *oauthService) provider(ctx context.Context, name string) (*oidcProvider, error) {
+	cfg, ok := s.configs[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	s.mu.Lock()
+	p, ok := s.providers[name]
+	s.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	// 发现文档的HTTP请求不应随单个请求的取消而中断后续复用
+	discovered, err := oidc.NewProvider(context.WithoutCancel(ctx), cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider %s: %w", name, err)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	p = &oidcProvider{
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+
+	s.mu.Lock()
+	s.providers[name] = p
+	s.mu.Unlock()
+
+	return p, nil
+}
+
+// resolveUser 根据第三方身份查找或创建当前租户的本地用户
+func (s *oauthService) resolveUser(ctx context.Context, provider, subject string, claims *oidcClaims) (*model.User, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, provider, subject)
+	if err == nil {
+		return s.userRepo.GetByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 仅当提供方确认邮箱已验证时才关联到同邮箱的已有账号
+	var user *model.User
+	if claims.Email != "" && claims.EmailVerified {
+		existing, err := s.userRepo.GetByEmail(ctx, claims.Email)
+		if err == nil {
+			user = existing
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	// 新用户、身份绑定和注册事件一同提交
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if user == nil {
+			created, err := s.createOAuthUser(ctx, provider, subject, claims)
+			if err != nil {
+				return err
+			}
+			user = created
+		}
+
+		return s.identityRepo.Create(ctx, &model.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  subject,
+			Email:    claims.Email,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createOAuthUser 为第三方身份创建本地用户并写入注册事件，密码为不可用的随机值
+func (s *oauthService) createOAuthUser(ctx context.Context, provider, subject string, claims *oidcClaims) (*model.User, error) {
+	username, err := s.uniqueUsername(ctx, usernameCandidate(provider, subject, claims))
+	if err != nil {
+		return nil, err
+	}
+
+	email := claims.Email
+	if email == "" || !claims.EmailVerified {
+		email = fmt.Sprintf("%s@%s.oauth.invalid", username, provider)
+	}
+
+	hashedPassword, err := s.passwords.Hash(randomToken(32))
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     model.RoleUser,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.events.Publish(ctx, userEvent(EventUserRegistered, user, "")); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// uniqueUsername 在候选用户名已被占用时追加随机后缀
+func (s *oauthService) uniqueUsername(ctx context.Context, candidate string) (string, error) {
+	username := candidate
+	for i := 0; i < 5; i++ {
+		_, err := s.userRepo.GetByUsername(ctx, username)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		username = fmt.Sprintf("%s_%s", candidate, randomToken(2))
+	}
+	return "", errors.New("failed to allocate a unique username")
+}
+
+// usernameCandidate 从ID令牌声明推导候选用户名
+func usernameCandidate(provider, subject string, claims *oidcClaims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" && claims.Email != "" {
+		candidate = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	candidate = usernameInvalidChars.ReplaceAllString(candidate, "")
+	if len(candidate) < 3 {
+		candidate = provider + "_" + usernameInvalidChars.ReplaceAllString(subject, "")
+	}
+	if len(candidate) > 40 {
+		candidate = candidate[:40]
+	}
+	return candidate
+}
+
+// purgeExpiredStates 清理过期的授权请求，调用方需持有锁
+func (s *oauthService) purgeExpiredStates() {
+	now := time.Now()
+	for state, pending := range s.states {
+		if now.After(pending.expiresAt) {
+			delete(s.states, state)
+		}
+	}
+}
+
+// randomToken 生成随机十六进制字符串
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/mockoidc"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// setupOAuthTest 启动模拟OIDC提供方并创建第三方登录服务
+func setupOAuthTest(t *testing.T) (*mockoidc.Server, OAuthService, *gorm.DB) {
+	t.Helper()
+
+	database := newTestDB(t)
+	mock, oauthService := newTestOAuthService(t, database)
+	return mock, oauthService, database
+}
+
+// newTestOAuthService 启动模拟OIDC提供方并创建使用database的第三方登录服务
+func newTestOAuthService(t *testing.T, database *gorm.DB) (*mockoidc.Server, OAuthService) {
+	t.Helper()
+
+	mock, err := mockoidc.New("")
+	require.NoError(t, err)
+	issuer := httptest.NewServer(mock)
+	t.Cleanup(issuer.Close)
+
+	oauthService := NewOAuthService(
+		repository.NewUserRepository(database),
+		repository.NewIdentityRepository(database),
+		NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop()),
+		NewEventOutbox(repository.NewTransactor(database), repository.NewOutboxRepository(database)),
+		newTestPasswords(t),
+		config.OAuthConfig{
+			Providers: map[string]config.OIDCProviderConfig{
+				"mock": {
+					IssuerURL:    issuer.URL,
+					ClientID:     "test-client",
+					ClientSecret: "test-secret",
+					RedirectURL:  "http://app.local/api/v1/auth/mock/callback",
+				},
+			},
+		},
+	)
+	return mock, oauthService
+}
+
+// authorize 访问授权地址并返回回调中的state和code
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("state"), location.Query().Get("code")
+}
+
+func TestOAuthService_LoginCreatesAndReusesUser(t *testing.T) {
+	_, oauthService, database := setupOAuthTest(t)
+	ctx := context.Background()
+
+	authURL, err := oauthService.AuthCodeURL(ctx, "mock")
+	require.NoError(t, err)
+	assert.Contains(t, authURL, "code_challenge_method=S256")
+
+	state, code := authorize(t, authURL)
+	result, err := oauthService.HandleCallback(ctx, "mock", state, code)
+	require.NoError(t, err)
+
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "mockuser", claims.Username)
+
+	// 第二次登录复用已绑定的账号
+	authURL, err = oauthService.AuthCodeURL(ctx, "mock")
+	require.NoError(t, err)
+	state, code = authorize(t, authURL)
+	result, err = oauthService.HandleCallback(ctx, "mock", state, code)
+	require.NoError(t, err)
+
+	second, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, claims.UserID, second.UserID)
+
+	var users, identities int64
+	database.Model(&model.User{}).Count(&users)
+	database.Model(&model.UserIdentity{}).Count(&identities)
+	assert.Equal(t, int64(1), users)
+	assert.Equal(t, int64(1), identities)
+
+	// 首次登录创建的用户写入注册事件
+	var events []model.OutboxEvent
+	require.NoError(t, database.Find(&events).Error)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventUserRegistered, events[0].Type)
+	assert.Equal(t, strconv.FormatUint(uint64(claims.UserID), 10), events[0].SubjectID)
+}
+
+func TestOAuthService_LinksVerifiedEmailToExistingUser(t *testing.T) {
+	mock, oauthService, database := setupOAuthTest(t)
+	ctx := context.Background()
+
+	existing := &model.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+	require.NoError(t, database.Create(existing).Error)
+
+	mock.SetUser(mockoidc.User{
+		Subject:           "alice-sub",
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice_oidc",
+	})
+
+	authURL, err := oauthService.AuthCodeURL(ctx, "mock")
+	require.NoError(t, err)
+	state, code := authorize(t, authURL)
+	result, err := oauthService.HandleCallback(ctx, "mock", state, code)
+	require.NoError(t, err)
+
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, claims.UserID)
+	assert.Equal(t, "alice", claims.Username)
+}
+
+func TestOAuthService_UnverifiedEmailCreatesNewUser(t *testing.T) {
+	mock, oauthService, database := setupOAuthTest(t)
+	ctx := context.Background()
+
+	existing := &model.User{Username: "bob", Email: "bob@example.com", Password: "hash"}
+	require.NoError(t, database.Create(existing).Error)
+
+	mock.SetUser(mockoidc.User{
+		Subject:           "bob-sub",
+		Email:             "bob@example.com",
+		EmailVerified:     false,
+		PreferredUsername: "bob",
+	})
+
+	authURL, err := oauthService.AuthCodeURL(ctx, "mock")
+	require.NoError(t, err)
+	state, code := authorize(t, authURL)
+	result, err := oauthService.HandleCallback(ctx, "mock", state, code)
+	require.NoError(t, err)
+
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.NotEqual(t, existing.ID, claims.UserID)
+	assert.NotEqual(t, "bob", claims.Username)
+}
+
+func TestOAuthService_RejectsInvalidState(t *testing.T) {
+	_, oauthService, _ := setupOAuthTest(t)
+	ctx := context.Background()
+
+	authURL, err := oauthService.AuthCodeURL(ctx, "mock")
+	require.NoError(t, err)
+	state, code := authorize(t, authURL)
+
+	_, err = oauthService.HandleCallback(ctx, "mock", "forged-state", code)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+	// state只能使用一次
+	_, err = oauthService.HandleCallback(ctx, "mock", state, code)
+	require.NoError(t, err)
+	_, err = oauthService.HandleCallback(ctx, "mock", state, code)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+}
+
+func TestOAuthService_UnknownProvider(t *testing.T) {
+	_, oauthService, _ := setupOAuthTest(t)
+
+	_, err := oauthService.AuthCodeURL(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestOAuthService_BindsIdentityPerTenant(t *testing.T) {
+	database, _, acme, globex := setupTenants(t)
+	_, oauthService := newTestOAuthService(t, database)
+
+	login := func(ctx context.Context) *middleware.Claims {
+		authURL, err := oauthService.AuthCodeURL(ctx, "mock")
+		require.NoError(t, err)
+		state, code := authorize(t, authURL)
+		result, err := oauthService.HandleCallback(ctx, "mock", state, code)
+		require.NoError(t, err)
+		claims, err := testTokens.ValidateToken(result.Token)
+		require.NoError(t, err)
+		return claims
+	}
+
+	// 同一第三方身份在每个租户中各绑定一个用户
+	acmeCtx := tenant.WithTenant(context.Background(), acme)
+	globexCtx := tenant.WithTenant(context.Background(), globex)
+	acmeUser := login(acmeCtx)
+	globexUser := login(globexCtx)
+	assert.NotEqual(t, acmeUser.UserID, globexUser.UserID)
+	assert.Equal(t, acmeUser.UserID, login(acmeCtx).UserID)
+
+	var identities int64
+	database.WithContext(tenant.Unscoped(context.Background())).Model(&model.UserIdentity{}).Count(&identities)
+	assert.Equal(t, int64(2), identities)
+}
+
+func TestOAuthService_CallbackUsesLoginTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database, tenantService, _, globex := setupTenants(t)
+	_, oauthService := newTestOAuthService(t, database)
+
+	r := gin.New()
+	r.Use(middleware.TenantMiddleware(tenantService, config.TenantConfig{Default: "acme"}, logger.NewNop()))
+	r.GET("/login", func(c *gin.Context) {
+		authURL, err := oauthService.AuthCodeURL(c.Request.Context(), "mock")
+		require.NoError(t, err)
+		c.String(http.StatusOK, authURL)
+	})
+	r.GET("/callback", func(c *gin.Context) {
+		result, err := oauthService.HandleCallback(c.Request.Context(), "mock", c.Query("state"), c.Query("code"))
+		if errors.Is(err, ErrInvalidOAuthState) {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		require.NoError(t, err)
+		c.String(http.StatusOK, result.Token)
+	})
+
+	request := func(path, header string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		if header != "" {
+			req.Header.Set(middleware.DefaultTenantHeader, header)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	callback := func(header string) *httptest.ResponseRecorder {
+		login := request("/login", "globex")
+		require.Equal(t, http.StatusOK, login.Code)
+		state, code := authorize(t, login.Body.String())
+		return request("/callback?state="+url.QueryEscape(state)+"&code="+url.QueryEscape(code), header)
+	}
+
+	// 回调未指定租户时使用发起登录时的租户，而不是默认租户
+	w := callback("")
+	require.Equal(t, http.StatusOK, w.Code)
+	claims, err := testTokens.ValidateToken(w.Body.String())
+	require.NoError(t, err)
+	assert.Equal(t, globex, claims.TenantID)
+
+	var user model.User
+	require.NoError(t, database.WithContext(tenant.WithTenant(context.Background(), globex)).First(&user, claims.UserID).Error)
+
+	// 回调明确指定了其他租户时拒绝
+	w = callback("acme")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = callback("globex")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/password"
+)
+
+// Passwords 密码哈希和强度策略：新密码使用配置的算法，并能校验其他算法和旧参数生成的哈希
+type Passwords struct {
+	hasher password.Hasher
+	policy *password.Policy
+}
+
+// NewPasswords 按配置创建密码哈希和强度策略，配置了泄露密码列表时加载该文件
+func NewPasswords(cfg config.PasswordConfig) (*Passwords, error) {
+	argon := password.NewArgon2id(cfg.Argon2.Memory, cfg.Argon2.Iterations, cfg.Argon2.Parallelism)
+	bcrypt := password.NewBcrypt(cfg.BcryptCost)
+
+	var hasher password.Hasher
+	switch cfg.Algorithm {
+	case "", "argon2id":
+		hasher = password.NewMulti(argon, bcrypt)
+	case "bcrypt":
+		hasher = password.NewMulti(bcrypt, argon)
+	default:
+		return nil, fmt.Errorf("unknown password algorithm %q", cfg.Algorithm)
+	}
+
+	policy := &password.Policy{
+		MinLength:     cfg.Policy.MinLength,
+		RequireUpper:  cfg.Policy.RequireUpper,
+		RequireLower:  cfg.Policy.RequireLower,
+		RequireDigit:  cfg.Policy.RequireDigit,
+		RequireSymbol: cfg.Policy.RequireSymbol,
+	}
+	if cfg.Policy.BreachedList != "" {
+		if err := policy.LoadBreachedList(cfg.Policy.BreachedList); err != nil {
+			return nil, err
+		}
+	}
+	return &Passwords{hasher: hasher, policy: policy}, nil
+}
+
+// Hash 使用当前算法计算密码哈希
+func (p *Passwords) Hash(plain string) (string, error) {
+	return p.hasher.Hash(plain)
+}
+
+// Verify 校验密码，rehash为true表示哈希的算法或参数已过时，应使用Hash重新计算并保存
+func (p *Passwords) Verify(plain, hash string) (rehash bool, err error) {
+	if err := p.hasher.Verify(plain, hash); err != nil {
+		return false, err
+	}
+	return p.hasher.NeedsRehash(hash), nil
+}
+
+// Check 按强度策略检查新密码，userInputs为用户名、邮箱等不能出现在密码中的值
+func (p *Passwords) Check(plain string, userInputs ...string) error {
+	return p.policy.Check(plain, userInputs...)
+}
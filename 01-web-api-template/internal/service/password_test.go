@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/password"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserService_LoginRehashesLegacyPassword(t *testing.T) {
+	database := newTestDB(t)
+	userRepo := repository.NewUserRepository(database)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: string(hashed), Role: model.RoleUser}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	userService := NewUserService(userRepo, sessions, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
+	_, err = userService.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	// 登录成功后bcrypt哈希升级为argon2id，新哈希仍能登录
+	updated, err := userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(updated.Password, "$argon2id$"))
+
+	_, err = userService.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"})
+	assert.NoError(t, err)
+	_, err = userService.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "wrong-password"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestUserService_RegisterChecksPasswordPolicy(t *testing.T) {
+	database := newTestDB(t)
+
+	passwords, err := NewPasswords(config.PasswordConfig{
+		Argon2: config.Argon2Config{Memory: 1024, Iterations: 1, Parallelism: 1},
+		Policy: config.PasswordPolicyConfig{MinLength: 8, RequireDigit: true},
+	})
+	require.NoError(t, err)
+	userService := NewUserService(repository.NewUserRepository(database), nil, NewNopAuditService(), nil, NewNopEventOutbox(), passwords, logger.NewNop())
+
+	_, err = userService.Register(context.Background(), &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "no-digits"})
+	assert.ErrorIs(t, err, password.ErrWeakPassword)
+	_, err = userService.Register(context.Background(), &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "alice-2024"})
+	assert.ErrorIs(t, err, password.ErrWeakPassword)
+
+	registered, err := userService.Register(context.Background(), &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "s3cret-pass"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", registered.Username)
+}
+
+func TestNewPasswords_UnknownAlgorithm(t *testing.T) {
+	_, err := NewPasswords(config.PasswordConfig{Algorithm: "md5"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// touchInterval 最后活跃时间的最小更新间隔，避免每个请求都写库
+const touchInterval = time.Minute
+
+var (
+	// ErrSessionNotFound 会话不存在或不属于当前用户
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionRevoked 会话已注销或已过期
+	ErrSessionRevoked = errors.New("session revoked or expired")
+)
+
+// SessionService 登录会话服务接口
+type SessionService interface {
+	Issue(ctx context.Context, user *model.User, method string) (string, error)
+	// IssueMFAToken 签发等待二次验证的短期令牌，不记录会话
+	IssueMFAToken(user *model.User) (string, error)
+	// ParseToken 校验令牌的签名和有效期，不检查会话状态
+	ParseToken(token string) (*middleware.Claims, error)
+	List(ctx context.Context, userID uint, currentTokenID string) ([]dto.SessionResponse, error)
+	Revoke(ctx context.Context, userID, sessionID uint) error
+	RevokeAll(ctx context.Context, userID uint) error
+	ValidateSession(ctx context.Context, tokenID string) error
+}
+
+// sessionService 登录会话服务实现
+type sessionService struct {
+	sessionRepo repository.SessionRepository
+	audit       AuditService
+	tokens      *middleware.TokenIssuer
+	log         logger.Logger
+}
+
+// NewSessionService 创建登录会话服务实例，令牌由tokens签发
+func NewSessionService(sessionRepo repository.SessionRepository, audit AuditService, tokens *middleware.TokenIssuer, log logger.Logger) SessionService {
+	return &sessionService{sessionRepo: sessionRepo, audit: audit, tokens: tokens, log: log}
+}
+
+// Issue 签发访问令牌并记录对应的会话，method为登录方式，记入审计日志
+func (s *sessionService) Issue(ctx context.Context, user *model.User, method string) (string, error) {
+	token, claims, err := s.tokens.GenerateToken(user.ID, user.Username, user.Role, user.TenantID)
+	if err != nil {
+		return "", err
+	}
+
+	info := requestinfo.FromContext(ctx)
+	now := time.Now()
+	session := &model.Session{
+		UserID:     user.ID,
+		TokenID:    claims.ID,
+		Device:     describeDevice(info.UserAgent),
+		UserAgent:  truncate(info.UserAgent, 255),
+		IP:         info.IP,
+		LastSeenAt: now,
+		ExpiresAt:  claims.ExpiresAt.Time,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", err
+	}
+
+	// 签发访问令牌即视为登录成功，操作者为登录用户本人
+	s.audit.Record(requestinfo.WithUser(ctx, user.ID, user.Username), AuditEntry{
+		Action:     AuditUserLoginSuccess,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff: map[string]interface{}{
+			"method":     method,
+			"session_id": session.ID,
+			"device":     session.Device,
+		},
+	})
+
+	return token, nil
+}
+
+// List 列出用户当前有效的会话，并标记发起请求的会话
+func (s *sessionService) List(ctx context.Context, userID uint, currentTokenID string) ([]dto.SessionResponse, error) {
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, dto.SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.TokenID == currentTokenID,
+		})
+	}
+	return responses, nil
+}
+
+// Revoke 注销用户自己的会话
+func (s *sessionService) Revoke(ctx context.Context, userID, sessionID uint) error {
+	revoked, err := s.sessionRepo.Revoke(ctx, userID, sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditUserSessionRevoke,
+		Success:    true,
+		TargetType: "session",
+		TargetID:   strconv.FormatUint(uint64(sessionID), 10),
+	})
+	return nil
+}
+
+// RevokeAll 注销用户的全部会话，例如账号被删除时；在事务的上下文中调用时随事务提交
+func (s *sessionService) RevokeAll(ctx context.Context, userID uint) error {
+	return s.sessionRepo.RevokeAll(ctx, userID, time.Now())
+}
+
+// IssueMFAToken 签发等待二次验证的短期令牌
+func (s *sessionService) IssueMFAToken(user *model.User) (string, error) {
+	return s.tokens.GenerateMFAToken(user.ID, user.Username, user.TenantID)
+}
+
+// ParseToken 校验令牌的签名和有效期
+func (s *sessionService) ParseToken(token string) (*middleware.Claims, error) {
+	return s.tokens.ValidateToken(token)
+}
+
+// ValidateSession 校验令牌对应的会话仍然有效，并按间隔刷新最后活跃时间。
+// 只有会话已注销、已过期或查询失败时返回错误，刷新活跃时间失败只记录日志
+func (s *sessionService) ValidateSession(ctx context.Context, tokenID string) error {
+	session, err := s.sessionRepo.GetByTokenID(ctx, tokenID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !session.Active(now) {
+		return ErrSessionRevoked
+	}
+
+	if now.Sub(session.LastSeenAt) >= touchInterval {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			s.log.Warn("Failed to update session last seen time", zap.Uint("session_id", session.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// describeDevice 根据User-Agent粗略识别浏览器和操作系统
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		browser = "curl"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "dalvik"):
+		browser = "Android app"
+	case strings.Contains(ua, "cfnetwork"):
+		browser = "iOS app"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		os = "iOS"
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "mac os") || strings.Contains(ua, "macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+// truncate 按字节截断字符串以适配数据库列宽
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+)
+
+func TestSessionService_IssueListRevoke(t *testing.T) {
+	database := newTestDB(t)
+	sessionService := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	user := &model.User{ID: 7, Username: "alice", Role: model.RoleUser}
+
+	ctx := requestinfo.NewContext(context.Background(), requestinfo.Info{
+		IP:        "203.0.113.10",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+	})
+	laptopToken, err := sessionService.Issue(ctx, user, "password")
+	require.NoError(t, err)
+	phoneToken, err := sessionService.Issue(context.Background(), user, "password")
+	require.NoError(t, err)
+
+	laptopClaims, err := testTokens.ValidateToken(laptopToken)
+	require.NoError(t, err)
+	phoneClaims, err := testTokens.ValidateToken(phoneToken)
+	require.NoError(t, err)
+
+	sessions, err := sessionService.List(context.Background(), user.ID, laptopClaims.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	var laptop, phone uint
+	for _, session := range sessions {
+		if session.Current {
+			laptop = session.ID
+			assert.Equal(t, "203.0.113.10", session.IP)
+			assert.Equal(t, "Chrome on macOS", session.Device)
+		} else {
+			phone = session.ID
+		}
+	}
+	require.NotZero(t, laptop)
+	require.NotZero(t, phone)
+
+	// 其他用户不能注销不属于自己的会话
+	assert.ErrorIs(t, sessionService.Revoke(context.Background(), 99, phone), ErrSessionNotFound)
+
+	require.NoError(t, sessionService.Revoke(context.Background(), user.ID, phone))
+	assert.ErrorIs(t, sessionService.ValidateSession(context.Background(), phoneClaims.ID), ErrSessionRevoked)
+	assert.NoError(t, sessionService.ValidateSession(context.Background(), laptopClaims.ID))
+
+	sessions, err = sessionService.List(context.Background(), user.ID, laptopClaims.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func TestSessionService_RevokeAllJoinsTransaction(t *testing.T) {
+	database := newTestDB(t)
+	sessionService := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	transactor := repository.NewTransactor(database)
+	user := &model.User{ID: 7, Username: "alice", Role: model.RoleUser}
+
+	token, err := sessionService.Issue(context.Background(), user, "password")
+	require.NoError(t, err)
+	claims, err := testTokens.ValidateToken(token)
+	require.NoError(t, err)
+
+	// 事务回滚时会话仍然有效
+	err = transactor.Transaction(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, sessionService.RevokeAll(ctx, user.ID))
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	assert.NoError(t, sessionService.ValidateSession(context.Background(), claims.ID))
+
+	err = transactor.Transaction(context.Background(), func(ctx context.Context) error {
+		return sessionService.RevokeAll(ctx, user.ID)
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, sessionService.ValidateSession(context.Background(), claims.ID), ErrSessionRevoked)
+}
+
+// failingTouchSessionRepository 刷新最后活跃时间总是失败
+type failingTouchSessionRepository struct {
+	repository.SessionRepository
+}
+
+func (failingTouchSessionRepository) Touch(context.Context, uint, time.Time) error {
+	return errors.New("database is read-only")
+}
+
+func TestSessionService_ValidateSessionIgnoresTouchError(t *testing.T) {
+	database := newTestDB(t)
+	sessionRepo := failingTouchSessionRepository{SessionRepository: repository.NewSessionRepository(database)}
+	sessionService := NewSessionService(sessionRepo, NewNopAuditService(), testTokens, logger.NewNop())
+	user := &model.User{ID: 7, Username: "alice", Role: model.RoleUser}
+
+	token, err := sessionService.Issue(context.Background(), user, "password")
+	require.NoError(t, err)
+	claims, err := testTokens.ValidateToken(token)
+	require.NoError(t, err)
+	require.NoError(t, database.Model(&model.Session{}).Where("token_id = ?", claims.ID).
+		Update("last_seen_at", time.Now().Add(-time.Hour)).Error)
+
+	// 刷新活跃时间失败不影响有效的会话
+	assert.NoError(t, sessionService.ValidateSession(context.Background(), claims.ID))
+
+	require.NoError(t, sessionService.RevokeAll(context.Background(), user.ID))
+	assert.ErrorIs(t, sessionService.ValidateSession(context.Background(), claims.ID), ErrSessionRevoked)
+}
+
+func TestJWTAuthMiddleware_RejectsRevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database := newTestDB(t)
+	sessionService := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+
+	token, err := sessionService.Issue(context.Background(), &model.User{ID: 1, Username: "alice"}, "password")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/protected", middleware.JWTAuthMiddleware(testTokens, sessionService, logger.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(token string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(token))
+
+	// 未记录会话的令牌（例如等待二次验证令牌）同样被拒绝
+	mfaToken, err := testTokens.GenerateMFAToken(1, "alice", 0)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, request(mfaToken))
+
+	sessions, err := sessionService.List(context.Background(), 1, "")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.NoError(t, sessionService.Revoke(context.Background(), 1, sessions[0].ID))
+
+	assert.Equal(t, http.StatusUnauthorized, request(token))
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// tenantCacheTTL 租户标识到ID映射的缓存时间，租户创建后不会修改标识
+const tenantCacheTTL = 10 * time.Minute
+
+// tenantSlugPattern 租户标识需要能作为子域名使用
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+var (
+	// ErrInvalidTenantSlug 租户标识格式不正确
+	ErrInvalidTenantSlug = errors.New("tenant slug may only contain lowercase letters, digits and hyphens")
+	// ErrTenantExists 租户标识已被使用
+	ErrTenantExists = errors.New("tenant slug already exists")
+)
+
+// TenantService 租户服务接口
+type TenantService interface {
+	ResolveTenant(ctx context.Context, slug string) (uint, error)
+	Create(ctx context.Context, req *dto.CreateTenantRequest) (*dto.TenantResponse, error)
+	List(ctx context.Context) ([]dto.TenantResponse, error)
+}
+
+// tenantService 租户服务实现
+type tenantService struct {
+	tenantRepo repository.TenantRepository
+	slugs      cache.Cache
+}
+
+// NewTenantService 创建租户服务实例
+func NewTenantService(tenantRepo repository.TenantRepository) TenantService {
+	return &tenantService{tenantRepo: tenantRepo, slugs: cache.NewLRU(1000, tenantCacheTTL)}
+}
+
+// ResolveTenant 根据标识查找租户ID，每个请求都会调用，结果缓存在内存中
+func (s *tenantService) ResolveTenant(ctx context.Context, slug string) (uint, error) {
+	if value, ok := s.slugs.Get(slug); ok {
+		return value.(uint), nil
+	}
+
+	tenant, err := s.tenantRepo.GetBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, middleware.ErrTenantNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.slugs.Set(slug, tenant.ID)
+	return tenant.ID, nil
+}
+
+// Create 创建租户
+func (s *tenantService) Create(ctx context.Context, req *dto.CreateTenantRequest) (*dto.TenantResponse, error) {
+	if !tenantSlugPattern.MatchString(req.Slug) {
+		return nil, ErrInvalidTenantSlug
+	}
+	if _, err := s.tenantRepo.GetBySlug(req.Slug); err == nil {
+		return nil, ErrTenantExists
+	}
+
+	tenant := &model.Tenant{Slug: req.Slug, Name: req.Name}
+	if err := s.tenantRepo.Create(tenant); err != nil {
+		return nil, err
+	}
+
+	response := toTenantResponse(tenant)
+	return &response, nil
+}
+
+// List 列出全部租户
+func (s *tenantService) List(ctx context.Context) ([]dto.TenantResponse, error) {
+	tenants, err := s.tenantRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.TenantResponse, 0, len(tenants))
+	for i := range tenants {
+		responses = append(responses, toTenantResponse(&tenants[i]))
+	}
+	return responses, nil
+}
+
+// toTenantResponse 转换租户信息响应
+func toTenantResponse(tenant *model.Tenant) dto.TenantResponse {
+	return dto.TenantResponse{
+		ID:        tenant.ID,
+		Slug:      tenant.Slug,
+		Name:      tenant.Name,
+		CreatedAt: tenant.CreatedAt,
+	}
+}
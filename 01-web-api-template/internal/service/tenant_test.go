@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/middleware"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// setupTenants 创建启用租户隔离的数据库和两个租户
+func setupTenants(t *testing.T) (*gorm.DB, TenantService, uint, uint) {
+	t.Helper()
+
+	database := newTestDB(t)
+	require.NoError(t, tenant.RegisterCallbacks(database))
+
+	tenantService := NewTenantService(repository.NewTenantRepository(database))
+	acme, err := tenantService.Create(context.Background(), &dto.CreateTenantRequest{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	globex, err := tenantService.Create(context.Background(), &dto.CreateTenantRequest{Slug: "globex", Name: "Globex"})
+	require.NoError(t, err)
+	return database, tenantService, acme.ID, globex.ID
+}
+
+func TestTenantScope_IsolatesUsers(t *testing.T) {
+	database, _, acme, globex := setupTenants(t)
+	userRepo := repository.NewUserRepository(database)
+	userService := NewUserService(userRepo, nil, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
+
+	acmeCtx := tenant.WithTenant(context.Background(), acme)
+	globexCtx := tenant.WithTenant(context.Background(), globex)
+
+	// 用户名和邮箱只在租户内唯一
+	req := &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"}
+	acmeAlice, err := userService.Register(acmeCtx, req)
+	require.NoError(t, err)
+	globexAlice, err := userService.Register(globexCtx, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, acmeAlice.ID, globexAlice.ID)
+
+	_, err = userService.Register(acmeCtx, req)
+	assert.Error(t, err)
+
+	user, err := userRepo.GetByUsername(acmeCtx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, acmeAlice.ID, user.ID)
+	assert.Equal(t, acme, user.TenantID)
+
+	// 不能读取其他租户的用户
+	_, err = userRepo.GetByID(acmeCtx, globexAlice.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	require.NoError(t, userRepo.Delete(acmeCtx, globexAlice.ID))
+	_, err = userRepo.GetByID(globexCtx, globexAlice.ID)
+	assert.NoError(t, err)
+
+	// 不能把其他租户的用户写入当前租户
+	user.FirstName = "Mallory"
+	assert.ErrorIs(t, userRepo.Update(globexCtx, user), tenant.ErrTenantMismatch)
+	user, err = userRepo.GetByID(acmeCtx, acmeAlice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, user.FirstName)
+
+	// 未指定租户时拒绝查询，跨租户访问需要显式声明
+	_, err = userRepo.GetByID(context.Background(), acmeAlice.ID)
+	assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+	_, err = userRepo.GetByID(tenant.Unscoped(context.Background()), globexAlice.ID)
+	assert.NoError(t, err)
+}
+
+func TestTenantService_Create(t *testing.T) {
+	_, tenantService, _, _ := setupTenants(t)
+
+	_, err := tenantService.Create(context.Background(), &dto.CreateTenantRequest{Slug: "acme", Name: "Acme 2"})
+	assert.ErrorIs(t, err, ErrTenantExists)
+	_, err = tenantService.Create(context.Background(), &dto.CreateTenantRequest{Slug: "Bad.Slug", Name: "Bad"})
+	assert.ErrorIs(t, err, ErrInvalidTenantSlug)
+
+	_, err = tenantService.ResolveTenant(context.Background(), "initech")
+	assert.ErrorIs(t, err, middleware.ErrTenantNotFound)
+}
+
+func TestTenantMiddleware_ResolvesTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database, tenantService, acme, globex := setupTenants(t)
+	sessionService := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+
+	r := gin.New()
+	r.Use(middleware.TenantMiddleware(tenantService, config.TenantConfig{
+		Header:     middleware.DefaultTenantHeader,
+		BaseDomain: "api.example.com",
+		Default:    "acme",
+	}, logger.NewNop()))
+	report := func(c *gin.Context) {
+		tenantID, _ := tenant.FromContext(c.Request.Context())
+		c.String(http.StatusOK, strconv.FormatUint(uint64(tenantID), 10))
+	}
+	r.GET("/public", report)
+	r.GET("/private", middleware.JWTAuthMiddleware(testTokens, sessionService, logger.NewNop()), report)
+
+	request := func(path, host, header, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set(middleware.DefaultTenantHeader, header)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	acmeID := strconv.FormatUint(uint64(acme), 10)
+	globexID := strconv.FormatUint(uint64(globex), 10)
+
+	// 子域名优先于请求头，都没有时使用默认租户
+	assert.Equal(t, acmeID, request("/public", "localhost:8080", "", "").Body.String())
+	assert.Equal(t, globexID, request("/public", "globex.api.example.com", "acme", "").Body.String())
+	assert.Equal(t, globexID, request("/public", "localhost", "globex", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, request("/public", "localhost", "initech", "").Code)
+
+	// 访问令牌绑定签发时的租户
+	ctx := tenant.WithTenant(context.Background(), globex)
+	token, err := sessionService.Issue(ctx, &model.User{ID: 1, Username: "alice", TenantID: globex}, "password")
+	require.NoError(t, err)
+
+	assert.Equal(t, globexID, request("/private", "localhost", "", token).Body.String())
+	assert.Equal(t, http.StatusOK, request("/private", "localhost", "globex", token).Code)
+	assert.Equal(t, http.StatusForbidden, request("/private", "acme.api.example.com", "", token).Code)
+}
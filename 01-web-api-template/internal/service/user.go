@@ -1,46 +1,88 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"go-practical-roadmap/01-web-api-template/internal/api/dto"
-	"go-practical-roadmap/01-web-api-template/internal/middleware"
 	"go-practical-roadmap/01-web-api-template/internal/model"
 	"go-practical-roadmap/01-web-api-template/internal/repository"
-	"golang.org/x/crypto/bcrypt"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // UserService 用户服务接口
 type UserService interface {
-	Register(req *dto.RegisterRequest) (*dto.UserProfileResponse, error)
-	Login(req *dto.LoginRequest) (string, error)
-	GetUserByID(id uint) (*dto.UserProfileResponse, error)
-	GetUserByUsername(username string) (*dto.UserProfileResponse, error)
+	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.UserProfileResponse, error)
+	Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
+	GetUserByID(ctx context.Context, id uint) (*dto.UserProfileResponse, error)
+	GetUserByUsername(ctx context.Context, username string) (*dto.UserProfileResponse, error)
+	ChangePassword(ctx context.Context, userID uint, req *dto.ChangePasswordRequest) error
+	ChangeEmail(ctx context.Context, userID uint, req *dto.ChangeEmailRequest) (*dto.UserProfileResponse, error)
+	ChangeRole(ctx context.Context, userID uint, role string) (*dto.UserProfileResponse, error)
+	// DeactivateAccount 用户停用自己的账号，需要验证当前密码，并注销用户的全部会话
+	DeactivateAccount(ctx context.Context, userID uint, req *dto.DeactivateAccountRequest) error
+	// ReactivateUser 管理员重新启用已停用的账号
+	ReactivateUser(ctx context.Context, userID uint) (*dto.UserProfileResponse, error)
+	// ResetPassword 管理员重置密码，无需原密码，并注销用户的全部会话
+	ResetPassword(ctx context.Context, userID uint, newPassword string) error
+	DeleteUser(ctx context.Context, userID uint) error
+	ListDeleted(ctx context.Context, query *dto.DeletedUserQuery) (*dto.DeletedUserListResponse, error)
+	RestoreUser(ctx context.Context, userID uint) (*dto.UserProfileResponse, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
 }
 
+// purgeBatchSize 每次永久删除的最大用户数，避免长事务
+const purgeBatchSize = 100
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrIncorrectPassword 原密码错误
+	ErrIncorrectPassword = errors.New("old password is incorrect")
+	// ErrUserConflict 恢复的用户名或邮箱已被其他用户占用
+	ErrUserConflict = errors.New("username or email is already taken by another user")
+	// ErrEmailExists 邮箱已被其他用户使用
+	ErrEmailExists = errors.New("email already exists")
+	// ErrAccountDeactivated 账号已停用，不能登录
+	ErrAccountDeactivated = errors.New("account is deactivated")
+)
+
 // userService 用户服务实现
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	sessions  SessionService
+	audit     AuditService
+	avatars   AvatarService
+	events    EventOutbox
+	passwords *Passwords
+	log       logger.Logger
 }
 
-// NewUserService 创建用户服务实例
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+// NewUserService 创建用户服务实例，avatars为nil时用户信息中不包含头像地址。
+// 注册、修改邮箱和删除用户时通过events在同一事务中写入领域事件，密码由passwords哈希和检查强度
+func NewUserService(userRepo repository.UserRepository, sessions SessionService, audit AuditService, avatars AvatarService, events EventOutbox, passwords *Passwords, log logger.Logger) UserService {
+	return &userService{userRepo: userRepo, sessions: sessions, audit: audit, avatars: avatars, events: events, passwords: passwords, log: log}
 }
 
 // Register 用户注册
-func (s *userService) Register(req *dto.RegisterRequest) (*dto.UserProfileResponse, error) {
+func (s *userService) Register(ctx context.Context, req *dto.RegisterRequest) (*dto.UserProfileResponse, error) {
 	// 检查用户是否已存在
-	if _, err := s.userRepo.GetByUsername(req.Username); err == nil {
+	if _, err := s.userRepo.GetByUsername(ctx, req.Username); err == nil {
 		return nil, errors.New("username already exists")
 	}
 
-	if _, err := s.userRepo.GetByEmail(req.Email); err == nil {
-		return nil, errors.New("email already exists")
+	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
+		return nil, ErrEmailExists
 	}
 
-	// 密码加密
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// 检查密码强度后加密
+	if err := s.passwords.Check(req.Password, req.Username, req.Email); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.passwords.Hash(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -49,74 +91,505 @@ func (s *userService) Register(req *dto.RegisterRequest) (*dto.UserProfileRespon
 	user := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
+		Role:     model.RoleUser,
 	}
 
-	// 保存到数据库
-	if err := s.userRepo.Create(user); err != nil {
+	// 保存到数据库，同时写入注册事件
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, userEvent(EventUserRegistered, user, ""))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// 返回用户信息（不包含密码）
-	response := &dto.UserProfileResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-	}
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditUserRegister,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff: map[string]interface{}{
+			"username": Change{New: user.Username},
+			"email":    Change{New: user.Email},
+		},
+	})
 
-	return response, nil
+	// 返回用户信息（不包含密码）
+	return s.profileResponse(user), nil
 }
 
 // Login 用户登录
-func (s *userService) Login(req *dto.LoginRequest) (string, error) {
+func (s *userService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
 	// 获取用户
-	user, err := s.userRepo.GetByUsername(req.Username)
+	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
-		return "", errors.New("invalid username or password")
+		s.recordLoginFailure(ctx, "", req.Username, "unknown user")
+		return nil, ErrInvalidCredentials
 	}
 
 	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return "", errors.New("invalid username or password")
+	rehash, err := s.passwords.Verify(req.Password, user.Password)
+	if err != nil {
+		s.recordLoginFailure(ctx, strconv.FormatUint(uint64(user.ID), 10), req.Username, "wrong password")
+		return nil, ErrInvalidCredentials
+	}
+	// 密码正确后才提示账号已停用，避免泄露账号状态
+	if !user.IsActive {
+		s.recordLoginFailure(ctx, strconv.FormatUint(uint64(user.ID), 10), req.Username, "account deactivated")
+		return nil, ErrAccountDeactivated
+	}
+	if rehash {
+		s.rehashPassword(ctx, user, req.Password)
 	}
 
 	// 生成JWT令牌
-	token, err := middleware.GenerateToken(user.ID, user.Username)
+	return issueLoginToken(ctx, s.sessions, user, "password")
+}
+
+// rehashPassword 使用当前的算法和参数重新计算过时的密码哈希，失败时不影响登录，下次登录时重试
+func (s *userService) rehashPassword(ctx context.Context, user *model.User, plain string) {
+	hashedPassword, err := s.passwords.Hash(plain)
+	if err == nil {
+		user.Password = hashedPassword
+		err = s.userRepo.Update(ctx, user)
+	}
 	if err != nil {
-		return "", err
+		s.log.Warn("Failed to rehash password", zap.Uint("user_id", user.ID), zap.Error(err))
 	}
+}
 
-	return token, nil
+// recordLoginFailure 记录登录失败事件
+func (s *userService) recordLoginFailure(ctx context.Context, targetID, username, reason string) {
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditUserLoginFailure,
+		TargetType: AuditTargetUser,
+		TargetID:   targetID,
+		Diff: map[string]interface{}{
+			"username": username,
+			"reason":   reason,
+		},
+	})
 }
 
-// GetUserByID 根据ID获取用户
-func (s *userService) GetUserByID(id uint) (*dto.UserProfileResponse, error) {
-	user, err := s.userRepo.GetByID(id)
+// ChangePassword 修改当前用户的密码
+func (s *userService) ChangePassword(ctx context.Context, userID uint, req *dto.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	entry := AuditEntry{
+		Action:     AuditUserPasswordChange,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+	}
+
+	if _, err := s.passwords.Verify(req.OldPassword, user.Password); err != nil {
+		s.audit.Record(ctx, entry)
+		return ErrIncorrectPassword
+	}
+
+	if err := s.passwords.Check(req.NewPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	hashedPassword, err := s.passwords.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	// 密码哈希不写入审计日志
+	entry.Success = true
+	entry.Diff = map[string]interface{}{"password": Change{Old: "[redacted]", New: "[redacted]"}}
+	s.audit.Record(ctx, entry)
+	return nil
+}
+
+// ChangeEmail 验证当前密码后修改邮箱
+func (s *userService) ChangeEmail(ctx context.Context, userID uint, req *dto.ChangeEmailRequest) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	response := &dto.UserProfileResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+	entry := AuditEntry{
+		Action:     AuditUserEmailChange,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+	}
+
+	if _, err := s.passwords.Verify(req.Password, user.Password); err != nil {
+		s.audit.Record(ctx, entry)
+		return nil, ErrIncorrectPassword
+	}
+	if user.Email == req.Email {
+		return s.profileResponse(user), nil
+	}
+	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
+		return nil, ErrEmailExists
+	}
+
+	oldEmail := user.Email
+	user.Email = req.Email
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, userEvent(EventUserEmailChanged, user, oldEmail))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Success = true
+	entry.Diff = map[string]interface{}{"email": Change{Old: oldEmail, New: user.Email}}
+	s.audit.Record(ctx, entry)
+	return s.profileResponse(user), nil
+}
+
+// ChangeRole 管理员修改用户角色，角色变化时注销全部会话，用户需重新登录以获得新角色的令牌
+func (s *userService) ChangeRole(ctx context.Context, userID uint, role string) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldRole := user.Role
+	if oldRole != role {
+		user.Role = role
+		// 角色变更与注销会话在同一事务中，避免角色已变更而旧令牌仍然有效
+		err := s.events.Transaction(ctx, func(ctx context.Context) error {
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				return err
+			}
+			return s.sessions.RevokeAll(ctx, user.ID)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditAdminRoleChange,
+			Success:    true,
+			TargetType: AuditTargetUser,
+			TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+			Diff:       map[string]interface{}{"role": Change{Old: oldRole, New: role}},
+		})
+	}
+
+	return s.profileResponse(user), nil
+}
+
+// DeactivateAccount 验证当前密码后停用账号，停用后注销全部会话，已签发的令牌随之失效
+func (s *userService) DeactivateAccount(ctx context.Context, userID uint, req *dto.DeactivateAccountRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	entry := AuditEntry{
+		Action:     AuditUserDeactivate,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+	}
+
+	if _, err := s.passwords.Verify(req.Password, user.Password); err != nil {
+		s.audit.Record(ctx, entry)
+		return ErrIncorrectPassword
+	}
+
+	now := time.Now()
+	user.IsActive = false
+	user.DeactivatedAt = &now
+	user.DeactivationReason = req.Reason
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, userEvent(EventUserDeactivated, user, ""))
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	entry.Success = true
+	entry.Diff = map[string]interface{}{
+		"is_active": Change{Old: true, New: false},
+		"reason":    req.Reason,
+	}
+	s.audit.Record(ctx, entry)
+	return nil
+}
+
+// ReactivateUser 管理员重新启用已停用的账号，账号未停用时不做修改
+func (s *userService) ReactivateUser(ctx context.Context, userID uint) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsActive {
+		return s.profileResponse(user), nil
+	}
+
+	user.IsActive = true
+	user.DeactivatedAt = nil
+	user.DeactivationReason = ""
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, userEvent(EventUserReactivated, user, ""))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminUserReactivate,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff:       map[string]interface{}{"is_active": Change{Old: false, New: true}},
+	})
+	return s.profileResponse(user), nil
+}
+
+// ResetPassword 管理员重置用户密码并注销其全部会话
+func (s *userService) ResetPassword(ctx context.Context, userID uint, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.passwords.Check(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	hashedPassword, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.sessions.RevokeAll(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminPasswordReset,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff:       map[string]interface{}{"password": Change{Old: "[redacted]", New: "[redacted]"}},
+	})
+	return nil
+}
+
+// DeleteUser 软删除用户并注销其全部会话
+func (s *userService) DeleteUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, userEvent(EventUserDeleted, user, ""))
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminUserDelete,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Diff: map[string]interface{}{
+			"username": Change{Old: user.Username},
+			"email":    Change{Old: user.Email},
+		},
+	})
+	return nil
+}
+
+// ListDeleted 分页列出已软删除的用户
+func (s *userService) ListDeleted(ctx context.Context, query *dto.DeletedUserQuery) (*dto.DeletedUserListResponse, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	users, total, err := s.userRepo.ListDeleted(ctx, limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.DeletedUserResponse, 0, len(users))
+	for _, user := range users {
+		items = append(items, dto.DeletedUserResponse{
+			ID:        user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			Role:      user.Role,
+			DeletedAt: user.DeletedAt.Time,
+		})
+	}
+
+	return &dto.DeletedUserListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// RestoreUser 恢复已软删除的用户，用户名或邮箱已被重新注册时拒绝恢复
+func (s *userService) RestoreUser(ctx context.Context, userID uint) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetDeletedByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByUsername(ctx, user.Username); err == nil {
+		return nil, ErrUserConflict
+	}
+	if _, err := s.userRepo.GetByEmail(ctx, user.Email); err == nil {
+		return nil, ErrUserConflict
 	}
 
-	return response, nil
+	if err := s.userRepo.Restore(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminUserRestore,
+		Success:    true,
+		TargetType: AuditTargetUser,
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+	})
+
+	return s.profileResponse(user), nil
+}
+
+// PurgeDeleted 永久删除在指定时间之前软删除的用户及其头像文件，返回删除数量
+func (s *userService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	for {
+		users, err := s.userRepo.PurgeDeleted(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, user := range users {
+			// 数据库记录已删除，头像文件删除失败只记录日志
+			if s.avatars != nil && user.AvatarKey != "" {
+				s.avatars.Delete(ctx, user.AvatarKey)
+			}
+			s.audit.Record(ctx, AuditEntry{
+				Action:     AuditUserPurge,
+				Success:    true,
+				TargetType: AuditTargetUser,
+				TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+			})
+		}
+		purged += len(users)
+
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+	}
+}
+
+// issueLoginToken 签发登录令牌并记录会话，启用二次验证的用户只获得等待验证令牌，已停用的账号不签发
+func issueLoginToken(ctx context.Context, sessions SessionService, user *model.User, method string) (*dto.LoginResponse, error) {
+	if !user.IsActive {
+		return nil, ErrAccountDeactivated
+	}
+	if user.MFAEnabled {
+		mfaToken, err := sessions.IssueMFAToken(user)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	token, err := sessions.Issue(ctx, user, method)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{Token: token}, nil
+}
+
+// userEvent 构造用户相关的领域事件
+func userEvent(eventType string, user *model.User, oldEmail string) DomainEvent {
+	return DomainEvent{
+		Type:      eventType,
+		SubjectID: strconv.FormatUint(uint64(user.ID), 10),
+		Data: dto.UserEventData{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			OldEmail: oldEmail,
+			Reason:   user.DeactivationReason,
+		},
+	}
+}
+
+// GetUserByID 根据ID获取用户
+func (s *userService) GetUserByID(ctx context.Context, id uint) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.profileResponse(user), nil
 }
 
 // GetUserByUsername 根据用户名获取用户
-func (s *userService) GetUserByUsername(username string) (*dto.UserProfileResponse, error) {
-	user, err := s.userRepo.GetByUsername(username)
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (*dto.UserProfileResponse, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.profileResponse(user), nil
+}
+
+// profileResponse 转换用户信息响应（不包含密码）
+func (s *userService) profileResponse(user *model.User) *dto.UserProfileResponse {
 	response := &dto.UserProfileResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
 	}
-
-	return response, nil
-}
\ No newline at end of file
+	if s.avatars != nil {
+		response.AvatarURL, response.AvatarURLs = s.avatars.URLs(user.AvatarKey)
+	}
+	return response
+}
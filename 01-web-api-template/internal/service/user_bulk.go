@@ -0,0 +1,551 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/validation"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// importRequiredColumns 导入CSV必须包含的列
+var importRequiredColumns = []string{"username", "email", "password"}
+
+var (
+	// ErrImportTooLarge 导入文件超过大小限制
+	ErrImportTooLarge = errors.New("import file is too large")
+	// ErrInvalidImportFile 导入文件不是有效的CSV或缺少必需的列
+	ErrInvalidImportFile = errors.New("import file must be a CSV with username, email and password columns")
+	// ErrImportQueueFull 等待执行的导入任务过多
+	ErrImportQueueFull = errors.New("too many pending import jobs, please retry later")
+)
+
+// UserImportRequest 导入用户请求
+type UserImportRequest struct {
+	Filename string
+	File     io.Reader
+	DryRun   bool
+	Locale   string
+}
+
+// BulkUserService 批量导入导出用户服务接口
+type BulkUserService interface {
+	Export(ctx context.Context, fn func(record *dto.UserExportRecord) error) error
+	Import(ctx context.Context, req *UserImportRequest) (*dto.UserImportJobResponse, error)
+	GetImportJob(ctx context.Context, id uint) (*dto.UserImportJobResponse, error)
+	ImportErrors(ctx context.Context, id uint) ([]dto.UserImportErrorResponse, error)
+	Close() error
+}
+
+// importTask 等待后台执行的导入任务，ctx携带发起请求的租户和操作者
+type importTask struct {
+	ctx   context.Context
+	jobID uint
+	data  []byte
+}
+
+// bulkUserService 批量导入导出用户服务实现，导入任务由单个后台协程依次执行
+type bulkUserService struct {
+	userRepo   repository.UserRepository
+	importRepo repository.UserImportRepository
+	audit      AuditService
+	events     EventOutbox
+	passwords  *Passwords
+	log        logger.Logger
+	validate   *validator.Validate
+	cfg        config.ImportConfig
+
+	mu     sync.RWMutex
+	closed bool
+	tasks  chan importTask
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewBulkUserService 创建批量导入导出用户服务实例并启动后台导入协程，导入的密码与注册使用相同的强度策略，
+// 导入的用户与注册一样通过events写入注册事件
+func NewBulkUserService(userRepo repository.UserRepository, importRepo repository.UserImportRepository, audit AuditService, events EventOutbox, passwords *Passwords, cfg config.ImportConfig, log logger.Logger) BulkUserService {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 10 << 20
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 16
+	}
+
+	// 与请求参数校验使用相同的binding规则和本地化文案
+	validate := validator.New()
+	validate.SetTagName("binding")
+	if err := validation.Register(validate); err != nil {
+		log.Error("Failed to register import validation translations", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &bulkUserService{
+		userRepo:   userRepo,
+		importRepo: importRepo,
+		audit:      audit,
+		events:     events,
+		passwords:  passwords,
+		log:        log,
+		validate:   validate,
+		cfg:        cfg,
+		tasks:      make(chan importTask, cfg.QueueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Export 流式遍历当前租户的全部用户
+func (s *bulkUserService) Export(ctx context.Context, fn func(record *dto.UserExportRecord) error) error {
+	return s.userRepo.Iterate(ctx, func(user *model.User) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(&dto.UserExportRecord{
+			ID:         user.ID,
+			Username:   user.Username,
+			Email:      user.Email,
+			FirstName:  user.FirstName,
+			LastName:   user.LastName,
+			Role:       user.Role,
+			IsActive:   user.IsActive,
+			MFAEnabled: user.MFAEnabled,
+			CreatedAt:  user.CreatedAt,
+		})
+	})
+}
+
+// Import 校验文件格式后创建导入任务，任务在后台执行
+func (s *bulkUserService) Import(ctx context.Context, req *UserImportRequest) (*dto.UserImportJobResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(req.File, s.cfg.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.cfg.MaxSize {
+		return nil, ErrImportTooLarge
+	}
+	if _, err := importColumns(csv.NewReader(bytes.NewReader(data))); err != nil {
+		return nil, err
+	}
+
+	job := &model.UserImportJob{
+		CreatedBy: requestinfo.FromContext(ctx).UserID,
+		Filename:  truncate(req.Filename, 255),
+		Locale:    validation.ResolveLocale(req.Locale, validation.DefaultLocale),
+		DryRun:    req.DryRun,
+		Status:    model.ImportStatusPending,
+	}
+	if err := s.importRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// 任务在请求结束后继续执行，只保留上下文中的租户和操作者信息
+	task := importTask{ctx: context.WithoutCancel(ctx), jobID: job.ID, data: data}
+
+	s.mu.RLock()
+	queued := false
+	if !s.closed {
+		select {
+		case s.tasks <- task:
+			queued = true
+		default:
+		}
+	}
+	s.mu.RUnlock()
+
+	if !queued {
+		s.finishJob(ctx, job, nil, ErrImportQueueFull.Error())
+		return nil, ErrImportQueueFull
+	}
+
+	return toImportJobResponse(job), nil
+}
+
+// GetImportJob 获取当前租户的导入任务状态
+func (s *bulkUserService) GetImportJob(ctx context.Context, id uint) (*dto.UserImportJobResponse, error) {
+	job, err := s.importRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toImportJobResponse(job), nil
+}
+
+// ImportErrors 获取导入任务的逐行错误报告
+func (s *bulkUserService) ImportErrors(ctx context.Context, id uint) ([]dto.UserImportErrorResponse, error) {
+	// 先按租户确认任务归属，错误记录本身不区分租户
+	job, err := s.importRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rowErrors, err := s.importRepo.ListErrors(job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.UserImportErrorResponse, 0, len(rowErrors))
+	for _, rowError := range rowErrors {
+		responses = append(responses, dto.UserImportErrorResponse{
+			Row:     rowError.Row,
+			Field:   rowError.Field,
+			Message: rowError.Message,
+		})
+	}
+	return responses, nil
+}
+
+// Close 停止接收新任务，中断正在执行的任务并将排队中的任务标记为失败
+func (s *bulkUserService) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		s.cancel()
+		close(s.tasks)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// run 后台协程：依次执行导入任务
+func (s *bulkUserService) run() {
+	defer close(s.done)
+
+	for task := range s.tasks {
+		ctx, stop := context.WithCancel(task.ctx)
+		release := context.AfterFunc(s.ctx, stop)
+		s.process(ctx, task)
+		release()
+		stop()
+	}
+}
+
+// importBatchRow 等待写入的一行数据
+type importBatchRow struct {
+	row  int
+	data dto.ImportUserRow
+}
+
+// importRun 单个导入任务的执行状态
+type importRun struct {
+	job       *model.UserImportJob
+	errors    []model.UserImportError
+	failed    map[int]bool
+	usernames map[string]int
+	emails    map[string]int
+	batch     []importBatchRow
+}
+
+// addError 记录行错误
+func (r *importRun) addError(row int, field, message string) {
+	r.errors = append(r.errors, model.UserImportError{
+		JobID:   r.job.ID,
+		Row:     row,
+		Field:   field,
+		Message: truncate(message, 500),
+	})
+	r.failed[row] = true
+}
+
+// process 执行导入任务：逐行校验，校验通过的行按批写入
+func (s *bulkUserService) process(ctx context.Context, task importTask) {
+	// 服务关闭时任务状态仍需更新为失败
+	job, err := s.importRepo.GetJob(context.WithoutCancel(ctx), task.jobID)
+	if err != nil {
+		s.log.Error("Failed to load import job", zap.Uint("job_id", task.jobID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	job.Status = model.ImportStatusRunning
+	job.StartedAt = &now
+	if err := s.importRepo.UpdateJob(context.WithoutCancel(ctx), job); err != nil {
+		s.log.Error("Failed to start import job", zap.Uint("job_id", job.ID), zap.Error(err))
+		return
+	}
+
+	run := &importRun{
+		job:       job,
+		failed:    make(map[int]bool),
+		usernames: make(map[string]int),
+		emails:    make(map[string]int),
+	}
+	failure := s.importRows(ctx, run, task.data)
+	if failure == "" {
+		failure = s.flush(ctx, run)
+	}
+	s.finishJob(ctx, job, run, failure)
+}
+
+// importRows 读取并校验全部数据行，返回导致任务失败的原因
+func (s *bulkUserService) importRows(ctx context.Context, run *importRun, data []byte) string {
+	reader := csv.NewReader(bytes.NewReader(data))
+	columns, err := importColumns(reader)
+	if err != nil {
+		return err.Error()
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return "import interrupted"
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			return ""
+		}
+		row, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				row = parseErr.StartLine
+			}
+			return fmt.Sprintf("malformed CSV at line %d: %v", row, err)
+		}
+
+		run.job.TotalRows++
+		if run.job.TotalRows > s.cfg.MaxRows {
+			return fmt.Sprintf("import file exceeds the limit of %d rows", s.cfg.MaxRows)
+		}
+
+		raw := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		value := func(column string) string {
+			return strings.TrimSpace(raw(column))
+		}
+		s.checkRow(ctx, run, row, dto.ImportUserRow{
+			RegisterRequest: dto.RegisterRequest{
+				Username: value("username"),
+				Email:    value("email"),
+				Password: raw("password"),
+			},
+			FirstName: value("first_name"),
+			LastName:  value("last_name"),
+		})
+
+		if len(run.batch) >= s.cfg.BatchSize {
+			if failure := s.flush(ctx, run); failure != "" {
+				return failure
+			}
+		}
+	}
+}
+
+// checkRow 按注册规则校验一行数据，并检查用户名、邮箱是否与文件中其他行或已有用户重复
+func (s *bulkUserService) checkRow(ctx context.Context, run *importRun, row int, data dto.ImportUserRow) {
+	if err := s.validate.Struct(&data); err != nil {
+		message, fields := validation.Translate(err, run.job.Locale)
+		if len(fields) == 0 {
+			run.addError(row, "", message)
+		}
+		for _, field := range fields {
+			run.addError(row, field.Field, field.Message)
+		}
+		return
+	}
+	if err := s.passwords.Check(data.Password, data.Username, data.Email); err != nil {
+		run.addError(row, "password", err.Error())
+		return
+	}
+
+	email := strings.ToLower(data.Email)
+	if first, ok := run.usernames[data.Username]; ok {
+		run.addError(row, "username", fmt.Sprintf("duplicates username in row %d", first))
+	}
+	if first, ok := run.emails[email]; ok {
+		run.addError(row, "email", fmt.Sprintf("duplicates email in row %d", first))
+	}
+	if run.failed[row] {
+		return
+	}
+	run.usernames[data.Username] = row
+	run.emails[email] = row
+
+	if _, err := s.userRepo.GetByUsername(ctx, data.Username); err == nil {
+		run.addError(row, "username", "username already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		run.addError(row, "", err.Error())
+	}
+	if _, err := s.userRepo.GetByEmail(ctx, data.Email); err == nil {
+		run.addError(row, "email", "email already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		run.addError(row, "", err.Error())
+	}
+	if run.failed[row] {
+		return
+	}
+
+	run.batch = append(run.batch, importBatchRow{row: row, data: data})
+}
+
+// flush 写入当前批次并为每个用户写入注册事件，试运行时只统计数量。
+// 批量写入失败时（例如与并发注册冲突）逐行写入，定位失败的行
+func (s *bulkUserService) flush(ctx context.Context, run *importRun) string {
+	batch := run.batch
+	run.batch = nil
+	if len(batch) == 0 {
+		return ""
+	}
+	if run.job.DryRun {
+		run.job.Imported += len(batch)
+		return ""
+	}
+	if ctx.Err() != nil {
+		return "import interrupted"
+	}
+
+	users := make([]model.User, 0, len(batch))
+	for _, item := range batch {
+		hashedPassword, err := s.passwords.Hash(item.data.Password)
+		if err != nil {
+			return err.Error()
+		}
+		users = append(users, model.User{
+			Username:  item.data.Username,
+			Email:     item.data.Email,
+			Password:  hashedPassword,
+			FirstName: item.data.FirstName,
+			LastName:  item.data.LastName,
+			Role:      model.RoleUser,
+		})
+	}
+
+	err := s.events.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.CreateBatch(ctx, users); err != nil {
+			return err
+		}
+		for i := range users {
+			if err := s.events.Publish(ctx, userEvent(EventUserRegistered, &users[i], "")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		run.job.Imported += len(users)
+		return ""
+	}
+
+	for i := range users {
+		// 回滚的批量写入可能已为用户分配了ID
+		users[i].ID = 0
+		err := s.events.Transaction(ctx, func(ctx context.Context) error {
+			if err := s.userRepo.Create(ctx, &users[i]); err != nil {
+				return err
+			}
+			return s.events.Publish(ctx, userEvent(EventUserRegistered, &users[i], ""))
+		})
+		if err != nil {
+			run.addError(batch[i].row, "", "failed to create user: username or email already exists")
+			continue
+		}
+		run.job.Imported++
+	}
+	return ""
+}
+
+// finishJob 保存行错误和任务结果，failure不为空时任务失败
+func (s *bulkUserService) finishJob(ctx context.Context, job *model.UserImportJob, run *importRun, failure string) {
+	// 任务被中断时仍需写入结果
+	ctx = context.WithoutCancel(ctx)
+
+	if run != nil {
+		if err := s.importRepo.CreateErrors(run.errors); err != nil {
+			s.log.Error("Failed to save import errors", zap.Uint("job_id", job.ID), zap.Error(err))
+		}
+		job.Failed = len(run.failed)
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = model.ImportStatusCompleted
+	if failure != "" {
+		job.Status = model.ImportStatusFailed
+		job.Error = truncate(failure, 500)
+	}
+	if err := s.importRepo.UpdateJob(ctx, job); err != nil {
+		s.log.Error("Failed to update import job", zap.Uint("job_id", job.ID), zap.Error(err))
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminUserImport,
+		Success:    failure == "",
+		TargetType: "user_import",
+		TargetID:   strconv.FormatUint(uint64(job.ID), 10),
+		Diff: map[string]interface{}{
+			"dry_run":  job.DryRun,
+			"total":    job.TotalRows,
+			"imported": job.Imported,
+			"failed":   job.Failed,
+		},
+	})
+}
+
+// importColumns 读取CSV表头，返回列名到下标的映射
+func importColumns(reader *csv.Reader) (map[string]int, error) {
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range importRequiredColumns {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrInvalidImportFile
+		}
+	}
+	return columns, nil
+}
+
+// toImportJobResponse 转换导入任务状态响应
+func toImportJobResponse(job *model.UserImportJob) *dto.UserImportJobResponse {
+	return &dto.UserImportJobResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		DryRun:     job.DryRun,
+		Filename:   job.Filename,
+		TotalRows:  job.TotalRows,
+		Imported:   job.Imported,
+		Failed:     job.Failed,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/requestinfo"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// setupBulkTest 创建启用租户隔离、写入发件箱的批量导入服务，返回acme和globex租户管理员的上下文
+func setupBulkTest(t *testing.T) (BulkUserService, repository.UserRepository, *gorm.DB, context.Context, context.Context) {
+	t.Helper()
+
+	database, _, acme, globex := setupTenants(t)
+	userRepo := repository.NewUserRepository(database)
+	events := NewEventOutbox(repository.NewTransactor(database), repository.NewOutboxRepository(database))
+	bulkService := NewBulkUserService(userRepo, repository.NewUserImportRepository(database), NewNopAuditService(),
+		events, newTestPasswords(t), config.ImportConfig{BatchSize: 2}, logger.NewNop())
+	t.Cleanup(func() { bulkService.Close() })
+
+	admin := requestinfo.NewContext(context.Background(), requestinfo.Info{UserID: 1, Username: "admin"})
+	return bulkService, userRepo, database, tenant.WithTenant(admin, acme), tenant.WithTenant(admin, globex)
+}
+
+// waitImportJob 等待导入任务执行结束
+func waitImportJob(t *testing.T, bulkService BulkUserService, ctx context.Context, id uint) *dto.UserImportJobResponse {
+	t.Helper()
+
+	var job *dto.UserImportJobResponse
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = bulkService.GetImportJob(ctx, id)
+		require.NoError(t, err)
+		return job.Status == model.ImportStatusCompleted || job.Status == model.ImportStatusFailed
+	}, 10*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestBulkUserService_ImportReportsRowErrors(t *testing.T) {
+	bulkService, userRepo, database, acmeCtx, globexCtx := setupBulkTest(t)
+	require.NoError(t, userRepo.Create(acmeCtx, &model.User{Username: "taken", Email: "taken@example.com", Password: "x"}))
+
+	csv := strings.Join([]string{
+		"username,email,password,first_name",
+		"alice,alice@example.com,password123,Alice",
+		"bob,not-an-email,password123,Bob",
+		"carol,carol@example.com,123,Carol",
+		"alice,alice2@example.com,password123,",
+		"taken,taken2@example.com,password123,",
+		"dave,dave@example.com,password123,Dave",
+		"erin,erin@example.com,password123,Erin",
+	}, "\n")
+
+	job, err := bulkService.Import(acmeCtx, &UserImportRequest{Filename: "users.csv", File: strings.NewReader(csv)})
+	require.NoError(t, err)
+	assert.Equal(t, model.ImportStatusPending, job.Status)
+
+	job = waitImportJob(t, bulkService, acmeCtx, job.ID)
+	assert.Equal(t, model.ImportStatusCompleted, job.Status)
+	assert.Equal(t, 7, job.TotalRows)
+	assert.Equal(t, 3, job.Imported)
+	assert.Equal(t, 4, job.Failed)
+
+	rowErrors, err := bulkService.ImportErrors(acmeCtx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, rowErrors, 4)
+	assert.Equal(t, dto.UserImportErrorResponse{Row: 3, Field: "email", Message: "email must be a valid email address"}, rowErrors[0])
+	assert.Equal(t, 4, rowErrors[1].Row)
+	assert.Equal(t, "password", rowErrors[1].Field)
+	assert.Equal(t, dto.UserImportErrorResponse{Row: 5, Field: "username", Message: "duplicates username in row 2"}, rowErrors[2])
+	assert.Equal(t, dto.UserImportErrorResponse{Row: 6, Field: "username", Message: "username already exists"}, rowErrors[3])
+
+	user, err := userRepo.GetByUsername(acmeCtx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.FirstName)
+	assert.Equal(t, model.RoleUser, user.Role)
+
+	// 导入的用户和任务都属于发起导入的租户
+	_, err = userRepo.GetByUsername(globexCtx, "alice")
+	assert.Error(t, err)
+	_, err = bulkService.GetImportJob(globexCtx, job.ID)
+	assert.Error(t, err)
+
+	var exported []string
+	require.NoError(t, bulkService.Export(acmeCtx, func(record *dto.UserExportRecord) error {
+		exported = append(exported, record.Username)
+		return nil
+	}))
+	assert.Equal(t, []string{"taken", "alice", "dave", "erin"}, exported)
+
+	// 导入的用户与注册一样写入注册事件
+	var events []model.OutboxEvent
+	require.NoError(t, database.WithContext(acmeCtx).Order("id").Find(&events).Error)
+	require.Len(t, events, 3)
+	for _, event := range events {
+		assert.Equal(t, EventUserRegistered, event.Type)
+	}
+}
+
+func TestBulkUserService_DryRun(t *testing.T) {
+	bulkService, userRepo, database, acmeCtx, _ := setupBulkTest(t)
+
+	csv := "Username,Email,Password\nalice,alice@example.com,password123\nbob,bob@example.com,password123\n"
+	job, err := bulkService.Import(acmeCtx, &UserImportRequest{File: strings.NewReader(csv), DryRun: true})
+	require.NoError(t, err)
+
+	job = waitImportJob(t, bulkService, acmeCtx, job.ID)
+	assert.Equal(t, model.ImportStatusCompleted, job.Status)
+	assert.True(t, job.DryRun)
+	assert.Equal(t, 2, job.Imported)
+
+	_, err = userRepo.GetByUsername(acmeCtx, "alice")
+	assert.Error(t, err)
+
+	var events int64
+	require.NoError(t, database.WithContext(acmeCtx).Model(&model.OutboxEvent{}).Count(&events).Error)
+	assert.Zero(t, events)
+}
+
+func TestBulkUserService_RejectsInvalidFile(t *testing.T) {
+	bulkService, _, _, acmeCtx, _ := setupBulkTest(t)
+
+	_, err := bulkService.Import(acmeCtx, &UserImportRequest{File: strings.NewReader("name,mail\nalice,alice@example.com\n")})
+	assert.ErrorIs(t, err, ErrInvalidImportFile)
+
+	_, err = bulkService.Import(acmeCtx, &UserImportRequest{File: strings.NewReader("")})
+	assert.ErrorIs(t, err, ErrInvalidImportFile)
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/cache"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/db"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// countingUserRepository 统计实际数据库查询次数，可选地让查询等待放行
+type countingUserRepository struct {
+	repository.UserRepository
+	queries atomic.Int32
+	gate    chan struct{}
+}
+
+func (r *countingUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	r.queries.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+	return r.UserRepository.GetByID(ctx, id)
+}
+
+func (r *countingUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.queries.Add(1)
+	return r.UserRepository.GetByUsername(ctx, username)
+}
+
+func TestCachedUserRepository_ReadThroughAndInvalidate(t *testing.T) {
+	counting := &countingUserRepository{UserRepository: repository.NewUserRepository(newTestDB(t))}
+	userCache := cache.NewLRU(100, time.Minute)
+	userRepo := repository.NewCachedUserRepository(counting, userCache)
+	userService := NewUserService(userRepo, nil, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
+
+	profile, err := userService.Register(context.Background(), &dto.RegisterRequest{
+		Username: "alice", Email: "alice@example.com", Password: "password123",
+	})
+	require.NoError(t, err)
+	counting.queries.Store(0)
+
+	for i := 0; i < 3; i++ {
+		user, err := userRepo.GetByID(context.Background(), profile.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Username)
+	}
+	assert.Equal(t, int32(1), counting.queries.Load())
+
+	// 按用户名查询复用按ID缓存的用户
+	_, err = userRepo.GetByUsername(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), counting.queries.Load())
+
+	// 修改返回值不影响缓存内容
+	user, _ := userRepo.GetByID(context.Background(), profile.ID)
+	user.Username = "mallory"
+	user, _ = userRepo.GetByID(context.Background(), profile.ID)
+	assert.Equal(t, "alice", user.Username)
+
+	// 更新后重新从数据库读取
+	user.Role = model.RoleAdmin
+	require.NoError(t, userRepo.Update(context.Background(), user))
+	user, err = userRepo.GetByID(context.Background(), profile.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, user.Role)
+	assert.Equal(t, int32(2), counting.queries.Load())
+
+	// 删除后不能再从缓存读到用户
+	require.NoError(t, userRepo.Delete(context.Background(), profile.ID))
+	_, err = userRepo.GetByID(context.Background(), profile.ID)
+	assert.Error(t, err)
+	_, err = userRepo.GetByUsername(context.Background(), "alice")
+	assert.Error(t, err)
+
+	stats := userCache.Stats()
+	assert.NotZero(t, stats.Hits)
+	assert.NotZero(t, stats.Misses)
+}
+
+// laggingReplicaUserRepository 模拟复制延迟：不要求读主库的查询返回快照中的旧数据
+type laggingReplicaUserRepository struct {
+	repository.UserRepository
+	stale map[string]model.User
+}
+
+func (r *laggingReplicaUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil || db.UsesPrimary(ctx) {
+		return user, err
+	}
+	stale := r.stale[user.Username]
+	return &stale, nil
+}
+
+func (r *laggingReplicaUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	if stale, ok := r.stale[username]; ok && !db.UsesPrimary(ctx) {
+		return &stale, nil
+	}
+	return r.UserRepository.GetByUsername(ctx, username)
+}
+
+func TestCachedUserRepository_ReplicaReadAfterInvalidateThenLogin(t *testing.T) {
+	database := newTestDB(t)
+	replica := &laggingReplicaUserRepository{UserRepository: repository.NewUserRepository(database), stale: map[string]model.User{}}
+	userRepo := repository.NewCachedUserRepository(replica, cache.NewLRU(100, time.Minute))
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	userService := NewUserService(userRepo, sessions, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
+
+	profile, err := userService.Register(db.WithPrimary(context.Background()), &dto.RegisterRequest{
+		Username: "alice", Email: "alice@example.com", Password: "password123",
+	})
+	require.NoError(t, err)
+	before, err := replica.UserRepository.GetByID(context.Background(), profile.ID)
+	require.NoError(t, err)
+	replica.stale["alice"] = *before
+
+	// 修改密码使缓存失效，副本仍是旧密码
+	err = userService.ChangePassword(db.WithPrimary(context.Background()), profile.ID, &dto.ChangePasswordRequest{
+		OldPassword: "password123", NewPassword: "password456",
+	})
+	require.NoError(t, err)
+
+	// 读副本的请求未命中缓存，从主库加载，不把旧数据写入缓存
+	user, err := userRepo.GetByUsername(db.WithReadYourWrites(context.Background()), "alice")
+	require.NoError(t, err)
+	assert.NotEqual(t, before.Password, user.Password)
+	user, err = userRepo.GetByID(db.WithReadYourWrites(context.Background()), profile.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.Password, user.Password)
+
+	// 登录读主库，只接受新密码
+	_, err = userService.Login(db.WithPrimary(context.Background()), &dto.LoginRequest{Username: "alice", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = userService.Login(db.WithPrimary(context.Background()), &dto.LoginRequest{Username: "alice", Password: "password456"})
+	require.NoError(t, err)
+}
+
+func TestCachedUserRepository_InvalidatesAfterCommit(t *testing.T) {
+	// 文件数据库允许事务提交前由其他连接读取已提交的数据
+	database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, database.AutoMigrate(&model.User{}))
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: model.RoleUser}
+	require.NoError(t, database.Create(user).Error)
+	userRepo := repository.NewCachedUserRepository(repository.NewUserRepository(database), cache.NewLRU(100, time.Minute))
+	transactor := repository.NewTransactor(database)
+
+	err = transactor.Transaction(context.Background(), func(ctx context.Context) error {
+		updated := *user
+		updated.Role = model.RoleAdmin
+		require.NoError(t, userRepo.Update(ctx, &updated))
+
+		// 事务中读到未提交的数据，但不写入缓存
+		found, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.RoleAdmin, found.Role)
+
+		// 提交前其他请求读到并缓存旧数据
+		found, err = userRepo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.RoleUser, found.Role)
+		return nil
+	})
+	require.NoError(t, err)
+
+	found, err := userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, found.Role)
+
+	// 回滚的事务不影响缓存中已提交的数据
+	err = transactor.Transaction(context.Background(), func(ctx context.Context) error {
+		updated := *found
+		updated.Role = model.RoleUser
+		require.NoError(t, userRepo.Update(ctx, &updated))
+		_, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	found, err = userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, found.Role)
+}
+
+func TestCachedUserRepository_CoalescesConcurrentMisses(t *testing.T) {
+	database := newTestDB(t)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: model.RoleUser}
+	require.NoError(t, database.Create(user).Error)
+
+	counting := &countingUserRepository{
+		UserRepository: repository.NewUserRepository(database),
+		gate:           make(chan struct{}),
+	}
+	userRepo := repository.NewCachedUserRepository(counting, cache.NewLRU(100, time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := userRepo.GetByID(context.Background(), user.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", found.Username)
+		}()
+	}
+
+	// 等待第一个查询开始后放行，其余请求合并到同一次查询
+	require.Eventually(t, func() bool { return counting.queries.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(counting.gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), counting.queries.Load())
+}
+
+func TestCachedUserRepository_SharedLoadSurvivesCallerCancel(t *testing.T) {
+	database := newTestDB(t)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: model.RoleUser}
+	require.NoError(t, database.Create(user).Error)
+
+	counting := &countingUserRepository{
+		UserRepository: repository.NewUserRepository(database),
+		gate:           make(chan struct{}),
+	}
+	userRepo := repository.NewCachedUserRepository(counting, cache.NewLRU(100, time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := userRepo.GetByID(ctx, user.ID)
+		firstErr <- err
+	}()
+	require.Eventually(t, func() bool { return counting.queries.Load() == 1 }, time.Second, time.Millisecond)
+
+	secondErr := make(chan error, 1)
+	go func() {
+		found, err := userRepo.GetByID(context.Background(), user.ID)
+		if err == nil {
+			assert.Equal(t, "alice", found.Username)
+		}
+		secondErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// 第一个调用方取消只结束自己的等待，合并的查询继续完成
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(counting.gate)
+	require.NoError(t, <-secondErr)
+	assert.Equal(t, int32(1), counting.queries.Load())
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+)
+
+func TestUserService_DeactivateAndReactivate(t *testing.T) {
+	userService, _, database, acme, _ := setupEventTest(t, config.WebhookConfig{})
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	ctx := tenant.WithTenant(context.Background(), acme)
+	login := &dto.LoginRequest{Username: "alice", Password: "password123"}
+
+	user, err := userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+	result, err := userService.Login(ctx, login)
+	require.NoError(t, err)
+
+	err = userService.DeactivateAccount(ctx, user.ID, &dto.DeactivateAccountRequest{Password: "wrong"})
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	require.NoError(t, userService.DeactivateAccount(ctx, user.ID, &dto.DeactivateAccountRequest{Password: "password123", Reason: "taking a break"}))
+
+	// 停用后已签发的令牌立即失效，也不能再登录
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.ErrorIs(t, sessions.ValidateSession(context.Background(), claims.ID), ErrSessionRevoked)
+	_, err = userService.Login(ctx, login)
+	assert.ErrorIs(t, err, ErrAccountDeactivated)
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	var stored model.User
+	require.NoError(t, database.WithContext(ctx).First(&stored, user.ID).Error)
+	assert.False(t, stored.IsActive)
+	assert.NotNil(t, stored.DeactivatedAt)
+	assert.Equal(t, "taking a break", stored.DeactivationReason)
+
+	_, err = userService.ReactivateUser(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = userService.Login(ctx, login)
+	assert.NoError(t, err)
+
+	var reactivated model.User
+	require.NoError(t, database.WithContext(ctx).First(&reactivated, user.ID).Error)
+	assert.True(t, reactivated.IsActive)
+	assert.Nil(t, reactivated.DeactivatedAt)
+	assert.Empty(t, reactivated.DeactivationReason)
+
+	// 重复启用不产生事件
+	_, err = userService.ReactivateUser(ctx, user.ID)
+	require.NoError(t, err)
+
+	var events []model.OutboxEvent
+	require.NoError(t, database.WithContext(ctx).Order("id").Find(&events).Error)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventUserDeactivated, events[1].Type)
+	assert.Equal(t, EventUserReactivated, events[2].Type)
+
+	var data dto.UserEventData
+	require.NoError(t, json.Unmarshal([]byte(events[1].Payload), &data))
+	assert.Equal(t, "taking a break", data.Reason)
+}
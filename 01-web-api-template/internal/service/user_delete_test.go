@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// setupUserDeleteTest 创建基于内存数据库的用户服务和会话服务
+func setupUserDeleteTest(t *testing.T) (UserService, SessionService, *gorm.DB) {
+	t.Helper()
+
+	database := newTestDB(t)
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	return NewUserService(repository.NewUserRepository(database), sessions, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop()), sessions, database
+}
+
+func TestUserService_DeleteAllowsReRegistration(t *testing.T) {
+	userService, sessions, _ := setupUserDeleteTest(t)
+	ctx := context.Background()
+	req := &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"}
+
+	original, err := userService.Register(ctx, req)
+	require.NoError(t, err)
+	result, err := userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	require.NoError(t, userService.DeleteUser(ctx, original.ID))
+
+	// 删除后原有会话立即失效
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.ErrorIs(t, sessions.ValidateSession(context.Background(), claims.ID), ErrSessionRevoked)
+
+	deleted, err := userService.ListDeleted(ctx, &dto.DeletedUserQuery{})
+	require.NoError(t, err)
+	require.Len(t, deleted.Items, 1)
+	assert.Equal(t, "alice", deleted.Items[0].Username)
+
+	// 已删除用户不再占用用户名和邮箱
+	replacement, err := userService.Register(ctx, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, original.ID, replacement.ID)
+
+	_, err = userService.RestoreUser(ctx, original.ID)
+	assert.ErrorIs(t, err, ErrUserConflict)
+
+	require.NoError(t, userService.DeleteUser(ctx, replacement.ID))
+	restored, err := userService.RestoreUser(ctx, original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, restored.ID)
+
+	_, err = userService.RestoreUser(ctx, original.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123"})
+	assert.NoError(t, err)
+}
+
+func TestUserService_PurgeDeleted(t *testing.T) {
+	userService, _, database := setupUserDeleteTest(t)
+	ctx := context.Background()
+
+	old, err := userService.Register(ctx, &dto.RegisterRequest{Username: "old", Email: "old@example.com", Password: "password123"})
+	require.NoError(t, err)
+	recent, err := userService.Register(ctx, &dto.RegisterRequest{Username: "recent", Email: "recent@example.com", Password: "password123"})
+	require.NoError(t, err)
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "old", Password: "password123"})
+	require.NoError(t, err)
+
+	require.NoError(t, userService.DeleteUser(ctx, old.ID))
+	require.NoError(t, userService.DeleteUser(ctx, recent.ID))
+	require.NoError(t, database.Unscoped().Model(&model.User{}).Where("id = ?", old.ID).
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+
+	purged, err := userService.PurgeDeleted(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	var count int64
+	database.Unscoped().Model(&model.User{}).Where("id = ?", old.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Model(&model.Session{}).Where("user_id = ?", old.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 未超过保留期的用户仍可恢复
+	_, err = userService.RestoreUser(ctx, recent.ID)
+	assert.NoError(t, err)
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	userService, sessions, _ := setupUserDeleteTest(t)
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+	result, err := userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	require.NoError(t, userService.ResetPassword(ctx, user.ID, "newpassword456"))
+
+	// 重置后原有会话立即失效，只能使用新密码登录
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+	assert.ErrorIs(t, sessions.ValidateSession(context.Background(), claims.ID), ErrSessionRevoked)
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "newpassword456"})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, userService.ResetPassword(ctx, 999, "newpassword456"), gorm.ErrRecordNotFound)
+}
+
+func TestUserService_ChangeRoleRevokesSessions(t *testing.T) {
+	userService, sessions, _ := setupUserDeleteTest(t)
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+	result, err := userService.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	claims, err := testTokens.ValidateToken(result.Token)
+	require.NoError(t, err)
+
+	// 角色未变化时保留会话
+	_, err = userService.ChangeRole(ctx, user.ID, model.RoleUser)
+	require.NoError(t, err)
+	assert.NoError(t, sessions.ValidateSession(context.Background(), claims.ID))
+
+	// 角色变化后原有令牌中的角色已过时，会话立即失效
+	_, err = userService.ChangeRole(ctx, user.ID, model.RoleAdmin)
+	require.NoError(t, err)
+	assert.ErrorIs(t, sessions.ValidateSession(context.Background(), claims.ID), ErrSessionRevoked)
+}
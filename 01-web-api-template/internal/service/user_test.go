@@ -1,13 +1,16 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go-practical-roadmap/01-web-api-template/internal/api/dto"
 	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
 )
 
 // MockUserRepository 模拟用户仓库
@@ -15,12 +18,22 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *model.User) error {
+func (m *MockUserRepository) Create(ctx context.Context, user *model.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByID(id uint) (*model.User, error) {
+func (m *MockUserRepository) CreateBatch(ctx context.Context, users []model.User) error {
+	args := m.Called(users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Iterate(ctx context.Context, fn func(user *model.User) error) error {
+	args := m.Called(fn)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	args := m.Called(id)
 	result := args.Get(0)
 	if result == nil {
@@ -29,7 +42,7 @@ func (m *MockUserRepository) GetByID(id uint) (*model.User, error) {
 	return result.(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByUsername(username string) (*model.User, error) {
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	args := m.Called(username)
 	result := args.Get(0)
 	if result == nil {
@@ -38,7 +51,7 @@ func (m *MockUserRepository) GetByUsername(username string) (*model.User, error)
 	return result.(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	args := m.Called(email)
 	result := args.Get(0)
 	if result == nil {
@@ -47,17 +60,17 @@ func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
 	return result.(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *model.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, user *model.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id uint) error {
+func (m *MockUserRepository) Delete(ctx context.Context, id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) List(limit, offset int) ([]model.User, error) {
+func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]model.User, error) {
 	args := m.Called(limit, offset)
 	result := args.Get(0)
 	if result == nil {
@@ -66,10 +79,57 @@ func (m *MockUserRepository) List(limit, offset int) ([]model.User, error) {
 	return result.([]model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetDeletedByID(ctx context.Context, id uint) (*model.User, error) {
+	args := m.Called(id)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListDeleted(ctx context.Context, limit, offset int) ([]model.User, int64, error) {
+	args := m.Called(limit, offset)
+	result := args.Get(0)
+	if result == nil {
+		return nil, 0, args.Error(2)
+	}
+	return result.([]model.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) ([]model.User, error) {
+	args := m.Called(before, limit)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.([]model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) RecordMFAFailure(ctx context.Context, id uint, maxAttempts int, lockUntil time.Time) (bool, error) {
+	args := m.Called(id, maxAttempts, lockUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ResetMFAFailures(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func TestUserService_Register_Success(t *testing.T) {
 	// 准备测试数据
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, nil, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
 
 	req := &dto.RegisterRequest{
 		Username: "testuser",
@@ -83,7 +143,7 @@ func TestUserService_Register_Success(t *testing.T) {
 	mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(nil)
 
 	// 执行测试
-	result, err := userService.Register(req)
+	result, err := userService.Register(context.Background(), req)
 
 	// 验证结果
 	assert.NoError(t, err)
@@ -98,7 +158,7 @@ func TestUserService_Register_Success(t *testing.T) {
 func TestUserService_Register_UsernameExists(t *testing.T) {
 	// 准备测试数据
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, nil, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
 
 	req := &dto.RegisterRequest{
 		Username: "existinguser",
@@ -116,7 +176,7 @@ func TestUserService_Register_UsernameExists(t *testing.T) {
 	mockRepo.On("GetByUsername", "existinguser").Return(existingUser, nil)
 
 	// 执行测试
-	result, err := userService.Register(req)
+	result, err := userService.Register(context.Background(), req)
 
 	// 验证结果
 	assert.Error(t, err)
@@ -130,7 +190,7 @@ func TestUserService_Register_UsernameExists(t *testing.T) {
 func TestUserService_GetUserByID_Success(t *testing.T) {
 	// 准备测试数据
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, nil, NewNopAuditService(), nil, NewNopEventOutbox(), newTestPasswords(t), logger.NewNop())
 
 	expectedUser := &model.User{
 		ID:       1,
@@ -142,7 +202,7 @@ func TestUserService_GetUserByID_Success(t *testing.T) {
 	mockRepo.On("GetByID", uint(1)).Return(expectedUser, nil)
 
 	// 执行测试
-	result, err := userService.GetUserByID(1)
+	result, err := userService.GetUserByID(context.Background(), 1)
 
 	// 验证结果
 	assert.NoError(t, err)
@@ -0,0 +1,546 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// Webhook请求头
+const (
+	// WebhookSignatureHeader 签名，格式为 t=<unix秒>,v1=<hex(HMAC-SHA256(secret, "<t>.<body>"))>
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookEventHeader 事件类型
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookDeliveryHeader 投递记录ID，重试时保持不变
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+)
+
+var (
+	// ErrInvalidWebhookURL 订阅地址不是http(s)地址
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+	// ErrWebhookAddressBlocked 订阅地址解析到内网、回环或链路本地地址
+	ErrWebhookAddressBlocked = errors.New("webhook address is a private, loopback or link-local ip")
+	// ErrInvalidWebhookEvent 订阅了不存在的事件类型
+	ErrInvalidWebhookEvent = errors.New("unknown webhook event type")
+)
+
+// WebhookService Webhook订阅管理和领域事件投递
+type WebhookService interface {
+	Create(ctx context.Context, req *dto.CreateWebhookRequest) (*dto.WebhookSecretResponse, error)
+	List(ctx context.Context) ([]dto.WebhookResponse, error)
+	Update(ctx context.Context, id uint, req *dto.UpdateWebhookRequest) (*dto.WebhookResponse, error)
+	Delete(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, id uint, query *dto.WebhookDeliveryQuery) (*dto.WebhookDeliveryListResponse, error)
+	// Dispatch 将发件箱中的新事件分发为投递记录，并投递到期的记录，返回本次尝试投递的数量。
+	// 处理全部租户的数据，ctx需使用 tenant.Unscoped
+	Dispatch(ctx context.Context) (int, error)
+}
+
+// webhookService Webhook服务实现
+type webhookService struct {
+	tx       repository.Transactor
+	outbox   repository.OutboxRepository
+	webhooks repository.WebhookRepository
+	audit    AuditService
+	client   *http.Client
+	cfg      config.WebhookConfig
+	log      logger.Logger
+}
+
+// NewWebhookService 创建Webhook服务
+func NewWebhookService(tx repository.Transactor, outbox repository.OutboxRepository, webhooks repository.WebhookRepository, audit AuditService, cfg config.WebhookConfig, log logger.Logger) WebhookService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = cfg.Backoff
+	}
+
+	// 拨号时检查实际连接的地址，避免订阅地址（或其DNS解析结果）指向内网服务；
+	// 不使用环境变量中的代理，确保检查的是订阅地址本身
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   webhookDialControl(allowedNetworks(cfg.AllowedNetworks, log)),
+	}).DialContext
+
+	return &webhookService{
+		tx:       tx,
+		outbox:   outbox,
+		webhooks: webhooks,
+		audit:    audit,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			// 重定向视为投递失败，避免请求被转发到订阅地址以外的地方
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+		log: log,
+	}
+}
+
+// Create 创建订阅并生成签名密钥
+func (s *webhookService) Create(ctx context.Context, req *dto.CreateWebhookRequest) (*dto.WebhookSecretResponse, error) {
+	events, err := normalizeWebhook(req.URL, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &model.Webhook{
+		URL:         req.URL,
+		Secret:      "whsec_" + randomToken(32),
+		Events:      events,
+		Description: req.Description,
+		Active:      true,
+	}
+	if err := s.webhooks.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminWebhookCreate,
+		Success:    true,
+		TargetType: AuditTargetWebhook,
+		TargetID:   strconv.FormatUint(uint64(webhook.ID), 10),
+		Diff: map[string]interface{}{
+			"url":    Change{New: webhook.URL},
+			"events": Change{New: webhook.EventTypes()},
+		},
+	})
+
+	return &dto.WebhookSecretResponse{WebhookResponse: *webhookResponse(webhook), Secret: webhook.Secret}, nil
+}
+
+// List 列出当前租户的订阅
+func (s *webhookService) List(ctx context.Context) ([]dto.WebhookResponse, error) {
+	webhooks, err := s.webhooks.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		items = append(items, *webhookResponse(&webhooks[i]))
+	}
+	return items, nil
+}
+
+// Update 修改订阅地址、事件和启用状态，签名密钥保持不变
+func (s *webhookService) Update(ctx context.Context, id uint, req *dto.UpdateWebhookRequest) (*dto.WebhookResponse, error) {
+	events, err := normalizeWebhook(req.URL, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.webhooks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := map[string]interface{}{}
+	if webhook.URL != req.URL {
+		diff["url"] = Change{Old: webhook.URL, New: req.URL}
+		webhook.URL = req.URL
+	}
+	if webhook.Events != events {
+		diff["events"] = Change{Old: webhook.EventTypes(), New: (&model.Webhook{Events: events}).EventTypes()}
+		webhook.Events = events
+	}
+	if req.Active != nil && webhook.Active != *req.Active {
+		diff["active"] = Change{Old: webhook.Active, New: *req.Active}
+		webhook.Active = *req.Active
+	}
+	webhook.Description = req.Description
+
+	if err := s.webhooks.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	if len(diff) > 0 {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     AuditAdminWebhookUpdate,
+			Success:    true,
+			TargetType: AuditTargetWebhook,
+			TargetID:   strconv.FormatUint(uint64(webhook.ID), 10),
+			Diff:       diff,
+		})
+	}
+	return webhookResponse(webhook), nil
+}
+
+// Delete 删除订阅，未完成的投递不再重试
+func (s *webhookService) Delete(ctx context.Context, id uint) error {
+	if err := s.webhooks.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     AuditAdminWebhookDelete,
+		Success:    true,
+		TargetType: AuditTargetWebhook,
+		TargetID:   strconv.FormatUint(uint64(id), 10),
+	})
+	return nil
+}
+
+// ListDeliveries 分页列出订阅的投递记录
+func (s *webhookService) ListDeliveries(ctx context.Context, id uint, query *dto.WebhookDeliveryQuery) (*dto.WebhookDeliveryListResponse, error) {
+	if _, err := s.webhooks.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	deliveries, total, err := s.webhooks.ListDeliveries(ctx, id, limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		item := dto.WebhookDeliveryResponse{
+			ID:             delivery.ID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			ResponseStatus: delivery.ResponseStatus,
+			Error:          delivery.Error,
+			LastAttemptAt:  delivery.LastAttemptAt,
+			DeliveredAt:    delivery.DeliveredAt,
+			CreatedAt:      delivery.CreatedAt,
+		}
+		if delivery.Status == model.DeliveryStatusPending {
+			nextAttemptAt := delivery.NextAttemptAt
+			item.NextAttemptAt = &nextAttemptAt
+		}
+		items = append(items, item)
+	}
+
+	return &dto.WebhookDeliveryListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// Dispatch 分发新事件并投递到期的记录
+func (s *webhookService) Dispatch(ctx context.Context) (int, error) {
+	if err := s.fanOut(ctx); err != nil {
+		return 0, fmt.Errorf("failed to fan out events: %w", err)
+	}
+	return s.deliverDue(ctx)
+}
+
+// fanOut 为每个新事件和订阅了该事件的同租户Webhook创建投递记录，并标记事件已分发
+func (s *webhookService) fanOut(ctx context.Context) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context) error {
+		events, err := s.outbox.ListPending(ctx, s.cfg.BatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		webhooks, err := s.webhooks.ListActive(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var deliveries []model.WebhookDelivery
+		ids := make([]uint, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+			for i := range webhooks {
+				if webhooks[i].TenantID != event.TenantID || !webhooks[i].Subscribes(event.Type) {
+					continue
+				}
+				deliveries = append(deliveries, model.WebhookDelivery{
+					TenantID:      event.TenantID,
+					WebhookID:     webhooks[i].ID,
+					EventID:       event.ID,
+					EventType:     event.Type,
+					Status:        model.DeliveryStatusPending,
+					NextAttemptAt: now,
+				})
+			}
+		}
+
+		if err := s.webhooks.CreateDeliveries(ctx, deliveries); err != nil {
+			return err
+		}
+		return s.outbox.MarkDispatched(ctx, ids, now)
+	})
+}
+
+// deliverDue 并发投递到期的记录
+func (s *webhookService) deliverDue(ctx context.Context) (int, error) {
+	due, err := s.webhooks.ListDueDeliveries(ctx, time.Now(), s.cfg.BatchSize)
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	webhookIDs := make([]uint, 0, len(due))
+	eventIDs := make([]uint, 0, len(due))
+	for _, delivery := range due {
+		webhookIDs = append(webhookIDs, delivery.WebhookID)
+		eventIDs = append(eventIDs, delivery.EventID)
+	}
+	webhooks, err := s.webhooks.GetByIDs(ctx, webhookIDs)
+	if err != nil {
+		return 0, err
+	}
+	events, err := s.outbox.GetByIDs(ctx, eventIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	webhookByID := make(map[uint]*model.Webhook, len(webhooks))
+	for i := range webhooks {
+		webhookByID[webhooks[i].ID] = &webhooks[i]
+	}
+	eventByID := make(map[uint]*model.OutboxEvent, len(events))
+	for i := range events {
+		eventByID[events[i].ID] = &events[i]
+	}
+
+	var group errgroup.Group
+	group.SetLimit(s.cfg.Workers)
+	for i := range due {
+		delivery := &due[i]
+		group.Go(func() error {
+			s.deliver(ctx, delivery, webhookByID[delivery.WebhookID], eventByID[delivery.EventID])
+			return nil
+		})
+	}
+	group.Wait()
+	return len(due), nil
+}
+
+// deliver 投递一条记录并保存结果，失败时按退避策略安排重试
+func (s *webhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery, webhook *model.Webhook, event *model.OutboxEvent) {
+	// 领取期限覆盖请求超时，实例在投递中退出时到期后由其他实例重试
+	now := time.Now()
+	claimed, err := s.webhooks.ClaimDelivery(ctx, delivery, now, now.Add(2*s.cfg.Timeout))
+	if err != nil || !claimed {
+		if err != nil && ctx.Err() == nil {
+			s.log.Error("Failed to claim webhook delivery", zap.Uint("delivery_id", delivery.ID), zap.Error(err))
+		}
+		return
+	}
+
+	var status int
+	if webhook == nil || event == nil {
+		err = errors.New("webhook or event no longer exists")
+	} else {
+		status, err = s.send(ctx, delivery, webhook, event)
+	}
+	// 服务停止导致的失败不计入尝试次数，领取到期后重新投递
+	if ctx.Err() != nil {
+		return
+	}
+
+	attemptAt := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &attemptAt
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryStatusSucceeded
+		delivery.DeliveredAt = &attemptAt
+		delivery.Error = ""
+	case delivery.Attempts >= s.cfg.MaxAttempts || webhook == nil || event == nil:
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.Error = truncate(err.Error(), 500)
+	default:
+		delivery.NextAttemptAt = attemptAt.Add(s.backoff(delivery.Attempts))
+		delivery.Error = truncate(err.Error(), 500)
+	}
+
+	if err := s.webhooks.UpdateDelivery(ctx, delivery); err != nil {
+		s.log.Error("Failed to save webhook delivery", zap.Uint("delivery_id", delivery.ID), zap.Error(err))
+		return
+	}
+	if delivery.Status == model.DeliveryStatusFailed {
+		s.log.Warn("Webhook delivery failed permanently",
+			zap.Uint("delivery_id", delivery.ID),
+			zap.Uint("webhook_id", delivery.WebhookID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("error", delivery.Error))
+	}
+}
+
+// send 发送签名的事件请求，2xx视为成功，返回响应状态码
+func (s *webhookService) send(ctx context.Context, delivery *model.WebhookDelivery, webhook *model.Webhook, event *model.OutboxEvent) (int, error) {
+	body, err := json.Marshal(dto.WebhookEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		TenantID:   event.TenantID,
+		OccurredAt: event.CreatedAt,
+		Data:       json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.Type)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(webhook.Secret, time.Now().Unix(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff 第attempts次失败后的重试间隔，按次数翻倍直到上限
+func (s *webhookService) backoff(attempts int) time.Duration {
+	delay := s.cfg.Backoff
+	for i := 1; i < attempts && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.MaxBackoff {
+		delay = s.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// WebhookSignature 计算Webhook请求的签名头，接收方用相同方法计算并比较，同时校验时间戳防止重放
+func WebhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// allowedNetworks 解析允许投递的内网网段，单个IP视为只包含该地址的网段，无效的条目记录日志后忽略
+func allowedNetworks(entries []string, log logger.Logger) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				log.Error("Invalid webhook allowed network", zap.String("network", entry), zap.Error(err))
+				continue
+			}
+			addr = addr.Unmap()
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// webhookDialControl 在建立连接前拒绝内网、回环、链路本地和未指定地址，allowed中的网段除外
+func webhookDialControl(allowed []netip.Prefix) func(network, address string, conn syscall.RawConn) error {
+	return func(network, address string, conn syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		addr := addrPort.Addr().Unmap()
+		for _, prefix := range allowed {
+			if prefix.Contains(addr) {
+				return nil
+			}
+		}
+		if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsUnspecified() {
+			return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, addr)
+		}
+		return nil
+	}
+}
+
+// normalizeWebhook 校验订阅地址和事件类型，返回去重后以逗号分隔的事件列表
+func normalizeWebhook(rawURL string, events []string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", ErrInvalidWebhookURL
+	}
+
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		known := false
+		for _, eventType := range EventTypes {
+			known = known || event == eventType
+		}
+		if !known {
+			return "", fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// webhookResponse 转换订阅信息响应（不包含密钥）
+func webhookResponse(webhook *model.Webhook) *dto.WebhookResponse {
+	return &dto.WebhookResponse{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		Events:      webhook.EventTypes(),
+		Description: webhook.Description,
+		Active:      webhook.Active,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+}
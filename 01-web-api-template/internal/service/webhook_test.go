@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-practical-roadmap/01-web-api-template/internal/api/dto"
+	"go-practical-roadmap/01-web-api-template/internal/config"
+	"go-practical-roadmap/01-web-api-template/internal/model"
+	"go-practical-roadmap/01-web-api-template/internal/pkg/tenant"
+	"go-practical-roadmap/01-web-api-template/internal/repository"
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// receivedWebhook 测试服务器收到的请求
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver 按顺序返回预设状态码并记录请求的测试服务器
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedWebhook
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedWebhook{header: req.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// failingOutbox 写入事件总是失败的发件箱，用于验证业务数据随之回滚
+type failingOutbox struct {
+	EventOutbox
+}
+
+func (failingOutbox) Publish(ctx context.Context, event DomainEvent) error {
+	return errors.New("outbox unavailable")
+}
+
+// setupEventTest 创建启用租户隔离、写入发件箱的用户服务和Webhook服务
+func setupEventTest(t *testing.T, cfg config.WebhookConfig) (UserService, WebhookService, *gorm.DB, uint, uint) {
+	t.Helper()
+
+	database, _, acme, globex := setupTenants(t)
+	outboxRepo := repository.NewOutboxRepository(database)
+	events := NewEventOutbox(repository.NewTransactor(database), outboxRepo)
+	sessions := NewSessionService(repository.NewSessionRepository(database), NewNopAuditService(), testTokens, logger.NewNop())
+	userService := NewUserService(repository.NewUserRepository(database), sessions, NewNopAuditService(), nil, events, newTestPasswords(t), logger.NewNop())
+	webhookService := NewWebhookService(repository.NewTransactor(database), outboxRepo,
+		repository.NewWebhookRepository(database), NewNopAuditService(), cfg, logger.NewNop())
+	return userService, webhookService, database, acme, globex
+}
+
+func TestUserService_PublishesEventsWithChanges(t *testing.T) {
+	userService, _, database, acme, _ := setupEventTest(t, config.WebhookConfig{})
+	ctx := tenant.WithTenant(context.Background(), acme)
+
+	user, err := userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	_, err = userService.ChangeEmail(ctx, user.ID, &dto.ChangeEmailRequest{Email: "alice@new.example.com", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	_, err = userService.ChangeEmail(ctx, user.ID, &dto.ChangeEmailRequest{Email: "alice@new.example.com", Password: "password123"})
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(ctx, user.ID))
+
+	var events []model.OutboxEvent
+	require.NoError(t, database.WithContext(ctx).Order("id").Find(&events).Error)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventUserRegistered, events[0].Type)
+	assert.Equal(t, EventUserEmailChanged, events[1].Type)
+	assert.Equal(t, EventUserDeleted, events[2].Type)
+	for _, event := range events {
+		assert.Equal(t, acme, event.TenantID)
+		assert.Equal(t, strconv.FormatUint(uint64(user.ID), 10), event.SubjectID)
+	}
+
+	var data dto.UserEventData
+	require.NoError(t, json.Unmarshal([]byte(events[1].Payload), &data))
+	assert.Equal(t, dto.UserEventData{UserID: user.ID, Username: "alice", Email: "alice@new.example.com", OldEmail: "alice@example.com"}, data)
+}
+
+func TestUserService_RollsBackWhenEventFails(t *testing.T) {
+	database, _, acme, _ := setupTenants(t)
+	events := failingOutbox{NewEventOutbox(repository.NewTransactor(database), repository.NewOutboxRepository(database))}
+	userRepo := repository.NewUserRepository(database)
+	userService := NewUserService(userRepo, nil, NewNopAuditService(), nil, events, newTestPasswords(t), logger.NewNop())
+	ctx := tenant.WithTenant(context.Background(), acme)
+
+	_, err := userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.Error(t, err)
+
+	_, err = userRepo.GetByUsername(ctx, "alice")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestWebhookService_DeliversSignedEventsWithRetry(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	userService, webhookService, _, acme, globex := setupEventTest(t, config.WebhookConfig{Backoff: time.Millisecond, AllowedNetworks: []string{"127.0.0.1"}})
+	acmeCtx := tenant.WithTenant(context.Background(), acme)
+	globexCtx := tenant.WithTenant(context.Background(), globex)
+	dispatchCtx := tenant.Unscoped(context.Background())
+
+	_, err := webhookService.Create(acmeCtx, &dto.CreateWebhookRequest{URL: "ftp://example.com"})
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	_, err = webhookService.Create(acmeCtx, &dto.CreateWebhookRequest{URL: server.URL, Events: []string{"user.unknown"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhookEvent)
+
+	webhook, err := webhookService.Create(acmeCtx, &dto.CreateWebhookRequest{URL: server.URL, Events: []string{EventUserRegistered}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(webhook.Secret, "whsec_"))
+	// 其他租户的订阅收不到acme的事件
+	_, err = webhookService.Create(globexCtx, &dto.CreateWebhookRequest{URL: server.URL + "/globex"})
+	require.NoError(t, err)
+
+	user, err := userService.Register(acmeCtx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+	// 未订阅的事件不投递
+	_, err = userService.ChangeEmail(acmeCtx, user.ID, &dto.ChangeEmailRequest{Email: "alice@new.example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	attempted, err := webhookService.Dispatch(dispatchCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+
+	deliveries, err := webhookService.ListDeliveries(acmeCtx, webhook.ID, &dto.WebhookDeliveryQuery{})
+	require.NoError(t, err)
+	require.Len(t, deliveries.Items, 1)
+	assert.Equal(t, model.DeliveryStatusPending, deliveries.Items[0].Status)
+	assert.Equal(t, 1, deliveries.Items[0].Attempts)
+	assert.Equal(t, http.StatusInternalServerError, deliveries.Items[0].ResponseStatus)
+	assert.NotNil(t, deliveries.Items[0].NextAttemptAt)
+
+	time.Sleep(5 * time.Millisecond)
+	attempted, err = webhookService.Dispatch(dispatchCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+
+	deliveries, err = webhookService.ListDeliveries(acmeCtx, webhook.ID, &dto.WebhookDeliveryQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryStatusSucceeded, deliveries.Items[0].Status)
+	assert.Equal(t, 2, deliveries.Items[0].Attempts)
+	assert.NotNil(t, deliveries.Items[0].DeliveredAt)
+
+	// 重试使用相同的投递ID和请求体，签名可用密钥验证
+	require.Len(t, receiver.requests, 2)
+	request := receiver.requests[1]
+	assert.Equal(t, receiver.requests[0].body, request.body)
+	assert.Equal(t, receiver.requests[0].header.Get(WebhookDeliveryHeader), request.header.Get(WebhookDeliveryHeader))
+	assert.Equal(t, EventUserRegistered, request.header.Get(WebhookEventHeader))
+
+	signature := request.header.Get(WebhookSignatureHeader)
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookSignature(webhook.Secret, timestamp, request.body), signature)
+	assert.NotEqual(t, WebhookSignature("whsec_other", timestamp, request.body), signature)
+
+	var event dto.WebhookEvent
+	require.NoError(t, json.Unmarshal(request.body, &event))
+	assert.Equal(t, EventUserRegistered, event.Type)
+	assert.Equal(t, acme, event.TenantID)
+	assert.JSONEq(t, `{"user_id":`+strconv.FormatUint(uint64(user.ID), 10)+`,"username":"alice","email":"alice@example.com"}`, string(event.Data))
+
+	// 已投递的事件不会重复发送
+	attempted, err = webhookService.Dispatch(dispatchCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempted)
+}
+
+func TestWebhookService_StopsAfterMaxAttempts(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	userService, webhookService, _, acme, _ := setupEventTest(t, config.WebhookConfig{Backoff: time.Millisecond, MaxAttempts: 2, AllowedNetworks: []string{"127.0.0.0/8"}})
+	ctx := tenant.WithTenant(context.Background(), acme)
+	dispatchCtx := tenant.Unscoped(context.Background())
+
+	webhook, err := webhookService.Create(ctx, &dto.CreateWebhookRequest{URL: server.URL})
+	require.NoError(t, err)
+	_, err = userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := webhookService.Dispatch(dispatchCtx)
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	deliveries, err := webhookService.ListDeliveries(ctx, webhook.ID, &dto.WebhookDeliveryQuery{})
+	require.NoError(t, err)
+	require.Len(t, deliveries.Items, 1)
+	assert.Equal(t, model.DeliveryStatusFailed, deliveries.Items[0].Status)
+	assert.Equal(t, 2, deliveries.Items[0].Attempts)
+	assert.Equal(t, "unexpected response status 502", deliveries.Items[0].Error)
+	assert.Len(t, receiver.requests, 2)
+}
+
+func TestWebhookService_BlocksPrivateAddresses(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	userService, webhookService, _, acme, _ := setupEventTest(t, config.WebhookConfig{Backoff: time.Millisecond, MaxAttempts: 1})
+	ctx := tenant.WithTenant(context.Background(), acme)
+
+	webhook, err := webhookService.Create(ctx, &dto.CreateWebhookRequest{URL: server.URL})
+	require.NoError(t, err)
+	_, err = userService.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	_, err = webhookService.Dispatch(tenant.Unscoped(context.Background()))
+	require.NoError(t, err)
+
+	// 订阅地址指向回环地址，连接在拨号时被拒绝
+	deliveries, err := webhookService.ListDeliveries(ctx, webhook.ID, &dto.WebhookDeliveryQuery{})
+	require.NoError(t, err)
+	require.Len(t, deliveries.Items, 1)
+	assert.Equal(t, model.DeliveryStatusFailed, deliveries.Items[0].Status)
+	assert.Contains(t, deliveries.Items[0].Error, ErrWebhookAddressBlocked.Error())
+	assert.Empty(t, receiver.requests)
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	control := webhookDialControl(allowedNetworks([]string{"10.1.0.0/16", "192.168.1.5", "not-a-network"}, logger.NewNop()))
+
+	tests := []struct {
+		address string
+		blocked bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "127.0.0.1:80", blocked: true},
+		{address: "[::1]:80", blocked: true},
+		{address: "[::ffff:127.0.0.1]:80", blocked: true},
+		{address: "10.0.0.1:80", blocked: true},
+		{address: "172.16.0.1:80", blocked: true},
+		{address: "169.254.169.254:80", blocked: true},
+		{address: "[fe80::1]:80", blocked: true},
+		{address: "0.0.0.0:80", blocked: true},
+		{address: "10.1.2.3:80"},
+		{address: "192.168.1.5:80"},
+		{address: "192.168.1.6:80", blocked: true},
+	}
+	for _, tt := range tests {
+		err := control("tcp", tt.address, nil)
+		if tt.blocked {
+			assert.ErrorIs(t, err, ErrWebhookAddressBlocked, tt.address)
+		} else {
+			assert.NoError(t, err, tt.address)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// consistencyKey 上下文中读一致性标记的键
+type consistencyKey struct{}
+
+// consistency 读一致性标记，primary为true时读操作使用主库
+type consistency struct {
+	primary atomic.Bool
+}
+
+// WithPrimary 返回读操作全部使用主库的上下文，用于读取后立即写回、
+// 或必须读到最新数据的场景
+func WithPrimary(ctx context.Context) context.Context {
+	c := &consistency{}
+	c.primary.Store(true)
+	return context.WithValue(ctx, consistencyKey{}, c)
+}
+
+// WithReadYourWrites 返回读己之写的上下文：在该上下文中发生写操作后，之后的读操作使用主库
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(consistencyKey{}).(*consistency); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, consistencyKey{}, &consistency{})
+}
+
+// UsesPrimary 上下文中的读操作是否使用主库
+func UsesPrimary(ctx context.Context) bool {
+	c, ok := ctx.Value(consistencyKey{}).(*consistency)
+	return ok && c.primary.Load()
+}
+
+// registerConsistencyCallbacks 注册读一致性回调：记录上下文中的写操作，需要时将读操作切换回主库
+func registerConsistencyCallbacks(database *gorm.DB, primary gorm.ConnPool) error {
+	// 在dbresolver选择副本之后执行
+	routeRead := func(tx *gorm.DB) {
+		if _, inTransaction := tx.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+			return
+		}
+		if UsesPrimary(tx.Statement.Context) {
+			tx.Statement.ConnPool = primary
+		}
+	}
+
+	callback := database.Callback()
+	if err := callback.Query().Before("gorm:query").Register("db:consistency", routeRead); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("db:consistency", routeRead); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("db:consistency", recordWrite); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("db:consistency", recordWrite); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Register("db:consistency", recordWrite)
+}
+
+// recordWrite 写操作成功后，同一上下文中的读操作改用主库
+func recordWrite(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.RowsAffected == 0 {
+		return
+	}
+	if c, ok := tx.Statement.Context.Value(consistencyKey{}).(*consistency); ok {
+		c.primary.Store(true)
+	}
+}
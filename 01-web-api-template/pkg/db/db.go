@@ -1,61 +1,78 @@
 package db
 
 import (
+	"database/sql"
+	"time"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// DB 数据库连接实例
-var DB *gorm.DB
+// PoolConfig 连接池配置，为0的字段使用database/sql的默认值
+type PoolConfig struct {
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+}
 
-// Connect 连接数据库
-func Connect(dsn string, driver string) error {
-	var err error
+// Connect 连接数据库，每次调用创建按pool配置的独立连接池，由调用方通过Close关闭
+func Connect(dsn string, driver string, pool PoolConfig) (*gorm.DB, error) {
+	var (
+		database *gorm.DB
+		err      error
+	)
 
 	switch driver {
 	case "sqlite":
-		DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		database, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	case "postgres":
-		DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		database, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	case "mysql":
-		DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+		database, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	default:
 		// 默认使用sqlite
-		DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		database, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	}
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 获取通用数据库对象
-	sqlDB, err := DB.DB()
+	sqlDB, err := database.DB()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 设置连接池
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	configurePool(sqlDB, pool)
 
-	return nil
+	return database, nil
+}
+
+// configurePool 设置连接池，主库和副本使用相同的设置
+func configurePool(sqlDB *sql.DB, pool PoolConfig) {
+	if pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
 }
 
 // Close 关闭数据库连接
-func Close() error {
-	if DB != nil {
-		sqlDB, err := DB.DB()
+func Close(database *gorm.DB) error {
+	if database != nil {
+		sqlDB, err := database.DB()
 		if err != nil {
 			return err
 		}
 		return sqlDB.Close()
 	}
 	return nil
-}
-
-// GetDB 获取数据库实例
-func GetDB() *gorm.DB {
-	return DB
 }
\ No newline at end of file
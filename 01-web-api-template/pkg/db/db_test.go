@@ -0,0 +1,34 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect_ConfiguresPool(t *testing.T) {
+	dir := t.TempDir()
+	pool := PoolConfig{MaxIdleConns: 2, MaxOpenConns: 7, ConnMaxLifetime: time.Minute}
+
+	database, err := Connect(filepath.Join(dir, "primary.db"), "sqlite", pool)
+	require.NoError(t, err)
+	t.Cleanup(func() { Close(database) })
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 7, sqlDB.Stats().MaxOpenConnections)
+
+	// 副本使用相同的连接池设置
+	openSQLite(t, filepath.Join(dir, "replica.db"), "replica")
+	replicas, err := UseReplicas(database, ReplicaConfig{
+		Driver:              "sqlite",
+		DSNs:                []string{filepath.Join(dir, "replica.db")},
+		HealthCheckInterval: time.Hour,
+		Pool:                pool,
+	}, &item{})
+	require.NoError(t, err)
+	t.Cleanup(func() { replicas.Close() })
+	assert.Equal(t, 7, replicas.replicas[0].conn.Stats().MaxOpenConnections)
+}
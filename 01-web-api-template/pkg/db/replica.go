@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-practical-roadmap/01-web-api-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ReplicaConfig 只读副本配置
+type ReplicaConfig struct {
+	Driver              string
+	DSNs                []string
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+	// Pool 副本的连接池配置，通常与主库相同
+	Pool PoolConfig
+	// Logger 记录副本健康状态的变化，为nil时不输出
+	Logger logger.Logger
+}
+
+// Replicas 读写分离：指定表的查询分发到健康的只读副本，写操作和事务使用主库。
+// 全部副本不可用时查询回退到主库
+type Replicas struct {
+	replicas []*replica
+	next     atomic.Uint64
+	log      logger.Logger
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// replica 单个只读副本及其健康状态
+type replica struct {
+	index   int
+	conn    *sql.DB
+	healthy atomic.Bool
+}
+
+// UseReplicas 为tables（模型或表名）启用读写分离，并按cfg.HealthCheckInterval定期检查副本。
+// 其他表的读写仍只使用主库
+func UseReplicas(database *gorm.DB, cfg ReplicaConfig, tables ...interface{}) (*Replicas, error) {
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 5 * time.Second
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = 2 * time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.NewNop()
+	}
+
+	primary, err := database.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replicas{log: cfg.Logger, stop: make(chan struct{}), done: make(chan struct{})}
+	dialectors := make([]gorm.Dialector, 0, len(cfg.DSNs)+1)
+	for i, dsn := range cfg.DSNs {
+		conn, err := sql.Open(driverName(cfg.Driver), dsn)
+		if err != nil {
+			r.closeConns()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		configurePool(conn, cfg.Pool)
+		rep := &replica{index: i, conn: conn}
+		r.replicas = append(r.replicas, rep)
+		dialectors = append(dialectors, dialector(cfg.Driver, conn))
+	}
+	// 主库作为最后一个候选，全部副本不可用时由策略选中。
+	// 只有一个候选时dbresolver不经过策略，这样也保证了单副本时的故障切换
+	dialectors = append(dialectors, dialector(cfg.Driver, primary))
+
+	// 启动时副本不可用不影响服务，由健康检查决定是否使用
+	r.check(cfg.HealthCheckTimeout)
+	automaticPing := database.Config.DisableAutomaticPing
+	database.Config.DisableAutomaticPing = true
+	err = database.Use(dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: r}, tables...))
+	database.Config.DisableAutomaticPing = automaticPing
+	if err != nil {
+		r.closeConns()
+		return nil, fmt.Errorf("failed to register replicas: %w", err)
+	}
+
+	if err := registerConsistencyCallbacks(database, primary); err != nil {
+		r.closeConns()
+		return nil, err
+	}
+
+	go r.run(cfg.HealthCheckInterval, cfg.HealthCheckTimeout)
+	return r, nil
+}
+
+// Resolve 实现dbresolver.Policy，在健康的副本间轮询，全部不可用时返回主库
+func (r *Replicas) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return connPools[rep.index]
+		}
+	}
+	return connPools[len(connPools)-1]
+}
+
+// Healthy 当前健康的副本数
+func (r *Replicas) Healthy() int {
+	healthy := 0
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// Close 停止健康检查并关闭副本连接
+func (r *Replicas) Close() error {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+	return r.closeConns()
+}
+
+// run 定期检查副本
+func (r *Replicas) run(interval, timeout time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.check(timeout)
+		}
+	}
+}
+
+// check 检查全部副本的连通性，状态变化时记录日志
+func (r *Replicas) check(timeout time.Duration) {
+	for _, rep := range r.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := rep.conn.PingContext(ctx)
+		cancel()
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			r.log.Info("Database replica is healthy", zap.Int("replica", rep.index))
+		} else {
+			r.log.Warn("Database replica is unhealthy, reads fail over", zap.Int("replica", rep.index), zap.Error(err))
+		}
+	}
+}
+
+// closeConns 关闭副本连接
+func (r *Replicas) closeConns() error {
+	var firstErr error
+	for _, rep := range r.replicas {
+		if err := rep.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// driverName database/sql驱动名
+func driverName(driver string) string {
+	switch driver {
+	case "postgres":
+		return "pgx"
+	case "mysql":
+		return "mysql"
+	default:
+		return "sqlite3"
+	}
+}
+
+// dialector 基于已有连接创建Gorm方言
+func dialector(driver string, conn gorm.ConnPool) gorm.Dialector {
+	switch driver {
+	case "postgres":
+		return postgres.New(postgres.Config{Conn: conn})
+	case "mysql":
+		// 副本不可用时不能查询版本，跳过初始化查询
+		return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+	default:
+		return &sqlite.Dialector{Conn: conn}
+	}
+}
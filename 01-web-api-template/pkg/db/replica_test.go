@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// item 启用读写分离的表
+type item struct {
+	ID   uint
+	Name string
+}
+
+// other 只使用主库的表
+type other struct {
+	ID   uint
+	Name string
+}
+
+// openSQLite 打开SQLite数据库并写入一行数据，用于区分读操作落在哪个库
+func openSQLite(t *testing.T, path, name string) *gorm.DB {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&item{}, &other{}))
+	require.NoError(t, database.Create(&item{ID: 1, Name: name}).Error)
+	require.NoError(t, database.Create(&other{ID: 1, Name: name}).Error)
+	t.Cleanup(func() {
+		sqlDB, _ := database.DB()
+		sqlDB.Close()
+	})
+	return database
+}
+
+// readItem 读取第一行数据的名称
+func readItem(t *testing.T, database *gorm.DB, ctx context.Context, model interface{}) string {
+	t.Helper()
+
+	var name string
+	require.NoError(t, database.WithContext(ctx).Model(model).Where("id = ?", 1).Pluck("name", &name).Error)
+	return name
+}
+
+func TestReplicas_RoutesReadsAndFailsOver(t *testing.T) {
+	dir := t.TempDir()
+	primary := openSQLite(t, filepath.Join(dir, "primary.db"), "primary")
+	openSQLite(t, filepath.Join(dir, "replica.db"), "replica")
+
+	replicas, err := UseReplicas(primary, ReplicaConfig{
+		Driver:              "sqlite",
+		DSNs:                []string{filepath.Join(dir, "replica.db")},
+		HealthCheckInterval: time.Hour,
+	}, &item{})
+	require.NoError(t, err)
+	t.Cleanup(func() { replicas.Close() })
+	assert.Equal(t, 1, replicas.Healthy())
+
+	ctx := context.Background()
+	assert.Equal(t, "replica", readItem(t, primary, ctx, &item{}))
+	// 未启用读写分离的表只使用主库
+	assert.Equal(t, "primary", readItem(t, primary, ctx, &other{}))
+	assert.Equal(t, "primary", readItem(t, primary, WithPrimary(ctx), &item{}))
+
+	// 事务中的读操作使用主库
+	require.NoError(t, primary.Transaction(func(tx *gorm.DB) error {
+		assert.Equal(t, "primary", readItem(t, tx, ctx, &item{}))
+		return nil
+	}))
+
+	// 读己之写：同一上下文中写入后改读主库
+	ryw := WithReadYourWrites(ctx)
+	assert.Equal(t, "replica", readItem(t, primary, ryw, &item{}))
+	require.NoError(t, primary.WithContext(ryw).Model(&item{}).Where("id = ?", 1).Update("name", "updated").Error)
+	assert.Equal(t, "updated", readItem(t, primary, ryw, &item{}))
+	assert.Equal(t, "replica", readItem(t, primary, ctx, &item{}))
+
+	// 副本不可用时回退到主库
+	require.NoError(t, replicas.replicas[0].conn.Close())
+	replicas.check(time.Second)
+	assert.Equal(t, 0, replicas.Healthy())
+	assert.Equal(t, "updated", readItem(t, primary, ctx, &item{}))
+}
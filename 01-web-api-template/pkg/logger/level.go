@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels 运行时可调整的日志级别：根级别对全部日志生效，命名日志记录器可以单独覆盖
+type Levels struct {
+	root zap.AtomicLevel
+
+	mu    sync.RWMutex
+	named map[string]*namedLevel
+}
+
+// namedLevel 命名日志记录器的级别，未覆盖时跟随上一级（db.replica跟随db，db跟随根级别）
+type namedLevel struct {
+	parent   zapcore.LevelEnabler
+	level    zap.AtomicLevel
+	override atomic.Bool
+}
+
+// Enabled 实现zapcore.LevelEnabler
+func (l *namedLevel) Enabled(level zapcore.Level) bool {
+	if l.override.Load() {
+		return l.level.Enabled(level)
+	}
+	return l.parent.Enabled(level)
+}
+
+// NewLevels 创建日志级别集合
+func NewLevels(root zapcore.Level) *Levels {
+	return &Levels{root: zap.NewAtomicLevelAt(root), named: make(map[string]*namedLevel)}
+}
+
+// ParseLevel 解析日志级别名称
+func ParseLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "debug":
+		return zap.DebugLevel, nil
+	case "info":
+		return zap.InfoLevel, nil
+	case "warn":
+		return zap.WarnLevel, nil
+	case "error":
+		return zap.ErrorLevel, nil
+	default:
+		return zap.InfoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// Set 设置日志级别，name为空时设置根级别
+func (l *Levels) Set(name, level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		l.root.SetLevel(lvl)
+		return nil
+	}
+
+	named := l.enabler(name)
+	named.level.SetLevel(lvl)
+	named.override.Store(true)
+	return nil
+}
+
+// Reset 取消命名日志记录器的级别覆盖，恢复跟随上一级
+func (l *Levels) Reset(name string) {
+	l.enabler(name).override.Store(false)
+}
+
+// Root 根级别
+func (l *Levels) Root() string {
+	return l.root.Level().String()
+}
+
+// Overrides 单独设置了级别的命名日志记录器
+func (l *Levels) Overrides() map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	overrides := make(map[string]string)
+	for name, named := range l.named {
+		if named.override.Load() {
+			overrides[name] = named.level.Level().String()
+		}
+	}
+	return overrides
+}
+
+// Names 已创建的命名日志记录器
+func (l *Levels) Names() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	names := make([]string, 0, len(l.named))
+	for name := range l.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// enabler 返回命名日志记录器的级别，不存在时创建
+func (l *Levels) enabler(name string) *namedLevel {
+	l.mu.RLock()
+	named, ok := l.named[name]
+	l.mu.RUnlock()
+	if ok {
+		return named
+	}
+
+	var parent zapcore.LevelEnabler = l.root
+	if i := strings.LastIndex(name, "."); i > 0 {
+		parent = l.enabler(name[:i])
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if named, ok = l.named[name]; !ok {
+		named = &namedLevel{parent: parent, level: zap.NewAtomicLevel()}
+		l.named[name] = named
+	}
+	return named
+}
+
+// levelCore 按可调整的级别过滤日志，内层core不再限制级别
+type levelCore struct {
+	zapcore.Core
+	enabler zapcore.LevelEnabler
+}
+
+// Enabled 实现zapcore.Core
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.enabler.Enabled(level)
+}
+
+// With 实现zapcore.Core
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), enabler: c.enabler}
+}
+
+// Check 实现zapcore.Core，级别未开启的日志不进入采样计数
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enabler.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
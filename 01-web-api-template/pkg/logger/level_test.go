@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevels_RuntimeAndNamed(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	levels := NewLevels(zap.InfoLevel)
+	root := newZapLogger(core, levels, SamplingConfig{})
+	httpLogger := root.Named("http")
+
+	root.Debug("dropped")
+	root.Info("kept")
+	httpLogger.Debug("dropped")
+	assert.Equal(t, 1, logs.Len())
+
+	// 运行时调低根级别，未单独设置的命名日志记录器跟随
+	require.NoError(t, levels.Set("", "debug"))
+	root.Debug("kept")
+	httpLogger.Debug("kept")
+	assert.Equal(t, 3, logs.Len())
+	assert.Equal(t, "http", logs.All()[2].LoggerName)
+
+	// 单独设置命名日志记录器的级别
+	require.NoError(t, levels.Set("http", "warn"))
+	httpLogger.Info("dropped")
+	root.Info("kept")
+	assert.Equal(t, 4, logs.Len())
+	assert.Equal(t, map[string]string{"http": "warn"}, levels.Overrides())
+	assert.Equal(t, []string{"http"}, levels.Names())
+
+	// 嵌套的日志记录器未单独设置时跟随上一级
+	replicaLogger := root.Named("db").Named("replica")
+	assert.Equal(t, []string{"db", "db.replica", "http"}, levels.Names())
+	require.NoError(t, levels.Set("db", "error"))
+	replicaLogger.Warn("dropped")
+	replicaLogger.Error("kept")
+	assert.Equal(t, 5, logs.Len())
+	assert.Equal(t, "db.replica", logs.All()[4].LoggerName)
+	levels.Reset("db")
+
+	levels.Reset("http")
+	httpLogger.Info("kept")
+	assert.Equal(t, 6, logs.Len())
+	assert.Empty(t, levels.Overrides())
+
+	assert.Error(t, levels.Set("http", "verbose"))
+}
+
+func TestNewZapLogger_Sampling(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	levels := NewLevels(zap.InfoLevel)
+	log := newZapLogger(core, levels, SamplingConfig{Initial: 2, Thereafter: 3, Tick: time.Minute})
+
+	for i := 0; i < 8; i++ {
+		log.Info("Request completed")
+	}
+	// 前2条完整记录，之后每3条记录1条
+	assert.Equal(t, 4, logs.FilterMessage("Request completed").Len())
+
+	// 不同消息分别计数
+	log.Info("User registered")
+	assert.Equal(t, 1, logs.FilterMessage("User registered").Len())
+
+	// 未开启级别的日志不占用采样计数
+	for i := 0; i < 5; i++ {
+		log.Debug("Cache miss")
+	}
+	require.NoError(t, levels.Set("", "debug"))
+	log.Debug("Cache miss")
+	log.Debug("Cache miss")
+	assert.Equal(t, 2, logs.FilterMessage("Cache miss").Len())
+}
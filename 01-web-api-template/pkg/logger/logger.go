@@ -1,11 +1,11 @@
 package logger
 
 import (
-	"os"
+	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Logger 日志记录器接口
@@ -15,61 +15,104 @@ type Logger interface {
 	Warn(msg string, fields ...zap.Field)
 	Error(msg string, fields ...zap.Field)
 	Fatal(msg string, fields ...zap.Field)
+	Named(name string) Logger
+	Levels() *Levels
 	Sync() error
 }
 
+// Config 日志记录器配置
+type Config struct {
+	Level  string
+	Format string
+	// Output 未配置Sinks时的输出：stdout或file（写入FilePath）
+	Output   string
+	FilePath string
+	// Rotation 文件输出的默认轮转参数
+	Rotation RotationConfig
+	// Sinks 同时写入的多个输出，配置后忽略Output
+	Sinks []SinkConfig
+	// Levels 命名日志记录器的初始级别，运行时可以通过Levels调整
+	Levels   map[string]string
+	Sampling SamplingConfig
+}
+
+// SamplingConfig 日志采样：每个Tick内同级别、同消息的日志只完整记录前Initial条，之后每Thereafter条记录1条。
+// Initial为0时不采样
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+	Tick       time.Duration
+}
+
 // zapLogger zap日志记录器实现
 type zapLogger struct {
 	logger *zap.Logger
+	// core 不限制级别的输出core，命名日志记录器在其上按各自的级别过滤
+	core   zapcore.Core
+	levels *Levels
+	name   string
 }
 
 // NewLogger 创建新的日志记录器
-func NewLogger(level string, format string, outputPath string) (Logger, error) {
-	var zapLevel zapcore.Level
-	switch level {
-	case "debug":
-		zapLevel = zap.DebugLevel
-	case "info":
-		zapLevel = zap.InfoLevel
-	case "warn":
-		zapLevel = zap.WarnLevel
-	case "error":
-		zapLevel = zap.ErrorLevel
-	default:
-		zapLevel = zap.InfoLevel
+func NewLogger(cfg Config) (Logger, error) {
+	l, err := newLogger(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return l, nil
+}
 
-	// 创建encoder配置
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+// newLogger 按配置创建zap日志记录器
+func newLogger(cfg Config) (*zapLogger, error) {
+	zapLevel, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	levels := NewLevels(zapLevel)
+	for name, level := range cfg.Levels {
+		if err := levels.Set(name, level); err != nil {
+			return nil, fmt.Errorf("logger %q: %w", name, err)
+		}
+	}
 
-	var encoder zapcore.Encoder
-	if format == "json" {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	core, err := newCore(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return newZapLogger(core, levels, cfg.Sampling), nil
+}
 
-	// 创建输出路径
-	var outputs []zapcore.WriteSyncer
-	if outputPath == "stdout" {
-		outputs = append(outputs, zapcore.AddSync(os.Stdout))
-	} else {
-		// 使用lumberjack进行日志轮转
-		lumberJackLogger := &lumberjack.Logger{
-			Filename:   outputPath,
-			MaxSize:    100, // megabytes
-			MaxBackups: 10,
-			MaxAge:     30, // days
+// newZapLogger 在输出core上添加采样和运行时级别过滤
+func newZapLogger(core zapcore.Core, levels *Levels, sampling SamplingConfig) *zapLogger {
+	if sampling.Initial > 0 {
+		tick := sampling.Tick
+		if tick <= 0 {
+			tick = time.Second
 		}
-		outputs = append(outputs, zapcore.AddSync(lumberJackLogger))
+		core = zapcore.NewSamplerWithOptions(core, tick, sampling.Initial, sampling.Thereafter)
 	}
 
-	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(outputs...), zapLevel)
-	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+	// 跳过zapLogger的方法，调用位置指向业务代码
+	logger := zap.New(&levelCore{Core: core, enabler: levels.root}, zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zap.ErrorLevel))
+	return &zapLogger{logger: logger, core: core, levels: levels}
+}
+
+// Named 创建命名日志记录器，级别可以通过Levels单独调整；嵌套的名称以"."连接
+func (l *zapLogger) Named(name string) Logger {
+	fullName := name
+	if l.name != "" {
+		fullName = l.name + "." + name
+	}
+	enabler := l.levels.enabler(fullName)
+	logger := l.logger.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return &levelCore{Core: l.core, enabler: enabler}
+	})).Named(name)
+	return &zapLogger{logger: logger, core: l.core, levels: l.levels, name: fullName}
+}
 
-	return &zapLogger{logger: logger}, nil
+// Levels 返回运行时可调整的日志级别
+func (l *zapLogger) Levels() *Levels {
+	return l.levels
 }
 
 // Debug 记录调试日志
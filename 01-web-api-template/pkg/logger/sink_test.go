@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	appLog := filepath.Join(dir, "app.log")
+	errorLog := filepath.Join(dir, "error.log")
+
+	log, err := NewLogger(Config{
+		Level:    "info",
+		Format:   "console",
+		Rotation: RotationConfig{MaxSize: 10, MaxAge: 7, MaxBackups: 3},
+		Sinks: []SinkConfig{
+			{Type: "file", Path: appLog},
+			{Type: "file", Path: errorLog, Level: "error", Format: "json"},
+		},
+	})
+	require.NoError(t, err)
+
+	log.Debug("debug message")
+	log.Info("info message")
+	log.Error("error message")
+	require.NoError(t, log.Sync())
+
+	// 全部输出都受运行时级别限制，各输出再按自身级别过滤
+	content, err := os.ReadFile(appLog)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "debug message")
+	assert.Contains(t, string(content), "info message")
+	assert.Contains(t, string(content), "error message")
+
+	content, err = os.ReadFile(errorLog)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 1)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "error message", entry["msg"])
+	assert.Equal(t, "ERROR", entry["level"])
+}
+
+func TestNewLogger_InvalidSink(t *testing.T) {
+	_, err := NewLogger(Config{Level: "info", Sinks: []SinkConfig{{Type: "syslog"}}})
+	assert.Error(t, err)
+
+	_, err = NewLogger(Config{Level: "info", Sinks: []SinkConfig{{Type: "file"}}})
+	assert.Error(t, err)
+
+	_, err = NewLogger(Config{Level: "info", Sinks: []SinkConfig{{Type: "stdout", Level: "verbose"}}})
+	assert.Error(t, err)
+
+	// 未配置sinks时使用output
+	_, err = NewLogger(Config{Level: "info", Output: "file"})
+	assert.Error(t, err)
+	_, err = NewLogger(Config{Level: "info", Output: "stdout"})
+	assert.NoError(t, err)
+}
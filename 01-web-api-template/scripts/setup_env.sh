@@ -11,6 +11,6 @@ cd "$(dirname "$0")/../"
 
 echo "Environment setup complete!"
 echo "You can now run:"
-echo "  go build ./cmd/server/main.go  # Build the application"
-echo "  go run ./cmd/server/main.go    # Run the application"
+echo "  go build ./cmd/server          # Build the application"
+echo "  go run ./cmd/server            # Run the application"
 echo "  go test ./...                  # Run all tests"
\ No newline at end of file
@@ -35,7 +35,7 @@ func NewApplication() (*Application, error) {
 	}
 
 	// 初始化日志
-	if err := logger.InitLogger(cfg.Logger.Level, cfg.Logger.Format, cfg.Logger.Output); err != nil {
+	if err := logger.InitLogger(loggerConfig(cfg.Logger)); err != nil {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
@@ -154,4 +154,36 @@ func (a *Application) listenTaskUpdates() {
 			}
 		}
 	}
+}
+
+// loggerConfig 转换日志配置
+func loggerConfig(cfg config.LoggerConfig) logger.Config {
+	sinks := make([]logger.SinkConfig, 0, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		sinks = append(sinks, logger.SinkConfig{
+			Type:   sink.Type,
+			Path:   sink.Path,
+			Level:  sink.Level,
+			Format: sink.Format,
+			Rotation: logger.RotationConfig{
+				MaxSize:    sink.MaxSize,
+				MaxAge:     sink.MaxAge,
+				MaxBackups: sink.MaxBackups,
+			},
+		})
+	}
+
+	return logger.Config{
+		Level:    cfg.Level,
+		Format:   cfg.Format,
+		Output:   cfg.Output,
+		FilePath: cfg.FilePath,
+		Rotation: logger.RotationConfig{
+			MaxSize:    cfg.MaxSize,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		},
+		Sinks: sinks,
+	}
 }
\ No newline at end of file
@@ -51,6 +51,20 @@ type LoggerConfig struct {
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxAge     int    `mapstructure:"max_age"`
 	MaxBackups int    `mapstructure:"max_backups"`
+	Compress   bool   `mapstructure:"compress"`
+	// Sinks 同时写入的多个输出，配置后忽略output和file_path
+	Sinks []LogSinkConfig `mapstructure:"sinks"`
+}
+
+// LogSinkConfig 日志输出配置，轮转参数为0时使用logger中的值
+type LogSinkConfig struct {
+	Type       string `mapstructure:"type"`
+	Path       string `mapstructure:"path"`
+	Level      string `mapstructure:"level"`
+	Format     string `mapstructure:"format"`
+	MaxSize    int    `mapstructure:"max_size"`
+	MaxAge     int    `mapstructure:"max_age"`
+	MaxBackups int    `mapstructure:"max_backups"`
 }
 
 // GlobalConfig 全局配置实例
@@ -85,6 +99,10 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("logger.level", "debug")
 	viper.SetDefault("logger.format", "console")
 	viper.SetDefault("logger.output", "stdout")
+	viper.SetDefault("logger.file_path", "./logs/worker.log")
+	viper.SetDefault("logger.max_size", 100)
+	viper.SetDefault("logger.max_age", 30)
+	viper.SetDefault("logger.max_backups", 10)
 
 	// 设置环境变量前缀
 	viper.SetEnvPrefix("APP")
@@ -1,11 +1,10 @@
 package logger
 
 import (
-	"os"
+	"fmt"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Logger 日志记录器接口
@@ -23,53 +22,49 @@ type zapLogger struct {
 	logger *zap.Logger
 }
 
+// Config 日志记录器配置
+type Config struct {
+	Level  string
+	Format string
+	// Output 未配置Sinks时的输出：stdout或file（写入FilePath）
+	Output   string
+	FilePath string
+	// Rotation 文件输出的默认轮转参数
+	Rotation RotationConfig
+	// Sinks 同时写入的多个输出，配置后忽略Output
+	Sinks []SinkConfig
+}
+
 // NewLogger 创建新的日志记录器
-func NewLogger(level string, format string, outputPath string) (Logger, error) {
-	var zapLevel zapcore.Level
+func NewLogger(cfg Config) (Logger, error) {
+	zapLevel, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	core, err := newCore(cfg, zapLevel)
+	if err != nil {
+		return nil, err
+	}
+	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+
+	return &zapLogger{logger: logger}, nil
+}
+
+// parseLevel 解析日志级别名称
+func parseLevel(level string) (zapcore.Level, error) {
 	switch level {
 	case "debug":
-		zapLevel = zap.DebugLevel
+		return zap.DebugLevel, nil
 	case "info":
-		zapLevel = zap.InfoLevel
+		return zap.InfoLevel, nil
 	case "warn":
-		zapLevel = zap.WarnLevel
+		return zap.WarnLevel, nil
 	case "error":
-		zapLevel = zap.ErrorLevel
+		return zap.ErrorLevel, nil
 	default:
-		zapLevel = zap.InfoLevel
+		return zap.InfoLevel, fmt.Errorf("unknown log level %q", level)
 	}
-
-	// 创建encoder配置
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-
-	var encoder zapcore.Encoder
-	if format == "json" {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	}
-
-	// 创建输出路径
-	var outputs []zapcore.WriteSyncer
-	if outputPath == "stdout" {
-		outputs = append(outputs, zapcore.AddSync(os.Stdout))
-	} else {
-		// 使用lumberjack进行日志轮转
-		lumberJackLogger := &lumberjack.Logger{
-			Filename:   outputPath,
-			MaxSize:    100, // megabytes
-			MaxBackups: 10,
-			MaxAge:     30, // days
-		}
-		outputs = append(outputs, zapcore.AddSync(lumberJackLogger))
-	}
-
-	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(outputs...), zapLevel)
-	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
-
-	return &zapLogger{logger: logger}, nil
 }
 
 // Debug 记录调试日志
@@ -15,10 +15,10 @@ var (
 )
 
 // InitLogger 初始化全局日志记录器
-func InitLogger(level string, format string, outputPath string) error {
+func InitLogger(cfg Config) error {
 	var err error
 	once.Do(func() {
-		GlobalLogger, err = NewLogger(level, format, outputPath)
+		GlobalLogger, err = NewLogger(cfg)
 	})
 	return err
 }
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// SinkConfig 日志输出配置
+type SinkConfig struct {
+	// Type 输出类型：stdout、stderr或file
+	Type string
+	// Path 文件输出的路径
+	Path string
+	// Level 该输出的最低级别，在Config.Level之上进一步过滤，为空时与Config.Level相同
+	Level string
+	// Format 编码格式json或console，为空时使用Config.Format
+	Format string
+	// Rotation 文件轮转参数，为0的字段使用Config.Rotation
+	Rotation RotationConfig
+}
+
+// RotationConfig 文件轮转参数，含义与lumberjack一致
+type RotationConfig struct {
+	MaxSize    int // 单个文件的最大大小，单位MB
+	MaxAge     int // 旧文件保留天数
+	MaxBackups int // 旧文件保留个数
+	Compress   bool
+}
+
+// newCore 为每个输出创建core，同时写入全部输出
+func newCore(cfg Config, level zapcore.Level) (zapcore.Core, error) {
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = []SinkConfig{{Type: cfg.Output, Path: cfg.FilePath}}
+	}
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for i, sink := range sinks {
+		core, err := newSinkCore(sink, cfg, level)
+		if err != nil {
+			return nil, fmt.Errorf("log sink %d: %w", i, err)
+		}
+		cores = append(cores, core)
+	}
+	return zapcore.NewTee(cores...), nil
+}
+
+// newSinkCore 按输出自身的级别和编码创建core，输出的级别不低于日志记录器的级别
+func newSinkCore(sink SinkConfig, cfg Config, level zapcore.Level) (zapcore.Core, error) {
+	if sink.Level != "" {
+		sinkLevel, err := parseLevel(sink.Level)
+		if err != nil {
+			return nil, err
+		}
+		if sinkLevel > level {
+			level = sinkLevel
+		}
+	}
+
+	format := sink.Format
+	if format == "" {
+		format = cfg.Format
+	}
+
+	writer, err := newWriter(sink, cfg.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	return zapcore.NewCore(newEncoder(format), writer, level), nil
+}
+
+// newWriter 创建输出，文件使用lumberjack按大小轮转
+func newWriter(sink SinkConfig, defaults RotationConfig) (zapcore.WriteSyncer, error) {
+	switch sink.Type {
+	case "", "stdout":
+		return zapcore.Lock(os.Stdout), nil
+	case "stderr":
+		return zapcore.Lock(os.Stderr), nil
+	case "file":
+		if sink.Path == "" {
+			return nil, errors.New("file sink requires a path")
+		}
+		rotation := sink.Rotation
+		if rotation.MaxSize == 0 {
+			rotation.MaxSize = defaults.MaxSize
+		}
+		if rotation.MaxAge == 0 {
+			rotation.MaxAge = defaults.MaxAge
+		}
+		if rotation.MaxBackups == 0 {
+			rotation.MaxBackups = defaults.MaxBackups
+		}
+		return zapcore.AddSync(&lumberjack.Logger{
+			Filename:   sink.Path,
+			MaxSize:    rotation.MaxSize,
+			MaxAge:     rotation.MaxAge,
+			MaxBackups: rotation.MaxBackups,
+			Compress:   rotation.Compress || defaults.Compress,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q", sink.Type)
+	}
+}
+
+// newEncoder 创建编码器，json以外的格式使用console
+func newEncoder(format string) zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+
+	if format == "json" {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	workerLog := filepath.Join(dir, "worker.log")
+	errorLog := filepath.Join(dir, "error.log")
+
+	log, err := NewLogger(Config{
+		Level:    "info",
+		Format:   "console",
+		Rotation: RotationConfig{MaxSize: 10, MaxAge: 7, MaxBackups: 3},
+		Sinks: []SinkConfig{
+			// 输出的级别低于日志记录器的级别时不生效
+			{Type: "file", Path: workerLog, Level: "debug"},
+			{Type: "file", Path: errorLog, Level: "error", Format: "json"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	log.Debug("debug message")
+	log.Info("info message")
+	log.Error("error message")
+	log.Sync()
+
+	content, err := os.ReadFile(workerLog)
+	if err != nil {
+		t.Fatalf("Failed to read worker log: %v", err)
+	}
+	if strings.Contains(string(content), "debug message") {
+		t.Error("Expected debug message to be filtered by logger level")
+	}
+	if !strings.Contains(string(content), "info message") || !strings.Contains(string(content), "error message") {
+		t.Errorf("Expected info and error messages in worker log, got %q", content)
+	}
+
+	content, err = os.ReadFile(errorLog)
+	if err != nil {
+		t.Fatalf("Failed to read error log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"msg":"error message"`) {
+		t.Errorf("Expected only the error message as JSON in error log, got %q", content)
+	}
+}
+
+func TestNewLogger_InvalidSink(t *testing.T) {
+	invalid := []Config{
+		{Level: "info", Sinks: []SinkConfig{{Type: "syslog"}}},
+		{Level: "info", Sinks: []SinkConfig{{Type: "file"}}},
+		{Level: "info", Output: "file"},
+		{Level: "verbose"},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewLogger(cfg); err == nil {
+			t.Errorf("Expected error for config %+v", cfg)
+		}
+	}
+}